@@ -0,0 +1,185 @@
+package sarama
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+// tagMemberID is the member ID of a consumer within its consumer group.
+const tagMemberID = "kafka.member.id"
+
+// messageSpans holds the spans of the messages being consumed through wrapped
+// consumer group handlers. Each claim holds the spans of its messages in its
+// own set, released when the handler returns from ConsumeClaim. The spans which
+// are evicted are finished.
+var messageSpans = messaging.Registry{
+	Evict: func(v interface{}) { v.(*messageSpan).get().Finish() },
+}
+
+// messageSpan is the span of a message received through a claim. It is started
+// once the handler receives the message, either by the claim or by
+// MessageContext, whichever comes first.
+type messageSpan struct {
+	once  sync.Once
+	start func() ddtrace.Span
+	span  ddtrace.Span
+}
+
+// get returns the span, starting it if needed.
+func (m *messageSpan) get() ddtrace.Span {
+	m.once.Do(func() { m.span = m.start() })
+	return m.span
+}
+
+// finishMessageSpan finishes the span of msg, if it is not finished yet.
+func finishMessageSpan(msg *sarama.ConsumerMessage) {
+	if m, ok := messageSpans.Take(msg); ok {
+		m.(*messageSpan).get().Finish()
+	}
+}
+
+// MessageContext returns a copy of ctx holding the span of msg, when msg was
+// received through a claim of a handler wrapped by WrapConsumerGroupHandler, so
+// that the handler can create child spans of it using tracer.StartSpanFromContext.
+// Otherwise, ctx is returned.
+func MessageContext(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	if m, ok := messageSpans.Load(msg); ok {
+		return tracer.ContextWithSpan(ctx, m.(*messageSpan).get())
+	}
+	return ctx
+}
+
+type consumerGroupHandler struct {
+	sarama.ConsumerGroupHandler
+	groupID string
+	cfg     *config
+}
+
+// WrapConsumerGroupHandler wraps a sarama.ConsumerGroupHandler of the consumer
+// group groupID, causing each message received through its claims to be traced.
+// The span of a message starts when the handler receives it, and finishes when
+// the handler marks it using the MarkMessage method of the session, receives
+// the next message of the claim or returns from ConsumeClaim, unless
+// WithProcessingSpans is used. Use MessageContext to create child spans of the
+// message spans.
+func WrapConsumerGroupHandler(groupID string, h sarama.ConsumerGroupHandler, opts ...Option) sarama.ConsumerGroupHandler {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &consumerGroupHandler{
+		ConsumerGroupHandler: h,
+		groupID:              groupID,
+		cfg:                  cfg,
+	}
+}
+
+type consumerGroupClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+// Messages returns the read channel for the messages of the claim.
+func (c *consumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+// consumerGroupSession finishes the spans of the messages it marks.
+type consumerGroupSession struct {
+	sarama.ConsumerGroupSession
+}
+
+// MarkMessage marks msg as consumed and finishes its span.
+func (s consumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.ConsumerGroupSession.MarkMessage(msg, metadata)
+	finishMessageSpan(msg)
+}
+
+// ConsumeClaim calls the ConsumeClaim method of the wrapped handler with a claim
+// tracing each of its messages.
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	wrapped := &consumerGroupClaim{
+		ConsumerGroupClaim: claim,
+		messages:           make(chan *sarama.ConsumerMessage),
+	}
+	var pending, spans *messaging.Set
+	if h.cfg.processingSpans {
+		pending = pendingMessages.NewSet()
+		// the handler returned, release the messages it did not process
+		defer pending.Close()
+	} else {
+		spans = messageSpans.NewSet()
+		defer func() {
+			// the handler returned, finish the spans of its last messages
+			for _, m := range spans.Close() {
+				m.(*messageSpan).get().Finish()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer close(wrapped.messages)
+		var prev *sarama.ConsumerMessage
+		for {
+			var msg *sarama.ConsumerMessage
+			select {
+			case m, ok := <-claim.Messages():
+				if !ok {
+					return
+				}
+				msg = m
+			case <-done:
+				return
+			}
+			opts := []tracer.StartSpanOption{
+				tracer.Tag(ext.MessagingKafkaConsumerGroup, h.groupID),
+				tracer.Tag(tagMemberID, session.MemberID()),
+			}
+			if pending != nil {
+				// the span is started and finished by StartProcessing
+				pending.Store(msg, messagePartition(msg), pendingProcessing{cfg: h.cfg, opts: opts})
+				select {
+				case wrapped.messages <- msg:
+					continue
+				case <-done:
+					pending.Take(msg)
+					return
+				}
+			}
+			m := &messageSpan{start: func() ddtrace.Span {
+				// the handler owns the message once received, so the span
+				// context is not injected into its headers
+				return newConsumerSpan(h.cfg, msg, opts...)
+			}}
+			spans.Store(msg, messagePartition(msg), m)
+			select {
+			case wrapped.messages <- msg:
+			case <-done:
+				spans.Take(msg)
+				return
+			}
+			// the handler received the next message, so the previous one was
+			// processed
+			if prev != nil {
+				finishMessageSpan(prev)
+			}
+			m.get()
+			prev = msg
+		}
+	}()
+	err := h.ConsumerGroupHandler.ConsumeClaim(consumerGroupSession{session}, wrapped)
+	// stop tracing the messages of the claim
+	close(done)
+	<-stopped
+	return err
+}
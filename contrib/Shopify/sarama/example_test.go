@@ -1,6 +1,7 @@
 package sarama_test
 
 import (
+	"context"
 	"log"
 
 	saramatrace "github.com/adityayuga/signalfx-go-tracing/contrib/Shopify/sarama"
@@ -48,6 +49,31 @@ func Example_syncProducer() {
 	}
 }
 
+func Example_syncProducerWithContext() {
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer([]string{"localhost:9092"}, cfg)
+	if err != nil {
+		panic(err)
+	}
+	tracedProducer := saramatrace.WrapSyncProducer(cfg, producer)
+	defer tracedProducer.Close()
+
+	// the span of the message is a child of the span of the request
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "request")
+	defer span.Finish()
+
+	msg := &sarama.ProducerMessage{
+		Topic: "some-topic",
+		Value: sarama.StringEncoder("Hello World"),
+	}
+	_, _, err = tracedProducer.SendMessageWithContext(ctx, msg)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func Example_consumer() {
 	consumer, err := sarama.NewConsumer([]string{"localhost:9092"}, nil)
 	if err != nil {
@@ -75,3 +101,38 @@ func Example_consumer() {
 		consumed++
 	}
 }
+
+type exampleHandler struct{}
+
+func (exampleHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (exampleHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+func (exampleHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		// the span of the message can be used as the parent of new spans
+		ctx := saramatrace.MessageContext(sess.Context(), msg)
+		span, _ := tracer.StartSpanFromContext(ctx, "process")
+		log.Printf("Consumed message offset %d\n", msg.Offset)
+		span.Finish()
+		// marking the message finishes its span
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+func Example_consumerGroup() {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_0_0_0
+
+	group, err := sarama.NewConsumerGroup([]string{"localhost:9092"}, "some-group", cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer group.Close()
+
+	handler := saramatrace.WrapConsumerGroupHandler("some-group", exampleHandler{})
+	for {
+		if err := group.Consume(context.Background(), []string{"some-topic"}, handler); err != nil {
+			panic(err)
+		}
+	}
+}
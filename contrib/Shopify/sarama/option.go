@@ -1,8 +1,12 @@
 package sarama
 
 type config struct {
-	serviceName   string
-	analyticsRate float64
+	serviceName     string
+	analyticsRate   float64
+	processingSpans bool
+	clientID        string
+	hashMessageKeys bool
+	batchSpans      bool
 }
 
 func defaults(cfg *config) {
@@ -20,6 +24,30 @@ func WithServiceName(name string) Option {
 	}
 }
 
+// WithClientID sets the Kafka client ID recorded on spans. Producers default to
+// the client ID of their sarama config.
+func WithClientID(id string) Option {
+	return func(cfg *config) {
+		cfg.clientID = id
+	}
+}
+
+// WithHashedMessageKeys tags spans with the SHA-256 hash of the keys of the
+// messages, which allows correlating messages by key without recording keys.
+func WithHashedMessageKeys() Option {
+	return func(cfg *config) {
+		cfg.hashMessageKeys = true
+	}
+}
+
+// WithBatchSpans makes wrapped sync producers trace each call to SendMessages
+// with a span parenting the spans of the sent messages.
+func WithBatchSpans() Option {
+	return func(cfg *config) {
+		cfg.batchSpans = true
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) Option {
 	if on {
@@ -35,3 +63,16 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithProcessingSpans makes wrapped consumers leave the tracing of received
+// messages to StartProcessing, so that consume spans cover the processing of
+// the messages rather than the time between their receptions. Received
+// messages should then be processed using StartProcessing, in order within
+// each partition and before their consumer is closed, for the options of the
+// consumer to apply. Messages may be skipped: they are released once a later
+// message of their partition is processed.
+func WithProcessingSpans() Option {
+	return func(cfg *config) {
+		cfg.processingSpans = true
+	}
+}
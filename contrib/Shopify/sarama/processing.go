@@ -0,0 +1,77 @@
+package sarama
+
+import (
+	"context"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+// pendingProcessing describes how to trace the processing of a message received
+// by a consumer configured with WithProcessingSpans.
+type pendingProcessing struct {
+	cfg  *config
+	opts []tracer.StartSpanOption
+}
+
+// pendingMessages holds the messages received by consumers configured with
+// WithProcessingSpans which are not being processed yet. Each consumer holds
+// its messages in its own set, released when it is closed. The messages which
+// were received before a processed message of the same partition are released
+// too, as they will not be processed.
+var pendingMessages messaging.Registry
+
+// messagePartition returns the partition msg was received from.
+func messagePartition(msg *sarama.ConsumerMessage) messaging.Partition {
+	return messaging.Partition{Topic: msg.Topic, Partition: msg.Partition}
+}
+
+// Processing traces the processing of a consumed message.
+type Processing struct {
+	span ddtrace.Span
+	ctx  context.Context
+}
+
+// StartProcessing starts tracing the processing of msg, which should have been
+// received from a consumer wrapped using WithProcessingSpans, in which case the
+// options of the consumer apply. The span is a child of the span context found
+// in the message headers, or else of the span found in ctx. Done must be called
+// once the processing of the message ends.
+func StartProcessing(ctx context.Context, msg *sarama.ConsumerMessage) *Processing {
+	p := pendingProcessing{cfg: new(config)}
+	if v, ok := pendingMessages.Take(msg); ok {
+		p = v.(pendingProcessing)
+	} else {
+		defaults(p.cfg)
+	}
+	var opts []tracer.StartSpanOption
+	if parent, ok := tracer.SpanFromContext(ctx); ok {
+		// startConsumerSpan gives precedence to a span context found in the
+		// headers
+		opts = append(opts, tracer.ChildOf(parent.Context()))
+	}
+	span := startConsumerSpan(p.cfg, msg, append(opts, p.opts...)...)
+	return &Processing{
+		span: span,
+		ctx:  tracer.ContextWithSpan(ctx, span),
+	}
+}
+
+// Context returns a copy of the context given to StartProcessing holding the
+// span of the message, to be used as the parent of new spans.
+func (p *Processing) Context() context.Context {
+	return p.ctx
+}
+
+// Span returns the span of the message.
+func (p *Processing) Span() ddtrace.Span {
+	return p.span
+}
+
+// Done finishes the span of the message, recording err if it is not nil.
+func (p *Processing) Done(err error) {
+	p.span.FinishWithOptionsExt(tracer.WithError(err))
+}
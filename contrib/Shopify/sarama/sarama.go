@@ -2,6 +2,9 @@
 package sarama // import "github.com/adityayuga/signalfx-go-tracing/contrib/Shopify/sarama"
 
 import (
+	"context"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -9,6 +12,9 @@ import (
 	sarama "gopkg.in/Shopify/sarama.v1"
 )
 
+// tagBatchErrors is the number of messages of a batch which failed to be sent.
+const tagBatchErrors = "kafka.batch.errors"
+
 type partitionConsumer struct {
 	sarama.PartitionConsumer
 	messages chan *sarama.ConsumerMessage
@@ -21,7 +27,9 @@ func (pc *partitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
 }
 
 // WrapPartitionConsumer wraps a sarama.PartitionConsumer causing each received
-// message to be traced.
+// message to be traced. By default, the span of a message is finished when the
+// next message is received; see WithProcessingSpans for finishing it when its
+// processing ends.
 func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.PartitionConsumer {
 	cfg := new(config)
 	defaults(cfg)
@@ -32,29 +40,22 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 		PartitionConsumer: pc,
 		messages:          make(chan *sarama.ConsumerMessage),
 	}
+	var pending *messaging.Set
+	if cfg.processingSpans {
+		pending = pendingMessages.NewSet()
+	}
 	go func() {
 		msgs := pc.Messages()
 		var prev ddtrace.Span
 		for msg := range msgs {
-			// create the next span from the message
-			opts := []tracer.StartSpanOption{
-				tracer.ServiceName(cfg.serviceName),
-				tracer.ResourceName("Consume Topic " + msg.Topic),
-				tracer.SpanType(ext.SpanTypeMessageConsumer),
-				tracer.Tag("partition", msg.Partition),
-				tracer.Tag("offset", msg.Offset),
+			if pending != nil {
+				// the span is started and finished by StartProcessing
+				pending.Store(msg, messagePartition(msg), pendingProcessing{cfg: cfg})
+				wrapped.messages <- msg
+				continue
 			}
-			if cfg.analyticsRate > 0 {
-				opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
-			}
-			// kafka supports headers, so try to extract a span context
-			carrier := NewConsumerMessageCarrier(msg)
-			if spanctx, err := tracer.Extract(carrier); err == nil {
-				opts = append(opts, tracer.ChildOf(spanctx))
-			}
-			next := tracer.StartSpan("kafka.consume", opts...)
-			// reinject the span context so consumers can pick it up
-			tracer.Inject(next.Context(), carrier)
+			// create the next span from the message
+			next := startConsumerSpan(cfg, msg)
 
 			wrapped.messages <- msg
 
@@ -68,6 +69,11 @@ func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) sarama.P
 		if prev != nil {
 			prev.Finish()
 		}
+		// the consumer is closed, release the messages which were not
+		// processed
+		if pending != nil {
+			pending.Close()
+		}
 		close(wrapped.messages)
 	}()
 	return wrapped
@@ -97,6 +103,20 @@ func WrapConsumer(c sarama.Consumer, opts ...Option) sarama.Consumer {
 	}
 }
 
+// A SyncProducer is a traced sarama.SyncProducer which can also parent the
+// spans of the messages it sends with the span found in a context.
+type SyncProducer interface {
+	sarama.SyncProducer
+
+	// SendMessageWithContext calls SendMessage, making the span of the message
+	// a child of the span found in ctx.
+	SendMessageWithContext(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error)
+
+	// SendMessagesWithContext calls SendMessages, making the spans of the
+	// messages children of the span found in ctx.
+	SendMessagesWithContext(ctx context.Context, msgs []*sarama.ProducerMessage) error
+}
+
 type syncProducer struct {
 	sarama.SyncProducer
 	version sarama.KafkaVersion
@@ -105,7 +125,13 @@ type syncProducer struct {
 
 // SendMessage calls sarama.SyncProducer.SendMessage and traces the request.
 func (p *syncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
-	span := startProducerSpan(p.cfg, p.version, msg)
+	return p.SendMessageWithContext(context.Background(), msg)
+}
+
+// SendMessageWithContext calls sarama.SyncProducer.SendMessage and traces the
+// request as a child of the span found in ctx.
+func (p *syncProducer) SendMessageWithContext(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	span := startProducerSpan(ctx, p.cfg, p.version, msg)
 	partition, offset, err = p.SyncProducer.SendMessage(msg)
 	finishProducerSpan(span, partition, offset, err)
 	return partition, offset, err
@@ -113,22 +139,60 @@ func (p *syncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32
 
 // SendMessages calls sarama.SyncProducer.SendMessages and traces the requests.
 func (p *syncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	return p.SendMessagesWithContext(context.Background(), msgs)
+}
+
+// SendMessagesWithContext calls sarama.SyncProducer.SendMessages and traces
+// the requests as children of the span found in ctx.
+func (p *syncProducer) SendMessagesWithContext(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	var batch ddtrace.Span
+	if p.cfg.batchSpans {
+		batch, ctx = startBatchSpan(ctx, p.cfg, msgs)
+	}
 	// although there's only one call made to the SyncProducer, the messages are
 	// treated individually, so we create a span for each one
 	spans := make([]ddtrace.Span, len(msgs))
 	for i, msg := range msgs {
-		spans[i] = startProducerSpan(p.cfg, p.version, msg)
+		spans[i] = startProducerSpan(ctx, p.cfg, p.version, msg)
 	}
 	err := p.SyncProducer.SendMessages(msgs)
+	errs := messageErrors(err)
+	failed := 0
 	for i, span := range spans {
-		finishProducerSpan(span, msgs[i].Partition, msgs[i].Offset, err)
+		msgErr := err
+		if errs != nil {
+			// only the messages listed in the errors failed
+			msgErr = errs[msgs[i]]
+		}
+		if msgErr != nil {
+			failed++
+		}
+		finishProducerSpan(span, msgs[i].Partition, msgs[i].Offset, msgErr)
+	}
+	if batch != nil {
+		batch.SetTag(tagBatchErrors, failed)
+		batch.FinishWithOptionsExt(tracer.WithError(err))
 	}
 	return err
 }
 
+// messageErrors maps the messages which failed to be sent to their error, when
+// err is a sarama.ProducerErrors. It returns nil otherwise.
+func messageErrors(err error) map[*sarama.ProducerMessage]error {
+	perrs, ok := err.(sarama.ProducerErrors)
+	if !ok {
+		return nil
+	}
+	errs := make(map[*sarama.ProducerMessage]error, len(perrs))
+	for _, perr := range perrs {
+		errs[perr.Msg] = perr.Err
+	}
+	return errs
+}
+
 // WrapSyncProducer wraps a sarama.SyncProducer so that all produced messages
 // are traced.
-func WrapSyncProducer(saramaConfig *sarama.Config, producer sarama.SyncProducer, opts ...Option) sarama.SyncProducer {
+func WrapSyncProducer(saramaConfig *sarama.Config, producer sarama.SyncProducer, opts ...Option) SyncProducer {
 	cfg := new(config)
 	defaults(cfg)
 	for _, opt := range opts {
@@ -137,6 +201,9 @@ func WrapSyncProducer(saramaConfig *sarama.Config, producer sarama.SyncProducer,
 	if saramaConfig == nil {
 		saramaConfig = sarama.NewConfig()
 	}
+	if cfg.clientID == "" {
+		cfg.clientID = saramaConfig.ClientID
+	}
 	return &syncProducer{
 		SyncProducer: producer,
 		version:      saramaConfig.Version,
@@ -144,6 +211,33 @@ func WrapSyncProducer(saramaConfig *sarama.Config, producer sarama.SyncProducer,
 	}
 }
 
+// messageContext replaces the metadata of a message given to an AsyncProducer
+// to carry the context of the message.
+type messageContext struct {
+	ctx      context.Context
+	metadata interface{}
+}
+
+// ProducerMessageWithContext sets the context of msg so that, when msg is sent
+// through the Input channel of a wrapped AsyncProducer, its span is a child of
+// the span found in ctx. The metadata of msg is restored before msg is given to
+// the underlying producer. It returns msg.
+func ProducerMessageWithContext(ctx context.Context, msg *sarama.ProducerMessage) *sarama.ProducerMessage {
+	msg.Metadata = &messageContext{ctx: ctx, metadata: msg.Metadata}
+	return msg
+}
+
+// takeMessageContext returns the context set on msg by
+// ProducerMessageWithContext, if any, and restores its metadata.
+func takeMessageContext(msg *sarama.ProducerMessage) context.Context {
+	mc, ok := msg.Metadata.(*messageContext)
+	if !ok {
+		return context.Background()
+	}
+	msg.Metadata = mc.metadata
+	return mc.ctx
+}
+
 type asyncProducer struct {
 	sarama.AsyncProducer
 	input     chan *sarama.ProducerMessage
@@ -168,7 +262,8 @@ func (p *asyncProducer) Errors() <-chan *sarama.ProducerError {
 
 // WrapAsyncProducer wraps a sarama.AsyncProducer so that all produced messages
 // are traced. It requires the underlying sarama Config so we can know whether
-// or not sucesses will be returned.
+// or not sucesses will be returned. See ProducerMessageWithContext for parenting
+// the spans of the messages.
 func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts ...Option) sarama.AsyncProducer {
 	cfg := new(config)
 	defaults(cfg)
@@ -178,6 +273,9 @@ func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts
 	if saramaConfig == nil {
 		saramaConfig = sarama.NewConfig()
 	}
+	if cfg.clientID == "" {
+		cfg.clientID = saramaConfig.ClientID
+	}
 	wrapped := &asyncProducer{
 		AsyncProducer: p,
 		input:         make(chan *sarama.ProducerMessage),
@@ -197,7 +295,8 @@ func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts
 			select {
 			case msg := <-wrapped.input:
 				key := spanKey{msg.Topic, msg.Partition, msg.Offset}
-				span := startProducerSpan(cfg, saramaConfig.Version, msg)
+				ctx := takeMessageContext(msg)
+				span := startProducerSpan(ctx, cfg, saramaConfig.Version, msg)
 				p.Input() <- msg
 				if saramaConfig.Producer.Return.Successes {
 					spans[key] = span
@@ -235,12 +334,86 @@ func WrapAsyncProducer(saramaConfig *sarama.Config, p sarama.AsyncProducer, opts
 	return wrapped
 }
 
-func startProducerSpan(cfg *config, version sarama.KafkaVersion, msg *sarama.ProducerMessage) ddtrace.Span {
+// startConsumerSpan starts the span of a received message and injects its
+// context into the headers of the message, so that consumers can pick it up.
+func startConsumerSpan(cfg *config, msg *sarama.ConsumerMessage, opts ...tracer.StartSpanOption) ddtrace.Span {
+	span := newConsumerSpan(cfg, msg, opts...)
+	tracer.Inject(span.Context(), NewConsumerMessageCarrier(msg))
+	return span
+}
+
+// newConsumerSpan starts the span of a received message, as a child of the span
+// context found in its headers, if any.
+func newConsumerSpan(cfg *config, msg *sarama.ConsumerMessage, opts ...tracer.StartSpanOption) ddtrace.Span {
+	opts = append(opts,
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName("Consume Topic "+msg.Topic),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(ext.MessagingDestination, msg.Topic),
+		tracer.Tag(ext.MessagingPayloadSize, len(msg.Value)),
+		tracer.Tag("partition", msg.Partition),
+		tracer.Tag("offset", msg.Offset),
+	)
+	if cfg.clientID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaClientID, cfg.clientID))
+	}
+	if cfg.hashMessageKeys && len(msg.Key) > 0 {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaMessageKey, messaging.HashKey(msg.Key)))
+	}
+	if cfg.analyticsRate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	// kafka supports headers, so try to extract a span context
+	if spanctx, err := tracer.Extract(NewConsumerMessageCarrier(msg)); err == nil {
+		opts = append(opts, tracer.ChildOf(spanctx))
+	}
+	return tracer.StartSpan("kafka.consume", opts...)
+}
+
+// startBatchSpan starts the span of a batch of messages sent together, which
+// parents the spans of the messages through the returned context.
+func startBatchSpan(ctx context.Context, cfg *config, msgs []*sarama.ProducerMessage) (ddtrace.Span, context.Context) {
+	opts := []tracer.StartSpanOption{
+		tracer.ServiceName(cfg.serviceName),
+		tracer.ResourceName("Produce Batch"),
+		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(ext.MessagingBatchSize, len(msgs)),
+	}
+	if cfg.clientID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaClientID, cfg.clientID))
+	}
+	if cfg.analyticsRate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	return tracer.StartSpanFromContext(ctx, "kafka.produce.batch", opts...)
+}
+
+// startProducerSpan starts the span of msg as a child of the span found in ctx,
+// or else of the span context found in the headers of msg.
+func startProducerSpan(ctx context.Context, cfg *config, version sarama.KafkaVersion, msg *sarama.ProducerMessage) ddtrace.Span {
 	carrier := NewProducerMessageCarrier(msg)
 	opts := []tracer.StartSpanOption{
 		tracer.ServiceName(cfg.serviceName),
 		tracer.ResourceName("Produce Topic " + msg.Topic),
 		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(ext.MessagingDestination, msg.Topic),
+	}
+	if msg.Value != nil {
+		opts = append(opts, tracer.Tag(ext.MessagingPayloadSize, msg.Value.Length()))
+	}
+	if cfg.clientID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaClientID, cfg.clientID))
+	}
+	if cfg.hashMessageKeys && msg.Key != nil {
+		if key, err := msg.Key.Encode(); err == nil && len(key) > 0 {
+			opts = append(opts, tracer.Tag(ext.MessagingKafkaMessageKey, messaging.HashKey(key)))
+		}
 	}
 	if cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
@@ -249,7 +422,7 @@ func startProducerSpan(cfg *config, version sarama.KafkaVersion, msg *sarama.Pro
 	if spanctx, err := tracer.Extract(carrier); err == nil {
 		opts = append(opts, tracer.ChildOf(spanctx))
 	}
-	span := tracer.StartSpan("kafka.produce", opts...)
+	span, _ := tracer.StartSpanFromContext(ctx, "kafka.produce", opts...)
 	if version.IsAtLeast(sarama.V0_11_0_0) {
 		// re-inject the span context so consumers can pick it up
 		tracer.Inject(span.Context(), carrier)
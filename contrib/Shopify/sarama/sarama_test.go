@@ -2,10 +2,13 @@ package sarama
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -67,6 +70,10 @@ func TestConsumer(t *testing.T) {
 
 		assert.Equal(t, int32(0), s.Tag("partition"))
 		assert.Equal(t, int64(0), s.Tag("offset"))
+		assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+		assert.Equal(t, "kafka", s.Tag(ext.MessagingSystem))
+		assert.Equal(t, "test-topic", s.Tag(ext.MessagingDestination))
+		assert.Equal(t, 5, s.Tag(ext.MessagingPayloadSize))
 		assert.Equal(t, "kafka", s.Tag(ext.ServiceName))
 		assert.Equal(t, "Consume Topic test-topic", s.Tag(ext.ResourceName))
 		assert.Equal(t, "queue", s.Tag(ext.SpanType))
@@ -89,6 +96,281 @@ func TestConsumer(t *testing.T) {
 	}
 }
 
+func TestConsumerProcessingSpans(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	broker := sarama.NewMockBroker(t, 0)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("test-topic", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("test-topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("test-topic", 0, sarama.OffsetNewest, 1),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("test-topic", 0, 0, sarama.StringEncoder("hello")).
+			SetMessage("test-topic", 0, 1, sarama.StringEncoder("world")),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	consumer = WrapConsumer(consumer, WithServiceName("my-consumer"), WithProcessingSpans())
+
+	partitionConsumer, err := consumer.ConsumePartition("test-topic", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg1 := <-partitionConsumer.Messages()
+	msg2 := <-partitionConsumer.Messages()
+	// no span is finished until the messages are processed
+	assert.Len(t, mt.FinishedSpans(), 0)
+
+	p := StartProcessing(context.Background(), msg1)
+	child, _ := tracer.StartSpanFromContext(p.Context(), "child")
+	child.Finish()
+	p.Done(nil)
+	StartProcessing(context.Background(), msg2).Done(errors.New("oops"))
+
+	partitionConsumer.Close()
+	// wait for the channel to be closed
+	<-partitionConsumer.Messages()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "child", spans[0].OperationName())
+	assert.Equal(t, spans[1].SpanID(), spans[0].ParentID())
+	for i, s := range spans[1:] {
+		assert.Equal(t, "kafka.consume", s.OperationName())
+		assert.Equal(t, "my-consumer", s.Tag(ext.ServiceName))
+		assert.Equal(t, int64(i), s.Tag("offset"))
+	}
+	assert.Nil(t, spans[1].Tag(ext.Error))
+	assert.Equal(t, "oops", spans[2].Tag(ext.Error).(error).Error())
+}
+
+func TestConsumerProcessingSkipped(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	broker := sarama.NewMockBroker(t, 0)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("test-topic", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("test-topic", 0, sarama.OffsetOldest, 0).
+			SetOffset("test-topic", 0, sarama.OffsetNewest, 1),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("test-topic", 0, 0, sarama.StringEncoder("hello")).
+			SetMessage("test-topic", 0, 1, sarama.StringEncoder("world")),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	consumer = WrapConsumer(consumer, WithServiceName("my-consumer"), WithProcessingSpans())
+
+	partitionConsumer, err := consumer.ConsumePartition("test-topic", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer partitionConsumer.Close()
+	// the first message is filtered out by the application
+	msg1 := <-partitionConsumer.Messages()
+	msg2 := <-partitionConsumer.Messages()
+	StartProcessing(context.Background(), msg2).Done(nil)
+
+	// the skipped message is released while the consumer is still open
+	_, ok := pendingMessages.Load(msg1)
+	assert.False(t, ok)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "my-consumer", spans[0].Tag(ext.ServiceName))
+	assert.Equal(t, int64(1), spans[0].Tag("offset"))
+}
+
+type mockConsumerGroupSession struct {
+	sarama.ConsumerGroupSession
+}
+
+func (mockConsumerGroupSession) MemberID() string                            { return "member-1" }
+func (mockConsumerGroupSession) Context() context.Context                    { return context.Background() }
+func (mockConsumerGroupSession) MarkMessage(*sarama.ConsumerMessage, string) {}
+
+type mockConsumerGroupClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c mockConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+type consumerGroupHandlerFunc func(sarama.ConsumerGroupSession, sarama.ConsumerGroupClaim) error
+
+func (consumerGroupHandlerFunc) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (consumerGroupHandlerFunc) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+func (fn consumerGroupHandlerFunc) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	return fn(sess, claim)
+}
+
+func TestConsumerGroupHandler(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "test-topic", Partition: 1, Offset: 10}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "test-topic", Partition: 1, Offset: 11}
+	close(claim.messages)
+
+	var (
+		children []ddtrace.Span
+		last     *sarama.ConsumerMessage
+	)
+	handler := WrapConsumerGroupHandler("test-group", consumerGroupHandlerFunc(
+		func(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+			for msg := range claim.Messages() {
+				last = msg
+				child, _ := tracer.StartSpanFromContext(MessageContext(sess.Context(), msg), "child")
+				child.Finish()
+				children = append(children, child)
+			}
+			return nil
+		}))
+	err := handler.ConsumeClaim(mockConsumerGroupSession{}, claim)
+	assert.NoError(t, err)
+
+	var consumed []mocktracer.Span
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() == "kafka.consume" {
+			consumed = append(consumed, s)
+		}
+	}
+	assert.Len(t, consumed, 2)
+	assert.Len(t, children, 2)
+	for i, s := range consumed {
+		assert.Equal(t, "test-group", s.Tag(ext.MessagingKafkaConsumerGroup))
+		assert.Equal(t, "member-1", s.Tag("kafka.member.id"))
+		assert.Equal(t, "test-topic", s.Tag(ext.MessagingDestination))
+		assert.Equal(t, int32(1), s.Tag("partition"))
+		assert.Equal(t, int64(10+i), s.Tag("offset"))
+		assert.Equal(t, s.SpanID(), children[i].(mocktracer.Span).ParentID())
+	}
+	// the spans of the claim are released
+	_, ok := messageSpans.Load(last)
+	assert.False(t, ok)
+}
+
+func TestConsumerGroupHandlerMarkMessage(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	for i := 0; i < 3; i++ {
+		claim.messages <- &sarama.ConsumerMessage{Topic: "test-topic", Partition: 1, Offset: int64(10 + i)}
+	}
+	close(claim.messages)
+
+	handler := WrapConsumerGroupHandler("test-group", consumerGroupHandlerFunc(
+		func(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+			for msg := range claim.Messages() {
+				time.Sleep(time.Millisecond)
+				if msg.Offset == 10 {
+					sess.MarkMessage(msg, "")
+					// the span of a marked message is finished
+					assert.Len(t, mt.FinishedSpans(), 1)
+					time.Sleep(time.Millisecond)
+				}
+			}
+			return nil
+		}))
+	err := handler.ConsumeClaim(mockConsumerGroupSession{}, claim)
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+	for i, s := range spans {
+		assert.Equal(t, int64(10+i), s.Tag("offset"))
+		if i > 0 {
+			// spans do not overlap nor include the time waiting for messages
+			assert.False(t, s.StartTime().Before(spans[i-1].FinishTime()))
+		}
+	}
+	assert.True(t, spans[1].StartTime().Sub(spans[0].FinishTime()) >= time.Millisecond,
+		"the span of a marked message does not cover the processing of the handler after marking it")
+}
+
+func TestConsumerGroupHandlerProcessingSpans(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// the producer of the message
+	producer := tracer.StartSpan("produce")
+	producer.Finish()
+	msg := &sarama.ConsumerMessage{Topic: "test-topic", Partition: 1, Offset: 10}
+	carrier := NewConsumerMessageCarrier(msg)
+	assert.NoError(t, tracer.Inject(producer.Context(), carrier))
+	// a message the handler does not process
+	unprocessed := &sarama.ConsumerMessage{Topic: "test-topic", Partition: 1, Offset: 11}
+
+	claim := mockConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- msg
+	claim.messages <- unprocessed
+	close(claim.messages)
+
+	parent := tracer.StartSpan("parent")
+	handler := WrapConsumerGroupHandler("test-group", consumerGroupHandlerFunc(
+		func(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+			msg := <-claim.Messages()
+			<-claim.Messages()
+			_, ok := pendingMessages.Load(unprocessed)
+			assert.True(t, ok)
+			// the span context of the headers takes precedence over ctx
+			ctx := tracer.ContextWithSpan(sess.Context(), parent)
+			StartProcessing(ctx, msg).Done(nil)
+			return nil
+		}), WithProcessingSpans())
+	err := handler.ConsumeClaim(mockConsumerGroupSession{}, claim)
+	assert.NoError(t, err)
+	parent.Finish()
+
+	// the messages of the claim are released once the handler returns
+	_, ok := pendingMessages.Load(unprocessed)
+	assert.False(t, ok)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+	s := spans[1]
+	assert.Equal(t, "kafka.consume", s.OperationName())
+	assert.Equal(t, "test-group", s.Tag(ext.MessagingKafkaConsumerGroup))
+	assert.Equal(t, "member-1", s.Tag("kafka.member.id"))
+	assert.Equal(t, int64(10), s.Tag("offset"))
+	assert.Equal(t, producer.(mocktracer.Span).SpanID(), s.ParentID())
+}
+
 func TestSyncProducer(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -115,10 +397,11 @@ func TestSyncProducer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	producer = WrapSyncProducer(cfg, producer)
+	producer = WrapSyncProducer(cfg, producer, WithHashedMessageKeys())
 
 	msg1 := &sarama.ProducerMessage{
 		Topic:    "my_topic",
+		Key:      sarama.StringEncoder("key"),
 		Value:    sarama.StringEncoder("test 1"),
 		Metadata: "test",
 	}
@@ -134,6 +417,12 @@ func TestSyncProducer(t *testing.T) {
 		assert.Equal(t, "kafka.produce", s.OperationName())
 		assert.Equal(t, int32(0), s.Tag("partition"))
 		assert.Equal(t, int64(0), s.Tag("offset"))
+		assert.Equal(t, ext.SpanKindProducer, s.Tag(ext.SpanKind))
+		assert.Equal(t, "kafka", s.Tag(ext.MessagingSystem))
+		assert.Equal(t, "my_topic", s.Tag(ext.MessagingDestination))
+		assert.Equal(t, 6, s.Tag(ext.MessagingPayloadSize))
+		assert.Equal(t, "sarama", s.Tag(ext.MessagingKafkaClientID))
+		assert.Equal(t, messaging.HashKey([]byte("key")), s.Tag(ext.MessagingKafkaMessageKey))
 	}
 }
 
@@ -187,6 +476,116 @@ func TestSyncProducerSendMessages(t *testing.T) {
 	}
 }
 
+type errSyncProducer struct {
+	sarama.SyncProducer
+	fail map[string]bool
+}
+
+func (p *errSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var errs sarama.ProducerErrors
+	for i, msg := range msgs {
+		if p.fail[msg.Topic] {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: sarama.ErrNotLeaderForPartition})
+			continue
+		}
+		msg.Offset = int64(i)
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+func TestSyncProducerSendMessagesBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	producer := WrapSyncProducer(nil, &errSyncProducer{fail: map[string]bool{"bad_topic": true}}, WithBatchSpans())
+	err := producer.SendMessages([]*sarama.ProducerMessage{
+		{Topic: "my_topic", Value: sarama.StringEncoder("test 1")},
+		{Topic: "bad_topic", Value: sarama.StringEncoder("test 2")},
+	})
+	assert.Error(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+	ok, failed, batch := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "kafka.produce.batch", batch.OperationName())
+	assert.Equal(t, "Produce Batch", batch.Tag(ext.ResourceName))
+	assert.Equal(t, 2, batch.Tag(ext.MessagingBatchSize))
+	assert.Equal(t, 1, batch.Tag(tagBatchErrors))
+	assert.Equal(t, err, batch.Tag(ext.Error))
+
+	assert.Equal(t, "Produce Topic my_topic", ok.Tag(ext.ResourceName))
+	assert.Equal(t, batch.SpanID(), ok.ParentID())
+	assert.Nil(t, ok.Tag(ext.Error))
+
+	assert.Equal(t, "Produce Topic bad_topic", failed.Tag(ext.ResourceName))
+	assert.Equal(t, batch.SpanID(), failed.ParentID())
+	assert.Equal(t, sarama.ErrNotLeaderForPartition, failed.Tag(ext.Error))
+}
+
+func (p *errSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if p.fail[msg.Topic] {
+		return 0, 0, sarama.ErrNotLeaderForPartition
+	}
+	return 0, 0, nil
+}
+
+func TestProducerContext(t *testing.T) {
+	t.Run("sync", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+		producer := WrapSyncProducer(nil, &errSyncProducer{}, WithBatchSpans())
+		producer.SendMessageWithContext(ctx, &sarama.ProducerMessage{Topic: "my_topic"})
+		producer.SendMessagesWithContext(ctx, []*sarama.ProducerMessage{{Topic: "my_topic"}})
+		parent.Finish()
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 4)
+		msg, batchMsg, batch := spans[0], spans[1], spans[2]
+		assert.Equal(t, "kafka.produce", msg.OperationName())
+		assert.Equal(t, spans[3].SpanID(), msg.ParentID())
+		assert.Equal(t, "kafka.produce.batch", batch.OperationName())
+		assert.Equal(t, spans[3].SpanID(), batch.ParentID())
+		assert.Equal(t, batch.SpanID(), batchMsg.ParentID())
+	})
+
+	t.Run("async", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		broker := newMockBroker(t)
+
+		cfg := sarama.NewConfig()
+		cfg.Producer.Return.Successes = true
+		producer, err := sarama.NewAsyncProducer([]string{broker.Addr()}, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		producer = WrapAsyncProducer(cfg, producer)
+		defer producer.Close()
+
+		parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+		producer.Input() <- ProducerMessageWithContext(ctx, &sarama.ProducerMessage{
+			Topic:    "my_topic",
+			Value:    sarama.StringEncoder("test 1"),
+			Metadata: "test",
+		})
+		msg := <-producer.Successes()
+		parent.Finish()
+		assert.Equal(t, "test", msg.Metadata)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "kafka.produce", spans[0].OperationName())
+		assert.Equal(t, spans[1].SpanID(), spans[0].ParentID())
+	})
+}
+
 func TestAsyncProducer(t *testing.T) {
 	// the default for producers is a fire-and-forget model that doesn't return
 	// successes
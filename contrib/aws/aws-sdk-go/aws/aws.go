@@ -2,10 +2,14 @@
 package aws // import "github.com/adityayuga/signalfx-go-tracing/contrib/aws/aws-sdk-go/aws"
 
 import (
+	"context"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
 
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
@@ -13,9 +17,19 @@ import (
 )
 
 const (
-	tagAWSAgent     = "aws.agent"
-	tagAWSOperation = "aws.operation"
-	tagAWSRegion    = "aws.region"
+	tagAWSAgent      = "aws.agent"
+	tagAWSOperation  = "aws.operation"
+	tagAWSRegion     = "aws.region"
+	tagAWSRequestID  = "aws.request_id"
+	tagAWSRetryCount = "aws.retry_count"
+
+	tagS3Bucket                 = "aws.s3.bucket"
+	tagS3KeyPrefix              = "aws.s3.key_prefix"
+	tagDynamoDBTableName        = "aws.dynamodb.table_name"
+	tagDynamoDBConsumedCapacity = "aws.dynamodb.consumed_capacity"
+	tagSQSQueueURL              = "aws.sqs.queue_url"
+	tagSNSTopicARN              = "aws.sns.topic_arn"
+	tagKinesisStreamName        = "aws.kinesis.stream_name"
 )
 
 type handlers struct {
@@ -31,6 +45,10 @@ func WrapSession(s *session.Session, opts ...Option) *session.Session {
 	}
 	h := &handlers{cfg: cfg}
 	s = s.Copy()
+	s.Handlers.Build.PushFrontNamed(request.NamedHandler{
+		Name: "github.com/adityayuga/signalfx-go-tracing/contrib/aws/aws-sdk-go/aws/handlers.Build",
+		Fn:   h.Build,
+	})
 	s.Handlers.Send.PushFrontNamed(request.NamedHandler{
 		Name: "github.com/adityayuga/signalfx-go-tracing/contrib/aws/aws-sdk-go/aws/handlers.Send",
 		Fn:   h.Send,
@@ -42,33 +60,165 @@ func WrapSession(s *session.Session, opts ...Option) *session.Session {
 	return s
 }
 
+// spanKey is the context key of the span of a request. Spans are looked up
+// with it rather than with tracer.SpanFromContext, so that the span of the
+// caller is never mistaken for the span of the request.
+type spanKey struct{}
+
+// Build starts the span of SQS requests sending messages, before their
+// parameters are serialized, so that its context is injected into the
+// messages. Other requests are traced once sent: building a request, for
+// example to presign it, does not start a span which would never be finished.
+func (h *handlers) Build(req *request.Request) {
+	if req.ExpireTime > 0 || h.awsService(req) != "sqs" {
+		return
+	}
+	switch req.Params.(type) {
+	case *sqs.SendMessageInput, *sqs.SendMessageBatchInput:
+		injectSQS(h.startSpan(req), req)
+	case *sqs.ReceiveMessageInput:
+		requestSQSAttribute(req)
+	}
+}
+
+// Send starts the span of the request on its first attempt. Send handlers run
+// once per attempt, retries are recorded on the span of the first one.
 func (h *handlers) Send(req *request.Request) {
+	span, ok := req.Context().Value(spanKey{}).(ddtrace.Span)
+	if !ok {
+		span = h.startSpan(req)
+	}
+	span.SetTag(tagAWSAgent, h.awsAgent(req))
+	span.SetTag(ext.HTTPURL, req.HTTPRequest.URL.String())
+}
+
+func (h *handlers) Complete(req *request.Request) {
+	span, ok := req.Context().Value(spanKey{}).(ddtrace.Span)
+	if !ok {
+		return
+	}
+	if req.HTTPResponse != nil {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(req.HTTPResponse.StatusCode))
+	}
+	if req.RequestID != "" {
+		span.SetTag(tagAWSRequestID, req.RequestID)
+	}
+	span.SetTag(tagAWSRetryCount, req.RetryCount)
+	if h.awsService(req) == "dynamodb" {
+		if units, ok := consumedCapacity(req.Data); ok {
+			span.SetTag(tagDynamoDBConsumedCapacity, units)
+		}
+	}
+	span.FinishWithOptionsExt(tracer.WithError(req.Error))
+}
+
+// startSpan starts the span of the request and stores it in its context.
+func (h *handlers) startSpan(req *request.Request) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeHTTP),
 		tracer.ServiceName(h.serviceName(req)),
 		tracer.ResourceName(h.resourceName(req)),
-		tracer.Tag(tagAWSAgent, h.awsAgent(req)),
 		tracer.Tag(tagAWSOperation, h.awsOperation(req)),
 		tracer.Tag(tagAWSRegion, h.awsRegion(req)),
 		tracer.Tag(ext.HTTPMethod, req.Operation.HTTPMethod),
-		tracer.Tag(ext.HTTPURL, req.HTTPRequest.URL.String()),
 	}
 	if h.cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, h.cfg.analyticsRate))
 	}
-	_, ctx := tracer.StartSpanFromContext(req.Context(), h.operationName(req), opts...)
-	req.SetContext(ctx)
+	span, ctx := tracer.StartSpanFromContext(req.Context(), h.operationName(req), opts...)
+	h.tagParams(span, req)
+	req.SetContext(context.WithValue(ctx, spanKey{}, span))
+	return span
 }
 
-func (h *handlers) Complete(req *request.Request) {
-	span, ok := tracer.SpanFromContext(req.Context())
-	if !ok {
-		return
+// tagParams tags span with the resources targeted by the request, read from
+// its input parameters.
+func (h *handlers) tagParams(span ddtrace.Span, req *request.Request) {
+	switch h.awsService(req) {
+	case "s3":
+		if bucket, ok := stringField(req.Params, "Bucket"); ok {
+			span.SetTag(tagS3Bucket, bucket)
+		}
+		// object keys may hold user data, only their "directory" is recorded
+		if key, ok := stringField(req.Params, "Key"); ok {
+			if i := strings.LastIndexByte(key, '/'); i >= 0 {
+				span.SetTag(tagS3KeyPrefix, key[:i+1])
+			}
+		}
+	case "dynamodb":
+		if table, ok := stringField(req.Params, "TableName"); ok {
+			span.SetTag(tagDynamoDBTableName, table)
+		}
+	case "sqs":
+		if url, ok := stringField(req.Params, "QueueUrl"); ok {
+			span.SetTag(tagSQSQueueURL, url)
+		}
+	case "sns":
+		if arn, ok := stringField(req.Params, "TopicArn"); ok {
+			span.SetTag(tagSNSTopicARN, arn)
+		} else if arn, ok := stringField(req.Params, "TargetArn"); ok {
+			span.SetTag(tagSNSTopicARN, arn)
+		}
+	case "kinesis":
+		if stream, ok := stringField(req.Params, "StreamName"); ok {
+			span.SetTag(tagKinesisStreamName, stream)
+		}
 	}
-	if req.HTTPResponse != nil {
-		span.SetTag(ext.HTTPCode, strconv.Itoa(req.HTTPResponse.StatusCode))
+}
+
+// stringField returns the value of the non-nil *string field with the given
+// name of the struct v points to.
+func stringField(v interface{}, name string) (string, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return "", false
 	}
-	span.FinishWithOptionsExt(tracer.WithError(req.Error))
+	f := rv.FieldByName(name)
+	if f.Kind() != reflect.Ptr || f.IsNil() || f.Elem().Kind() != reflect.String {
+		return "", false
+	}
+	return f.Elem().String(), true
+}
+
+// consumedCapacity returns the capacity units consumed by a DynamoDB
+// operation, read from the ConsumedCapacity field of its output. Operations
+// spanning several tables return a list of capacities, which are summed. The
+// field is only set when requested using ReturnConsumedCapacity.
+func consumedCapacity(output interface{}) (float64, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(output))
+	if rv.Kind() != reflect.Struct {
+		return 0, false
+	}
+	f := rv.FieldByName("ConsumedCapacity")
+	switch f.Kind() {
+	case reflect.Ptr:
+		return capacityUnits(f)
+	case reflect.Slice:
+		var (
+			total float64
+			found bool
+		)
+		for i := 0; i < f.Len(); i++ {
+			if units, ok := capacityUnits(f.Index(i)); ok {
+				total += units
+				found = true
+			}
+		}
+		return total, found
+	}
+	return 0, false
+}
+
+// capacityUnits returns the CapacityUnits of the *dynamodb.ConsumedCapacity v.
+func capacityUnits(v reflect.Value) (float64, bool) {
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return 0, false
+	}
+	f := v.Elem().FieldByName("CapacityUnits")
+	if f.Kind() != reflect.Ptr || f.IsNil() || f.Elem().Kind() != reflect.Float64 {
+		return 0, false
+	}
+	return f.Elem().Float(), true
 }
 
 func (h *handlers) operationName(req *request.Request) string {
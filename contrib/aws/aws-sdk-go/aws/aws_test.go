@@ -2,18 +2,26 @@ package aws
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/signalfx/signalfx-go-tracing/ddtrace/ext"
-	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
-	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
-	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
-	"github.com/adityayuga/signalfx-go-tracing
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAWS(t *testing.T) {
@@ -49,6 +57,8 @@ func TestAWS(t *testing.T) {
 		assert.Equal(t, "403", s.Tag(ext.HTTPCode))
 		assert.Equal(t, "PUT", s.Tag(ext.HTTPMethod))
 		assert.Equal(t, "http://s3.us-west-2.amazonaws.com/BUCKET", s.Tag(ext.HTTPURL))
+		assert.Equal(t, "BUCKET", s.Tag(tagS3Bucket))
+		assert.NotNil(t, s.Tag(tagAWSRetryCount))
 	})
 
 	t.Run("ec2", func(t *testing.T) {
@@ -77,6 +87,213 @@ func TestAWS(t *testing.T) {
 	})
 }
 
+func TestServiceTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "REQUEST")
+		w.Header().Set("X-Amzn-Requestid", "REQUEST")
+		if r.Header.Get("X-Amz-Target") == "DynamoDB_20120810.GetItem" {
+			w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+			w.Write([]byte(`{"ConsumedCapacity":{"TableName":"TABLE","CapacityUnits":0.5}}`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := aws.NewConfig().
+		WithRegion("us-west-2").
+		WithEndpoint(srv.URL).
+		WithS3ForcePathStyle(true).
+		WithMaxRetries(0).
+		WithDisableComputeChecksums(true).
+		WithCredentials(credentials.AnonymousCredentials)
+	session := WrapSession(session.Must(session.NewSession(cfg)))
+
+	run := func(t *testing.T, send func() error) mocktracer.Span {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		require.NoError(t, send())
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		s := spans[0]
+		assert.Equal(t, "REQUEST", s.Tag(tagAWSRequestID))
+		assert.Equal(t, 0, s.Tag(tagAWSRetryCount))
+		assert.Equal(t, "200", s.Tag(ext.HTTPCode))
+		return s
+	}
+
+	t.Run("s3", func(t *testing.T) {
+		s := run(t, func() error {
+			_, err := s3.New(session).PutObject(&s3.PutObjectInput{
+				Bucket: aws.String("BUCKET"),
+				Key:    aws.String("users/42/avatar.png"),
+			})
+			return err
+		})
+		assert.Equal(t, "BUCKET", s.Tag(tagS3Bucket))
+		assert.Equal(t, "users/42/", s.Tag(tagS3KeyPrefix))
+	})
+
+	t.Run("dynamodb", func(t *testing.T) {
+		s := run(t, func() error {
+			_, err := dynamodb.New(session).GetItem(&dynamodb.GetItemInput{
+				TableName: aws.String("TABLE"),
+				Key: map[string]*dynamodb.AttributeValue{
+					"id": {S: aws.String("42")},
+				},
+				ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityTotal),
+			})
+			return err
+		})
+		assert.Equal(t, "TABLE", s.Tag(tagDynamoDBTableName))
+		assert.Equal(t, 0.5, s.Tag(tagDynamoDBConsumedCapacity))
+	})
+
+	t.Run("sqs", func(t *testing.T) {
+		s := run(t, func() error {
+			_, err := sqs.New(session).SendMessage(&sqs.SendMessageInput{
+				QueueUrl:    aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/QUEUE"),
+				MessageBody: aws.String("body"),
+			})
+			return err
+		})
+		assert.Equal(t, "https://sqs.us-west-2.amazonaws.com/123456789012/QUEUE", s.Tag(tagSQSQueueURL))
+	})
+}
+
+func TestUnsentRequests(t *testing.T) {
+	cfg := aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.AnonymousCredentials)
+	session := WrapSession(session.Must(session.NewSession(cfg)))
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+
+	// built and presigned requests are not traced
+	req, _ := s3.New(session).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String("BUCKET"),
+		Key:    aws.String("KEY"),
+	})
+	req.SetContext(ctx)
+	require.NoError(t, req.Build())
+	_, err := req.Presign(time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, req.Context().Value(spanKey{}), "no span is started")
+
+	// requests failing validation complete without finishing the span of
+	// the caller
+	_, err = s3.New(session).GetObjectWithContext(ctx, &s3.GetObjectInput{})
+	require.Error(t, err)
+
+	assert.Empty(t, mt.FinishedSpans())
+	root.Finish()
+	assert.Len(t, mt.FinishedSpans(), 1)
+}
+
+func TestConsumedCapacity(t *testing.T) {
+	_, ok := consumedCapacity(&dynamodb.GetItemOutput{})
+	assert.False(t, ok)
+
+	units, ok := consumedCapacity(&dynamodb.BatchGetItemOutput{
+		ConsumedCapacity: []*dynamodb.ConsumedCapacity{
+			{CapacityUnits: aws.Float64(1)},
+			{CapacityUnits: aws.Float64(2.5)},
+			nil,
+		},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, units)
+}
+
+func TestSQSPropagation(t *testing.T) {
+	cfg := aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.AnonymousCredentials)
+	sqsapi := sqs.New(WrapSession(session.Must(session.NewSession(cfg))))
+	queue := aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/QUEUE")
+
+	// build builds req and completes it without sending it.
+	build := func(t *testing.T, req *request.Request) {
+		require.NoError(t, req.Build())
+		req.Handlers.Complete.Run(req)
+	}
+
+	t.Run("send", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		input := &sqs.SendMessageInput{QueueUrl: queue, MessageBody: aws.String("body")}
+		req, _ := sqsapi.SendMessageRequest(input)
+		build(t, req)
+
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Nil(t, input.MessageAttributes, "the input of the caller is not modified")
+		attrs := req.Params.(*sqs.SendMessageInput).MessageAttributes
+		require.Contains(t, attrs, sqsAttributeName)
+		assert.Equal(t, "String", aws.StringValue(attrs[sqsAttributeName].DataType))
+
+		// the receiver continues the trace of the sender
+		sctx, err := ExtractSQSMessage(&sqs.Message{MessageAttributes: attrs})
+		require.NoError(t, err)
+		tracer.StartSpan("consume", tracer.ChildOf(sctx)).Finish()
+		spans = mt.FinishedSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, spans[0].TraceID(), spans[1].TraceID())
+		assert.Equal(t, spans[0].SpanID(), spans[1].ParentID())
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		full := make(map[string]*sqs.MessageAttributeValue)
+		for i := 0; i < sqsMaxAttributes; i++ {
+			full[strconv.Itoa(i)] = &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("v")}
+		}
+		input := &sqs.SendMessageBatchInput{
+			QueueUrl: queue,
+			Entries: []*sqs.SendMessageBatchRequestEntry{
+				{Id: aws.String("1"), MessageBody: aws.String("body")},
+				{Id: aws.String("2"), MessageBody: aws.String("body"), MessageAttributes: full},
+			},
+		}
+		req, _ := sqsapi.SendMessageBatchRequest(input)
+		build(t, req)
+
+		assert.Nil(t, input.Entries[0].MessageAttributes, "the input of the caller is not modified")
+		entries := req.Params.(*sqs.SendMessageBatchInput).Entries
+		assert.Contains(t, entries[0].MessageAttributes, sqsAttributeName)
+		assert.NotContains(t, entries[1].MessageAttributes, sqsAttributeName, "messages are limited to 10 attributes")
+	})
+
+	t.Run("receive", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		names := make([]*string, 1, 2)
+		names[0] = aws.String("attr")
+		input := &sqs.ReceiveMessageInput{QueueUrl: queue, MessageAttributeNames: names}
+		req, _ := sqsapi.ReceiveMessageRequest(input)
+		build(t, req)
+		assert.Equal(t, []*string{aws.String("attr"), aws.String(sqsAttributeName)}, req.Params.(*sqs.ReceiveMessageInput).MessageAttributeNames)
+		assert.Len(t, input.MessageAttributeNames, 1, "the input of the caller is not modified")
+		assert.Nil(t, names[:2][1], "the input of the caller is not modified")
+
+		input = &sqs.ReceiveMessageInput{QueueUrl: queue, MessageAttributeNames: []*string{aws.String("All")}}
+		req, _ = sqsapi.ReceiveMessageRequest(input)
+		build(t, req)
+		assert.Len(t, req.Params.(*sqs.ReceiveMessageInput).MessageAttributeNames, 1)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := ExtractSQSMessage(&sqs.Message{})
+		assert.Equal(t, tracer.ErrSpanContextNotFound, err)
+	})
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	cfg := aws.NewConfig().
 		WithRegion("us-west-2").
@@ -4,7 +4,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	awstrace "github.com/adityayuga/signalfx-go-tracing/contrib/aws/aws-sdk-go/aws"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 )
 
 // To start tracing requests, wrap the AWS session.Session by invoking
@@ -19,3 +22,25 @@ func Example() {
 		Bucket: aws.String("some-bucket-name"),
 	})
 }
+
+// Messages sent to SQS using a wrapped session carry the span context of
+// their sender, which the receiver extracts to continue the trace.
+func ExampleExtractSQSMessage() {
+	sess := awstrace.WrapSession(session.Must(session.NewSession()))
+	sqsapi := sqs.New(sess)
+	out, err := sqsapi.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl: aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/queue"),
+	})
+	if err != nil {
+		return
+	}
+	for _, msg := range out.Messages {
+		var opts []ddtrace.StartSpanOption
+		if sctx, err := awstrace.ExtractSQSMessage(msg); err == nil {
+			opts = append(opts, tracer.ChildOf(sctx))
+		}
+		span := tracer.StartSpan("sqs.consume", opts...)
+		// handle the message
+		span.Finish()
+	}
+}
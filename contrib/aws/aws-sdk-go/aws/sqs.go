@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+)
+
+const (
+	// sqsAttributeName is the name of the message attribute holding the span
+	// context of the span which sent a message.
+	sqsAttributeName = "signalfx.context"
+	// sqsMaxAttributes is the maximum number of attributes of a message.
+	sqsMaxAttributes = 10
+)
+
+// injectSQS injects the context of span into the messages sent by req. The
+// parameters of the caller are left unchanged: the request is given a copy of
+// them holding the injected attributes.
+func injectSQS(span ddtrace.Span, req *request.Request) {
+	switch params := req.Params.(type) {
+	case *sqs.SendMessageInput:
+		cp := *params
+		cp.MessageAttributes = injectSQSAttributes(span, params.MessageAttributes)
+		req.Params = &cp
+	case *sqs.SendMessageBatchInput:
+		cp := *params
+		cp.Entries = make([]*sqs.SendMessageBatchRequestEntry, len(params.Entries))
+		for i, entry := range params.Entries {
+			if entry == nil {
+				continue
+			}
+			e := *entry
+			e.MessageAttributes = injectSQSAttributes(span, entry.MessageAttributes)
+			cp.Entries[i] = &e
+		}
+		req.Params = &cp
+	}
+}
+
+// requestSQSAttribute requests the attribute holding the span context from the
+// messages received by req, using a copy of the parameters of the caller.
+func requestSQSAttribute(req *request.Request) {
+	params, ok := req.Params.(*sqs.ReceiveMessageInput)
+	if !ok {
+		return
+	}
+	for _, name := range params.MessageAttributeNames {
+		switch aws.StringValue(name) {
+		case "All", ".*", sqsAttributeName:
+			return
+		}
+	}
+	cp := *params
+	cp.MessageAttributeNames = make([]*string, len(params.MessageAttributeNames), len(params.MessageAttributeNames)+1)
+	copy(cp.MessageAttributeNames, params.MessageAttributeNames)
+	cp.MessageAttributeNames = append(cp.MessageAttributeNames, aws.String(sqsAttributeName))
+	req.Params = &cp
+}
+
+// injectSQSAttributes returns a copy of the given message attributes holding
+// the context of span. Messages already holding the maximum number of
+// attributes are left without it.
+func injectSQSAttributes(span ddtrace.Span, attrs map[string]*sqs.MessageAttributeValue) map[string]*sqs.MessageAttributeValue {
+	if _, ok := attrs[sqsAttributeName]; !ok && len(attrs) >= sqsMaxAttributes {
+		return attrs
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		return attrs
+	}
+	value, err := json.Marshal(carrier)
+	if err != nil {
+		return attrs
+	}
+	cp := make(map[string]*sqs.MessageAttributeValue, len(attrs)+1)
+	for k, v := range attrs {
+		cp[k] = v
+	}
+	cp[sqsAttributeName] = &sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(string(value)),
+	}
+	return cp
+}
+
+// ExtractSQSMessage returns the span context injected into msg when it was
+// sent using a session wrapped by WrapSession. It allows continuing the trace
+// of the sender when handling a received message, for example by passing the
+// context to tracer.ChildOf. Messages received using a wrapped session hold
+// the span context, other receivers need to request the "signalfx.context"
+// message attribute.
+func ExtractSQSMessage(msg *sqs.Message) (ddtrace.SpanContext, error) {
+	if msg == nil {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	attr, ok := msg.MessageAttributes[sqsAttributeName]
+	if !ok || attr == nil || attr.StringValue == nil {
+		return nil, tracer.ErrSpanContextNotFound
+	}
+	carrier := tracer.TextMapCarrier{}
+	if err := json.Unmarshal([]byte(*attr.StringValue), &carrier); err != nil {
+		return nil, tracer.ErrSpanContextCorrupted
+	}
+	return tracer.Extract(carrier)
+}
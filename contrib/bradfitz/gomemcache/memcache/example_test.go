@@ -20,3 +20,11 @@ func Example() {
 	mc.WithContext(ctx).Set(&memcache.Item{Key: "my key", Value: []byte("my value")})
 
 }
+
+func ExampleNewFromSelector() {
+	// spans are tagged with the address of the server picked for their keys
+	var ss memcache.ServerList
+	ss.SetServers("127.0.0.1:11211", "127.0.0.1:11212")
+	mc := memcachetrace.NewFromSelector(&ss)
+	mc.GetMulti([]string{"key1", "key2"})
+}
@@ -8,6 +8,9 @@ package memcache // import "github.com/adityayuga/signalfx-go-tracing/contrib/br
 
 import (
 	"context"
+	"net"
+	"sort"
+	"strings"
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
@@ -30,6 +33,27 @@ func WrapClient(client *memcache.Client, opts ...ClientOption) *Client {
 	}
 }
 
+// NewFromSelector returns a traced client using the given ServerSelector, tagging
+// spans with the address of the server handling their keys.
+func NewFromSelector(ss memcache.ServerSelector, opts ...ClientOption) *Client {
+	return WrapClient(memcache.NewFromSelector(ss), append(opts, WithServerSelector(ss))...)
+}
+
+const (
+	// tagServers is the tag holding the comma-separated addresses of the
+	// servers handling the keys of a multi-key operation, when there are
+	// several of them.
+	tagServers = "memcached.servers"
+	// tagKeys is the tag holding the number of keys of a multi-key operation.
+	tagKeys = "memcached.keys"
+	// tagHits is the tag holding the number of keys found.
+	tagHits = "memcached.hits"
+	// tagMisses is the tag holding the number of keys not found.
+	tagMisses = "memcached.misses"
+	// tagValueSize is the tag holding the size, in bytes, of a stored value.
+	tagValueSize = "memcached.value_size"
+)
+
 // A Client is used to trace requests to the memcached server.
 type Client struct {
 	*memcache.Client
@@ -54,8 +78,9 @@ func (c *Client) WithContext(ctx context.Context) *Client {
 	}
 }
 
-// startSpan starts a span from the context set with WithContext.
-func (c *Client) startSpan(resourceName string) ddtrace.Span {
+// startSpan starts a span from the context set with WithContext, tagged with
+// the servers handling the given keys.
+func (c *Client) startSpan(resourceName string, keys ...string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeMemcached),
 		tracer.ServiceName(c.cfg.serviceName),
@@ -65,40 +90,91 @@ func (c *Client) startSpan(resourceName string) ddtrace.Span {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
 	}
 	span, _ := tracer.StartSpanFromContext(c.context, operationName, opts...)
+	c.tagServers(span, keys)
 	return span
 }
 
+// tagServers tags span with the addresses of the servers picked by the
+// configured ServerSelector for keys.
+func (c *Client) tagServers(span ddtrace.Span, keys []string) {
+	if c.cfg.selector == nil || len(keys) == 0 {
+		return
+	}
+	addrs := make(map[string]struct{})
+	for _, key := range keys {
+		addr, err := c.cfg.selector.PickServer(key)
+		if err != nil {
+			continue
+		}
+		addrs[addr.String()] = struct{}{}
+	}
+	switch len(addrs) {
+	case 0:
+		return
+	case 1:
+		for addr := range addrs {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				// e.g. unix sockets
+				span.SetTag(ext.TargetHost, addr)
+				return
+			}
+			span.SetTag(ext.TargetHost, host)
+			span.SetTag(ext.TargetPort, port)
+		}
+	default:
+		list := make([]string, 0, len(addrs))
+		for addr := range addrs {
+			list = append(list, addr)
+		}
+		sort.Strings(list)
+		span.SetTag(tagServers, strings.Join(list, ","))
+	}
+}
+
+// finishSpan finishes span with the given error. Cache misses are not errors,
+// they are counted instead.
+func finishSpan(span ddtrace.Span, err error) {
+	if err == memcache.ErrCacheMiss {
+		span.SetTag(tagMisses, 1)
+		err = nil
+	}
+	span.FinishWithOptionsExt(tracer.WithError(err))
+}
+
 // wrapped methods:
 
 // Add invokes and traces Client.Add.
 func (c *Client) Add(item *memcache.Item) error {
-	span := c.startSpan("Add")
+	span := c.startSpan("Add", item.Key)
+	span.SetTag(tagValueSize, len(item.Value))
 	err := c.Client.Add(item)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
 // CompareAndSwap invokes and traces Client.CompareAndSwap.
 func (c *Client) CompareAndSwap(item *memcache.Item) error {
-	span := c.startSpan("CompareAndSwap")
+	span := c.startSpan("CompareAndSwap", item.Key)
+	span.SetTag(tagValueSize, len(item.Value))
 	err := c.Client.CompareAndSwap(item)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
 // Decrement invokes and traces Client.Decrement.
 func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Decrement")
+	span := c.startSpan("Decrement", key)
 	newValue, err = c.Client.Decrement(key, delta)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return newValue, err
 }
 
 // Delete invokes and traces Client.Delete.
 func (c *Client) Delete(key string) error {
-	span := c.startSpan("Delete")
+	span := c.startSpan("Delete", key)
 	err := c.Client.Delete(key)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
@@ -106,7 +182,7 @@ func (c *Client) Delete(key string) error {
 func (c *Client) DeleteAll() error {
 	span := c.startSpan("DeleteAll")
 	err := c.Client.DeleteAll()
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
@@ -114,54 +190,71 @@ func (c *Client) DeleteAll() error {
 func (c *Client) FlushAll() error {
 	span := c.startSpan("FlushAll")
 	err := c.Client.FlushAll()
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
 // Get invokes and traces Client.Get.
 func (c *Client) Get(key string) (item *memcache.Item, err error) {
-	span := c.startSpan("Get")
+	span := c.startSpan("Get", key)
 	item, err = c.Client.Get(key)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	if err == nil {
+		span.SetTag(tagHits, 1)
+		span.SetTag(tagMisses, 0)
+	} else if err == memcache.ErrCacheMiss {
+		span.SetTag(tagHits, 0)
+	}
+	finishSpan(span, err)
 	return item, err
 }
 
 // GetMulti invokes and traces Client.GetMulti.
 func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
-	span := c.startSpan("GetMulti")
+	span := c.startSpan("GetMulti", keys...)
+	span.SetTag(tagKeys, len(keys))
 	items, err := c.Client.GetMulti(keys)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	if err == nil {
+		unique := make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			unique[key] = struct{}{}
+		}
+		span.SetTag(tagHits, len(items))
+		span.SetTag(tagMisses, len(unique)-len(items))
+	}
+	finishSpan(span, err)
 	return items, err
 }
 
 // Increment invokes and traces Client.Increment.
 func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error) {
-	span := c.startSpan("Increment")
+	span := c.startSpan("Increment", key)
 	newValue, err = c.Client.Increment(key, delta)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return newValue, err
 }
 
 // Replace invokes and traces Client.Replace.
 func (c *Client) Replace(item *memcache.Item) error {
-	span := c.startSpan("Replace")
+	span := c.startSpan("Replace", item.Key)
+	span.SetTag(tagValueSize, len(item.Value))
 	err := c.Client.Replace(item)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
 // Set invokes and traces Client.Set.
 func (c *Client) Set(item *memcache.Item) error {
-	span := c.startSpan("Set")
+	span := c.startSpan("Set", item.Key)
+	span.SetTag(tagValueSize, len(item.Value))
 	err := c.Client.Set(item)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
 
 // Touch invokes and traces Client.Touch.
 func (c *Client) Touch(key string, seconds int32) error {
-	span := c.startSpan("Touch")
+	span := c.startSpan("Touch", key)
 	err := c.Client.Touch(key, seconds)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	finishSpan(span, err)
 	return err
 }
@@ -87,6 +87,88 @@ func testMemcache(t *testing.T, addr string) {
 	})
 }
 
+func TestTags(t *testing.T) {
+	li := makeFakeServer(t)
+	defer li.Close()
+	host, port, _ := net.SplitHostPort(li.Addr().String())
+
+	var ss memcache.ServerList
+	if err := ss.SetServers(li.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	client := NewFromSelector(&ss, WithServiceName("test-memcache"))
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	err := client.Set(&memcache.Item{Key: "key1", Value: []byte("value1")})
+	assert.Nil(t, err)
+	_, err = client.Get("key1")
+	assert.Nil(t, err)
+	_, err = client.Get("key2")
+	assert.Equal(t, memcache.ErrCacheMiss, err)
+	items, err := client.GetMulti([]string{"key1", "key2", "key3"})
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	err = client.Delete("key2")
+	assert.Equal(t, memcache.ErrCacheMiss, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 5)
+	for _, span := range spans {
+		assert.Equal(t, host, span.Tag(ext.TargetHost))
+		assert.Equal(t, port, span.Tag(ext.TargetPort))
+		assert.Nil(t, span.Tag(ext.Error), "cache misses are not errors")
+	}
+
+	set, hit, miss, multi, del := spans[0], spans[1], spans[2], spans[3], spans[4]
+	assert.Equal(t, 6, set.Tag(tagValueSize))
+	assert.Equal(t, 1, hit.Tag(tagHits))
+	assert.Equal(t, 0, hit.Tag(tagMisses))
+	assert.Equal(t, 0, miss.Tag(tagHits))
+	assert.Equal(t, 1, miss.Tag(tagMisses))
+	assert.Equal(t, 3, multi.Tag(tagKeys))
+	assert.Equal(t, 1, multi.Tag(tagHits))
+	assert.Equal(t, 2, multi.Tag(tagMisses))
+	assert.Equal(t, 1, del.Tag(tagMisses))
+}
+
+func TestMultipleServers(t *testing.T) {
+	li1, li2 := makeFakeServer(t), makeFakeServer(t)
+	defer li1.Close()
+	defer li2.Close()
+
+	var ss memcache.ServerList
+	if err := ss.SetServers(li1.Addr().String(), li2.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	// keys spread over both servers
+	var keys []string
+	addrs := make(map[string]bool)
+	for i := 0; len(addrs) < 2; i++ {
+		key := fmt.Sprintf("key%d", i)
+		addr, err := ss.PickServer(key)
+		assert.Nil(t, err)
+		addrs[addr.String()] = true
+		keys = append(keys, key)
+	}
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := WrapClient(memcache.NewFromSelector(&ss), WithServerSelector(&ss))
+	_, err := client.GetMulti(keys)
+	assert.Nil(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.TargetHost))
+	servers := strings.Split(spans[0].Tag(tagServers).(string), ",")
+	assert.Len(t, servers, 2)
+	assert.True(t, addrs[servers[0]])
+	assert.True(t, addrs[servers[1]])
+}
+
 func TestFakeServer(t *testing.T) {
 	li := makeFakeServer(t)
 	defer li.Close()
@@ -189,11 +271,21 @@ func makeFakeServer(t *testing.T) net.Listener {
 				for s.Scan() {
 					args := strings.Split(s.Text(), " ")
 					switch args[0] {
-					case "add":
+					case "add", "set":
 						if !s.Scan() {
 							return
 						}
 						fmt.Fprintf(c, "STORED\r\n")
+					case "gets":
+						// only key1 exists
+						for _, key := range args[1:] {
+							if key == "key1" {
+								fmt.Fprintf(c, "VALUE key1 0 6 1\r\nvalue1\r\n")
+							}
+						}
+						fmt.Fprintf(c, "END\r\n")
+					case "delete":
+						fmt.Fprintf(c, "NOT_FOUND\r\n")
 					default:
 						fmt.Fprintf(c, "SERVER ERROR unknown command: %v \r\n", args[0])
 						return
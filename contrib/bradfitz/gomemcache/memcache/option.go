@@ -1,5 +1,7 @@
 package memcache
 
+import "github.com/bradfitz/gomemcache/memcache"
+
 const (
 	serviceName   = "memcached"
 	operationName = "memcached.query"
@@ -8,6 +10,7 @@ const (
 type clientConfig struct {
 	serviceName   string
 	analyticsRate float64
+	selector      memcache.ServerSelector
 }
 
 // ClientOption represents an option that can be passed to Dial.
@@ -25,6 +28,15 @@ func WithServiceName(name string) ClientOption {
 	}
 }
 
+// WithServerSelector sets the ServerSelector used by the wrapped client, so that
+// spans are tagged with the address of the server handling their keys. It is
+// set by NewFromSelector.
+func WithServerSelector(ss memcache.ServerSelector) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.selector = ss
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) ClientOption {
 	if on {
@@ -2,6 +2,11 @@
 package kafka // import "github.com/adityayuga/signalfx-go-tracing/contrib/confluentinc/confluent-kafka-go/kafka"
 
 import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -15,6 +20,10 @@ func NewConsumer(conf *kafka.ConfigMap, opts ...Option) (*Consumer, error) {
 	if err != nil {
 		return nil, err
 	}
+	opts = append([]Option{
+		WithClientID(configString(conf, "client.id")),
+		WithConsumerGroup(configString(conf, "group.id")),
+	}, opts...)
 	return WrapConsumer(c, opts...), nil
 }
 
@@ -24,23 +33,67 @@ func NewProducer(conf *kafka.ConfigMap, opts ...Option) (*Producer, error) {
 	if err != nil {
 		return nil, err
 	}
+	opts = append([]Option{
+		WithClientID(configString(conf, "client.id")),
+	}, opts...)
+	if !configBool(conf, "go.delivery.reports", true) {
+		// delivery reports are never received
+		opts = append(opts, WithDeliveryReports(false))
+	}
 	return WrapProducer(p, opts...), nil
 }
 
+// configString returns the string value of key in conf, if any.
+func configString(conf *kafka.ConfigMap, key string) string {
+	if conf == nil {
+		return ""
+	}
+	v, err := conf.Get(key, "")
+	if err != nil {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// configBool returns the boolean value of key in conf, or def if it is not set.
+func configBool(conf *kafka.ConfigMap, key string, def bool) bool {
+	if conf == nil {
+		return def
+	}
+	v, err := conf.Get(key, def)
+	if err != nil {
+		return def
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return def
+	}
+	return b
+}
+
 // A Consumer wraps a kafka.Consumer.
 type Consumer struct {
 	*kafka.Consumer
 	cfg    *config
 	events chan kafka.Event
 	prev   ddtrace.Span
+	// pending holds the received messages not being processed yet, when
+	// using WithProcessingSpans
+	pending *messaging.Set
 }
 
 // WrapConsumer wraps a kafka.Consumer so that any consumed events are traced.
+// By default, the span of a message is finished when the next message is
+// received; see WithProcessingSpans for finishing it when its processing ends.
 func WrapConsumer(c *kafka.Consumer, opts ...Option) *Consumer {
 	wrapped := &Consumer{
 		Consumer: c,
 		cfg:      newConfig(opts...),
 	}
+	if wrapped.cfg.processingSpans {
+		wrapped.pending = pendingMessages.NewSet()
+	}
 	wrapped.events = wrapped.traceEventsChannel(c.Events())
 	return wrapped
 }
@@ -59,7 +112,12 @@ func (c *Consumer) traceEventsChannel(in chan kafka.Event) chan kafka.Event {
 
 			// only trace messages
 			if msg, ok := evt.(*kafka.Message); ok {
-				next = c.startSpan(msg)
+				if c.cfg.processingSpans {
+					// the span is started and finished by StartProcessing
+					c.pending.Store(msg, messagePartition(msg), c.cfg)
+				} else {
+					next = c.startSpan(c.cfg.ctx, msg)
+				}
 			}
 
 			out <- evt
@@ -79,32 +137,59 @@ func (c *Consumer) traceEventsChannel(in chan kafka.Event) chan kafka.Event {
 	return out
 }
 
-func (c *Consumer) startSpan(msg *kafka.Message) ddtrace.Span {
+func (c *Consumer) startSpan(ctx context.Context, msg *kafka.Message) ddtrace.Span {
+	return startConsumerSpan(ctx, c.cfg, msg)
+}
+
+func startConsumerSpan(ctx context.Context, cfg *config, msg *kafka.Message) ddtrace.Span {
 	opts := []tracer.StartSpanOption{
-		tracer.ServiceName(c.cfg.serviceName),
+		tracer.ServiceName(cfg.serviceName),
 		tracer.ResourceName("Consume Topic " + *msg.TopicPartition.Topic),
 		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.SpanKind, ext.SpanKindConsumer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(ext.MessagingDestination, *msg.TopicPartition.Topic),
+		tracer.Tag(ext.MessagingPayloadSize, len(msg.Value)),
 		tracer.Tag("partition", msg.TopicPartition.Partition),
 		tracer.Tag("offset", msg.TopicPartition.Offset),
 	}
-	if c.cfg.analyticsRate > 0 {
-		opts = append(opts, tracer.Tag(ext.EventSampleRate, c.cfg.analyticsRate))
+	if cfg.consumerGroup != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaConsumerGroup, cfg.consumerGroup))
+	}
+	opts = append(opts, messageTags(cfg, msg)...)
+	if cfg.analyticsRate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 	}
 	// kafka supports headers, so try to extract a span context
 	carrier := NewMessageCarrier(msg)
 	if spanctx, err := tracer.Extract(carrier); err == nil {
 		opts = append(opts, tracer.ChildOf(spanctx))
 	}
-	span, _ := tracer.StartSpanFromContext(c.cfg.ctx, "kafka.consume", opts...)
+	span, _ := tracer.StartSpanFromContext(ctx, "kafka.consume", opts...)
 	// reinject the span context so consumers can pick it up
 	tracer.Inject(span.Context(), carrier)
 	return span
 }
 
+// messageTags returns the client ID and message key tags of msg.
+func messageTags(cfg *config, msg *kafka.Message) []tracer.StartSpanOption {
+	var opts []tracer.StartSpanOption
+	if cfg.clientID != "" {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaClientID, cfg.clientID))
+	}
+	if cfg.hashMessageKeys && len(msg.Key) > 0 {
+		opts = append(opts, tracer.Tag(ext.MessagingKafkaMessageKey, messaging.HashKey(msg.Key)))
+	}
+	return opts
+}
+
 // Close calls the underlying Consumer.Close and if polling is enabled, finishes
-// any remaining span.
+// any remaining span. The messages which were not processed are released.
 func (c *Consumer) Close() error {
 	err := c.Consumer.Close()
+	if c.pending != nil {
+		c.pending.Close()
+	}
 	// we only close the previous span if consuming via the events channel is
 	// not enabled, because otherwise there would be a data race from the
 	// consuming goroutine.
@@ -130,7 +215,12 @@ func (c *Consumer) Poll(timeoutMS int) (event kafka.Event) {
 	}
 	evt := c.Consumer.Poll(timeoutMS)
 	if msg, ok := evt.(*kafka.Message); ok {
-		c.prev = c.startSpan(msg)
+		if c.cfg.processingSpans {
+			// the span is started and finished by StartProcessing
+			c.pending.Store(msg, messagePartition(msg), c.cfg)
+		} else {
+			c.prev = c.startSpan(c.cfg.ctx, msg)
+		}
 	}
 	return evt
 }
@@ -140,18 +230,111 @@ type Producer struct {
 	*kafka.Producer
 	cfg            *config
 	produceChannel chan *kafka.Message
+	events         chan kafka.Event
+	// closing is closed when the producer starts closing, so that its events
+	// are no longer relayed to Events, which may not be read anymore
+	closing chan struct{}
+	// eventsDone is closed once the events of the producer are all read
+	eventsDone chan struct{}
+
+	mu sync.Mutex
+	// awaiting holds the messages waiting for their delivery report
+	awaiting map[*deliveryOpaque]struct{}
 }
 
-// WrapProducer wraps a kafka.Producer so requests are traced.
+// WrapProducer wraps a kafka.Producer so requests are traced. The span of a
+// message is finished on its delivery report, received either from its
+// delivery channel or from Events. See WithDeliveryReports for producers
+// whose delivery reports are disabled.
 func WrapProducer(p *kafka.Producer, opts ...Option) *Producer {
 	wrapped := &Producer{
 		Producer: p,
 		cfg:      newConfig(opts...),
+		closing:  make(chan struct{}),
+		awaiting: make(map[*deliveryOpaque]struct{}),
 	}
 	wrapped.produceChannel = wrapped.traceProduceChannel(p.ProduceChannel())
+	wrapped.events = wrapped.traceEventsChannel(p.Events())
 	return wrapped
 }
 
+// errNotDelivered is recorded on the spans of the messages whose delivery
+// report was not received before their producer was closed.
+var errNotDelivered = errors.New("producer closed before the delivery report was received")
+
+// deliveryOpaque replaces the opaque of a produced message until its delivery
+// report is received, so that its span can be finished.
+type deliveryOpaque struct {
+	span   ddtrace.Span
+	opaque interface{}
+}
+
+// awaitDelivery returns a copy of msg to enqueue instead of msg so that its
+// span is finished on its delivery report. The message of the caller is left
+// unchanged.
+func (p *Producer) awaitDelivery(span ddtrace.Span, msg *kafka.Message) *kafka.Message {
+	d := &deliveryOpaque{span: span, opaque: msg.Opaque}
+	p.mu.Lock()
+	p.awaiting[d] = struct{}{}
+	p.mu.Unlock()
+	cp := *msg
+	cp.Opaque = d
+	return &cp
+}
+
+// cancelDelivery stops awaiting the delivery report of a message which was
+// not enqueued.
+func (p *Producer) cancelDelivery(msg *kafka.Message) {
+	if d, ok := msg.Opaque.(*deliveryOpaque); ok {
+		p.mu.Lock()
+		delete(p.awaiting, d)
+		p.mu.Unlock()
+	}
+}
+
+// finishDelivery finishes the span of the message of a delivery report and
+// restores its opaque, reporting whether the message was traced.
+func (p *Producer) finishDelivery(msg *kafka.Message) bool {
+	d, ok := msg.Opaque.(*deliveryOpaque)
+	if !ok {
+		return false
+	}
+	p.mu.Lock()
+	delete(p.awaiting, d)
+	p.mu.Unlock()
+	msg.Opaque = d.opaque
+	d.span.SetTag("offset", msg.TopicPartition.Offset)
+	// delivery errors are returned via TopicPartition.Error
+	d.span.FinishWithOptionsExt(tracer.WithError(msg.TopicPartition.Error))
+	return true
+}
+
+func (p *Producer) traceEventsChannel(in chan kafka.Event) chan kafka.Event {
+	if in == nil || !p.cfg.deliveryReports {
+		return in
+	}
+
+	out := make(chan kafka.Event, 1)
+	p.eventsDone = make(chan struct{})
+	go func() {
+		defer close(p.eventsDone)
+		defer close(out)
+		for evt := range in {
+			if msg, ok := evt.(*kafka.Message); ok {
+				p.finishDelivery(msg)
+			}
+			select {
+			case out <- evt:
+			case <-p.closing:
+				// the producer is closing and Events may no longer be
+				// read, so the event is dropped rather than blocking Close
+			}
+		}
+	}()
+
+	return out
+}
+
 func (p *Producer) traceProduceChannel(out chan *kafka.Message) chan *kafka.Message {
 	if out == nil {
 		return out
@@ -160,7 +343,11 @@ func (p *Producer) traceProduceChannel(out chan *kafka.Message) chan *kafka.Mess
 	in := make(chan *kafka.Message, 1)
 	go func() {
 		for msg := range in {
-			span := p.startSpan(msg)
+			span := p.startSpan(p.cfg.ctx, msg)
+			if p.cfg.deliveryReports && p.events != nil {
+				out <- p.awaitDelivery(span, msg)
+				continue
+			}
 			out <- msg
 			span.Finish()
 		}
@@ -169,33 +356,57 @@ func (p *Producer) traceProduceChannel(out chan *kafka.Message) chan *kafka.Mess
 	return in
 }
 
-func (p *Producer) startSpan(msg *kafka.Message) ddtrace.Span {
+func (p *Producer) startSpan(ctx context.Context, msg *kafka.Message) ddtrace.Span {
 	opts := []tracer.StartSpanOption{
 		tracer.ServiceName(p.cfg.serviceName),
 		tracer.ResourceName("Produce Topic " + *msg.TopicPartition.Topic),
 		tracer.SpanType(ext.SpanTypeMessageProducer),
+		tracer.Tag(ext.SpanKind, ext.SpanKindProducer),
+		tracer.Tag(ext.MessagingSystem, "kafka"),
+		tracer.Tag(ext.MessagingDestination, *msg.TopicPartition.Topic),
+		tracer.Tag(ext.MessagingPayloadSize, len(msg.Value)),
 		tracer.Tag("partition", msg.TopicPartition.Partition),
 	}
+	opts = append(opts, messageTags(p.cfg, msg)...)
 	if p.cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, p.cfg.analyticsRate))
 	}
 	carrier := NewMessageCarrier(msg)
-	span, _ := tracer.StartSpanFromContext(p.cfg.ctx, "kafka.produce", opts...)
+	span, _ := tracer.StartSpanFromContext(ctx, "kafka.produce", opts...)
 	// inject the span context so consumers can pick it up
 	tracer.Inject(span.Context(), carrier)
 	return span
 }
 
 // Close calls the underlying Producer.Close and also closes the internal
-// wrapping producer channel.
+// wrapping producer channel. The spans of the messages whose delivery report
+// was not received are finished with an error.
 func (p *Producer) Close() {
 	close(p.produceChannel)
+	close(p.closing)
 	p.Producer.Close()
+	if p.eventsDone != nil {
+		// wait for the remaining delivery reports to be read
+		<-p.eventsDone
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for d := range p.awaiting {
+		d.span.FinishWithOptionsExt(tracer.WithError(errNotDelivered))
+		delete(p.awaiting, d)
+	}
 }
 
 // Produce calls the underlying Producer.Produce and traces the request.
 func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
-	span := p.startSpan(msg)
+	return p.ProduceWithContext(p.cfg.ctx, msg, deliveryChan)
+}
+
+// ProduceWithContext calls the underlying Producer.Produce and traces the
+// request as a child of the span found in ctx, whose context is injected into
+// the headers of msg.
+func (p *Producer) ProduceWithContext(ctx context.Context, msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	span := p.startSpan(ctx, msg)
 
 	// if the user has selected a delivery channel, we will wrap it and
 	// wait for the delivery event to finish the span
@@ -214,15 +425,32 @@ func (p *Producer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) er
 		}()
 	}
 
+	// with no delivery channel, the delivery report is sent to Events
+	awaitEvents := deliveryChan == nil && p.cfg.deliveryReports && p.events != nil
+	if awaitEvents {
+		msg = p.awaitDelivery(span, msg)
+	}
+
 	err := p.Producer.Produce(msg, deliveryChan)
-	// with no delivery channel, finish immediately
-	if deliveryChan == nil {
+	if err != nil && awaitEvents {
+		// the message was not enqueued so there will be no delivery report
+		p.cancelDelivery(msg)
+		awaitEvents = false
+	}
+	// without delivery reports, finish immediately
+	if deliveryChan == nil && !awaitEvents {
 		span.FinishWithOptionsExt(tracer.WithError(err))
 	}
 
 	return err
 }
 
+// Events returns the kafka Events channel of the producer. The spans of
+// delivered messages are finished as their delivery reports are received.
+func (p *Producer) Events() chan kafka.Event {
+	return p.events
+}
+
 // ProduceChannel returns a channel which can receive kafka Messages and will
 // send them to the underlying producer channel.
 func (p *Producer) ProduceChannel() chan *kafka.Message {
@@ -1,11 +1,15 @@
 package kafka
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/stretchr/testify/assert"
@@ -75,9 +79,123 @@ func TestConsumerChannel(t *testing.T) {
 		assert.Equal(t, int32(1), s.Tag("partition"))
 		assert.Equal(t, 0.3, s.Tag(ext.EventSampleRate))
 		assert.Equal(t, kafka.Offset(i+1), s.Tag("offset"))
+		assert.Equal(t, ext.SpanKindConsumer, s.Tag(ext.SpanKind))
+		assert.Equal(t, "kafka", s.Tag(ext.MessagingSystem))
+		assert.Equal(t, testTopic, s.Tag(ext.MessagingDestination))
+		assert.Equal(t, 6, s.Tag(ext.MessagingPayloadSize))
+		assert.Equal(t, testGroupID, s.Tag(ext.MessagingKafkaConsumerGroup))
 	}
 }
 
+func TestConsumerProcessingSpans(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := NewConsumer(&kafka.ConfigMap{
+		"go.events.channel.enable": true, // required for the events channel to be turned on
+		"group.id":                 testGroupID,
+		"socket.timeout.ms":        10,
+		"session.timeout.ms":       10,
+		"enable.auto.offset.store": false,
+	}, WithProcessingSpans())
+	assert.NoError(t, err)
+
+	err = c.Subscribe(testTopic, nil)
+	assert.NoError(t, err)
+
+	// the producer of the first message
+	producer := tracer.StartSpan("produce")
+	go func() {
+		for i := 1; i <= 3; i++ {
+			msg := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{
+					Topic:     &testTopic,
+					Partition: 1,
+					Offset:    kafka.Offset(i),
+				},
+			}
+			if i == 1 {
+				tracer.Inject(producer.Context(), NewMessageCarrier(msg))
+			}
+			c.Consumer.Events() <- msg
+		}
+	}()
+
+	msg1 := (<-c.Events()).(*kafka.Message)
+	msg2 := (<-c.Events()).(*kafka.Message)
+	// a message which is not processed
+	msg3 := (<-c.Events()).(*kafka.Message)
+	// no span is finished until the messages are processed
+	assert.Len(t, mt.FinishedSpans(), 0)
+
+	// the span context of the headers takes precedence over ctx
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	StartProcessing(ctx, msg1).Done(nil)
+	StartProcessing(ctx, msg2).Done(errors.New("oops"))
+	parent.Finish()
+	producer.Finish()
+
+	_, ok := pendingMessages.Load(msg3)
+	assert.True(t, ok)
+	c.Close()
+	// wait for the events channel to be closed
+	<-c.Events()
+	// the messages of the consumer are released once it is closed
+	_, ok = pendingMessages.Load(msg3)
+	assert.False(t, ok)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 4)
+	for i, s := range spans[:2] {
+		assert.Equal(t, "kafka.consume", s.OperationName())
+		assert.Equal(t, kafka.Offset(i+1), s.Tag("offset"))
+	}
+	assert.Nil(t, spans[0].Tag(ext.Error))
+	assert.Equal(t, "oops", spans[1].Tag(ext.Error).(error).Error())
+	assert.Equal(t, producer.(mocktracer.Span).SpanID(), spans[0].ParentID())
+	assert.Equal(t, parent.(mocktracer.Span).SpanID(), spans[1].ParentID())
+}
+
+func TestConsumerProcessingSkipped(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	c, err := NewConsumer(&kafka.ConfigMap{
+		"go.events.channel.enable": true, // required for the events channel to be turned on
+		"group.id":                 testGroupID,
+		"socket.timeout.ms":        10,
+		"session.timeout.ms":       10,
+		"enable.auto.offset.store": false,
+	}, WithProcessingSpans(), WithServiceName("my-consumer"))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	go func() {
+		for i := 1; i <= 2; i++ {
+			c.Consumer.Events() <- &kafka.Message{
+				TopicPartition: kafka.TopicPartition{
+					Topic:     &testTopic,
+					Partition: 1,
+					Offset:    kafka.Offset(i),
+				},
+			}
+		}
+	}()
+
+	// the first message is filtered out by the application
+	msg1 := (<-c.Events()).(*kafka.Message)
+	msg2 := (<-c.Events()).(*kafka.Message)
+	StartProcessing(context.Background(), msg2).Done(nil)
+
+	// the skipped message is released while the consumer is still open
+	_, ok := pendingMessages.Load(msg1)
+	assert.False(t, ok)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "my-consumer", spans[0].Tag(ext.ServiceName))
+}
+
 /*
 to run the integration test locally:
 
@@ -174,3 +292,139 @@ func TestConsumerPoll(t *testing.T) {
 	assert.Equal(t, "queue", s1.Tag(ext.SpanType))
 	assert.Equal(t, int32(0), s1.Tag("partition"))
 }
+
+func TestProducerDeliveryReports(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	p := &Producer{
+		cfg:      newConfig(WithDeliveryReports(true)),
+		awaiting: make(map[*deliveryOpaque]struct{}),
+	}
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &testTopic,
+			Partition: 1,
+		},
+		Value:  []byte("value"),
+		Opaque: "opaque",
+	}
+	report := p.awaitDelivery(p.startSpan(p.cfg.ctx, msg), msg)
+	assert.Equal(t, "opaque", msg.Opaque, "the message of the caller is not modified")
+	assert.Len(t, mt.FinishedSpans(), 0)
+
+	// the delivery report of the message
+	report.TopicPartition.Offset = 42
+	report.TopicPartition.Error = errors.New("delivery failed")
+	assert.True(t, p.finishDelivery(report))
+	assert.Equal(t, "opaque", report.Opaque)
+	assert.False(t, p.finishDelivery(report))
+	assert.Empty(t, p.awaiting)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "kafka.produce", s.OperationName())
+	assert.Equal(t, kafka.Offset(42), s.Tag("offset"))
+	assert.Equal(t, report.TopicPartition.Error, s.Tag(ext.Error))
+}
+
+func TestProducerDeliveryReportsDisabled(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	assert.True(t, newConfig().deliveryReports, "delivery reports are enabled by default")
+
+	p, err := NewProducer(&kafka.ConfigMap{
+		"go.delivery.reports": false,
+		"socket.timeout.ms":   10,
+	}, WithDeliveryReports(true))
+	assert.NoError(t, err)
+	assert.False(t, p.cfg.deliveryReports, "ignored without delivery reports")
+
+	msg := &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &testTopic}, Opaque: "opaque"}
+	assert.NoError(t, p.Produce(msg, nil))
+	assert.Equal(t, "opaque", msg.Opaque)
+	p.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Nil(t, spans[0].Tag(ext.Error))
+}
+
+func TestProducerCloseUndelivered(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	p, err := NewProducer(&kafka.ConfigMap{
+		"socket.timeout.ms":  10,
+		"message.timeout.ms": 60000,
+	})
+	assert.NoError(t, err)
+
+	msg := &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &testTopic}, Opaque: "opaque"}
+	assert.NoError(t, p.Produce(msg, nil))
+	assert.Equal(t, "opaque", msg.Opaque, "the message of the caller is not modified")
+	// no broker is reachable, so the message is never delivered
+	assert.Len(t, mt.FinishedSpans(), 0)
+	p.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, errNotDelivered, spans[0].Tag(ext.Error))
+}
+
+func TestProducerCloseUnreadEvents(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	p, err := NewProducer(&kafka.ConfigMap{
+		"socket.timeout.ms":  10,
+		"message.timeout.ms": 60000,
+	})
+	assert.NoError(t, err)
+
+	// the delivery reports of several messages are received, but the
+	// application has stopped reading Events
+	for i := 0; i < 3; i++ {
+		msg := &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &testTopic}}
+		p.Producer.Events() <- p.awaitDelivery(p.startSpan(p.cfg.ctx, msg), msg)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the unread events")
+	}
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 3)
+	for _, s := range spans {
+		assert.Nil(t, s.Tag(ext.Error))
+	}
+}
+
+func TestProducerStartSpanContext(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	p := &Producer{cfg: newConfig()}
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &testTopic},
+	}
+	p.startSpan(ctx, msg).Finish()
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanID(), spans[0].ParentID())
+	// the span context is injected into the headers
+	_, err := tracer.Extract(NewMessageCarrier(msg))
+	assert.NoError(t, err)
+}
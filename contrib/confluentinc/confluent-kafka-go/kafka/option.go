@@ -5,9 +5,14 @@ import (
 )
 
 type config struct {
-	ctx           context.Context
-	serviceName   string
-	analyticsRate float64
+	ctx             context.Context
+	serviceName     string
+	analyticsRate   float64
+	processingSpans bool
+	clientID        string
+	consumerGroup   string
+	hashMessageKeys bool
+	deliveryReports bool
 }
 
 // An Option customizes the config.
@@ -15,8 +20,9 @@ type Option func(cfg *config)
 
 func newConfig(opts ...Option) *config {
 	cfg := &config{
-		serviceName: "kafka",
-		ctx:         context.Background(),
+		serviceName:     "kafka",
+		ctx:             context.Background(),
+		deliveryReports: true,
 		// analyticsRate: globalconfig.AnalyticsRate(),
 	}
 	for _, opt := range opts {
@@ -39,6 +45,44 @@ func WithServiceName(serviceName string) Option {
 	}
 }
 
+// WithClientID sets the Kafka client ID recorded on spans. NewConsumer and
+// NewProducer default to the "client.id" of their config.
+func WithClientID(id string) Option {
+	return func(cfg *config) {
+		cfg.clientID = id
+	}
+}
+
+// WithConsumerGroup sets the Kafka consumer group recorded on consume spans.
+// NewConsumer defaults to the "group.id" of its config.
+func WithConsumerGroup(group string) Option {
+	return func(cfg *config) {
+		cfg.consumerGroup = group
+	}
+}
+
+// WithHashedMessageKeys tags spans with the SHA-256 hash of the keys of the
+// messages, which allows correlating messages by key without recording keys.
+func WithHashedMessageKeys() Option {
+	return func(cfg *config) {
+		cfg.hashMessageKeys = true
+	}
+}
+
+// WithDeliveryReports sets whether the spans of the messages produced without
+// a delivery channel are finished on their delivery reports, recording their
+// offset and delivery error, rather than once enqueued. It is enabled by
+// default, unless "go.delivery.reports" is disabled in the config given to
+// NewProducer, in which case no report is ever received. Producers with
+// delivery reports disabled which are given to WrapProducer should be given
+// WithDeliveryReports(false). The spans still waiting for their delivery
+// report when the producer is closed are finished with an error.
+func WithDeliveryReports(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.deliveryReports = enabled
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) Option {
 	if on {
@@ -54,3 +98,16 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithProcessingSpans makes wrapped consumers leave the tracing of received
+// messages to StartProcessing, so that consume spans cover the processing of
+// the messages rather than the time between their receptions. Received
+// messages should then be processed using StartProcessing, in order within
+// each partition and before their consumer is closed, for the options of the
+// consumer to apply. Messages may be skipped: they are released once a later
+// message of their partition is processed.
+func WithProcessingSpans() Option {
+	return func(cfg *config) {
+		cfg.processingSpans = true
+	}
+}
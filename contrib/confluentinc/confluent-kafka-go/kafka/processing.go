@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/messaging"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// pendingMessages holds the messages received by consumers configured with
+// WithProcessingSpans which are not being processed yet, along with the config
+// of their consumer. Each consumer holds its messages in its own set, released
+// when it is closed. The messages which were received before a processed
+// message of the same partition are released too, as they will not be
+// processed.
+var pendingMessages messaging.Registry
+
+// messagePartition returns the partition msg was received from.
+func messagePartition(msg *kafka.Message) messaging.Partition {
+	p := messaging.Partition{Partition: msg.TopicPartition.Partition}
+	if msg.TopicPartition.Topic != nil {
+		p.Topic = *msg.TopicPartition.Topic
+	}
+	return p
+}
+
+// Processing traces the processing of a consumed message.
+type Processing struct {
+	span ddtrace.Span
+	ctx  context.Context
+}
+
+// StartProcessing starts tracing the processing of msg, which should have been
+// received from a consumer wrapped using WithProcessingSpans, in which case the
+// options of the consumer apply. The span is a child of the span context found
+// in the message headers, or else of the span found in ctx. Done must be called
+// once the processing of the message ends.
+func StartProcessing(ctx context.Context, msg *kafka.Message) *Processing {
+	cfg, ok := pendingMessages.Take(msg)
+	if !ok {
+		cfg = newConfig()
+	}
+	parent := ctx
+	if _, err := tracer.Extract(NewMessageCarrier(msg)); err == nil {
+		// a span context found in the headers takes precedence
+		parent = context.Background()
+	}
+	span := startConsumerSpan(parent, cfg.(*config), msg)
+	return &Processing{
+		span: span,
+		ctx:  tracer.ContextWithSpan(ctx, span),
+	}
+}
+
+// Context returns a copy of the context given to StartProcessing holding the
+// span of the message, to be used as the parent of new spans.
+func (p *Processing) Context() context.Context {
+	return p.ctx
+}
+
+// Span returns the span of the message.
+func (p *Processing) Span() ddtrace.Span {
+	return p.span
+}
+
+// Done finishes the span of the message, recording err if it is not nil.
+func (p *Processing) Done(err error) {
+	p.span.FinishWithOptionsExt(tracer.WithError(err))
+}
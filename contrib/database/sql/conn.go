@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql/driver"
 	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 )
 
 var _ driver.Conn = (*tracedConn)(nil)
@@ -11,42 +13,66 @@ var _ driver.Conn = (*tracedConn)(nil)
 type tracedConn struct {
 	driver.Conn
 	*traceParams
+	// tx is the transaction in progress when transaction spans are enabled
+	tx *tracedTx
+}
+
+// txContext returns ctx holding the span of the transaction in progress, if
+// any, so that it parents the spans of the statements of the transaction.
+func (tc *tracedConn) txContext(ctx context.Context) context.Context {
+	if tc.tx != nil {
+		return tracer.ContextWithSpan(ctx, tc.tx.span)
+	}
+	return ctx
 }
 
 func (tc *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
 	start := time.Now()
+	t := &tracedTx{traceParams: tc.traceParams, conn: tc}
+	if tc.config.traceTransactions {
+		t.span = tc.startSpan(ctx, "Transaction", "", start)
+		ctx = tracer.ContextWithSpan(ctx, t.span)
+	}
 	if connBeginTx, ok := tc.Conn.(driver.ConnBeginTx); ok {
 		tx, err = connBeginTx.BeginTx(ctx, opts)
-		tc.tryTrace(ctx, "Begin", "", start, err)
-		if err != nil {
-			return nil, err
-		}
-		return &tracedTx{tx, tc.traceParams, ctx}, nil
+	} else {
+		tx, err = tc.Conn.Begin()
 	}
-	tx, err = tc.Conn.Begin()
 	tc.tryTrace(ctx, "Begin", "", start, err)
 	if err != nil {
+		if t.span != nil {
+			t.span.FinishWithOptionsExt(tracer.WithError(err))
+		}
 		return nil, err
 	}
-	return &tracedTx{tx, tc.traceParams, ctx}, nil
+	t.Tx = tx
+	t.ctx = ctx
+	if t.span != nil {
+		tc.tx = t
+	}
+	return t, nil
 }
 
 func (tc *tracedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
 	start := time.Now()
+	ctx = tc.txContext(ctx)
 	if connPrepareCtx, ok := tc.Conn.(driver.ConnPrepareContext); ok {
-		stmt, err := connPrepareCtx.PrepareContext(ctx, query)
+		stmt, err = connPrepareCtx.PrepareContext(ctx, query)
+	} else {
+		stmt, err = tc.Prepare(query)
+	}
+	if err != nil || !tc.config.mergePrepare {
 		tc.tryTrace(ctx, "Prepare", query, start, err)
-		if err != nil {
-			return nil, err
-		}
-		return &tracedStmt{stmt, tc.traceParams, ctx, query}, nil
 	}
-	stmt, err = tc.Prepare(query)
-	tc.tryTrace(ctx, "Prepare", query, start, err)
 	if err != nil {
 		return nil, err
 	}
-	return &tracedStmt{stmt, tc.traceParams, ctx, query}, nil
+	s := &tracedStmt{Stmt: stmt, traceParams: tc.traceParams, conn: tc, ctx: ctx, query: query}
+	if tc.config.mergePrepare {
+		// the preparation is covered by the span of the first execution
+		s.prepareStart = start
+	}
+	return s, nil
 }
 
 func (tc *tracedConn) Exec(query string, args []driver.Value) (driver.Result, error) {
@@ -60,7 +86,7 @@ func (tc *tracedConn) ExecContext(ctx context.Context, query string, args []driv
 	start := time.Now()
 	if execContext, ok := tc.Conn.(driver.ExecerContext); ok {
 		r, err := execContext.ExecContext(ctx, query, args)
-		tc.tryTrace(ctx, "Exec", query, start, err)
+		tc.tryTrace(tc.txContext(ctx), "Exec", query, start, err)
 		return r, err
 	}
 	dargs, err := namedValueToValue(args)
@@ -73,7 +99,7 @@ func (tc *tracedConn) ExecContext(ctx context.Context, query string, args []driv
 	default:
 	}
 	r, err = tc.Exec(query, dargs)
-	tc.tryTrace(ctx, "Exec", query, start, err)
+	tc.tryTrace(tc.txContext(ctx), "Exec", query, start, err)
 	return r, err
 }
 
@@ -98,8 +124,7 @@ func (tc *tracedConn) QueryContext(ctx context.Context, query string, args []dri
 	start := time.Now()
 	if queryerContext, ok := tc.Conn.(driver.QueryerContext); ok {
 		rows, err := queryerContext.QueryContext(ctx, query, args)
-		tc.tryTrace(ctx, "Query", query, start, err)
-		return rows, err
+		return tc.traceQuery(tc.txContext(ctx), query, start, rows, err), err
 	}
 	dargs, err := namedValueToValue(args)
 	if err != nil {
@@ -111,6 +136,5 @@ func (tc *tracedConn) QueryContext(ctx context.Context, query string, args []dri
 	default:
 	}
 	rows, err = tc.Query(query, dargs)
-	tc.tryTrace(ctx, "Query", query, start, err)
-	return rows, err
+	return tc.traceQuery(tc.txContext(ctx), query, start, rows, err), err
 }
@@ -0,0 +1,121 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path"
+	"reflect"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/database/sql/internal"
+)
+
+// OpenDB returns a DB using the given connector, tracing its connections. The
+// driver of the connector should be registered using Register, which sets the
+// name of the driver and the default options; otherwise, the name of the driver
+// is the name of its package. Use WithDSN for the spans to be tagged with the
+// details of the database.
+func OpenDB(c driver.Connector, opts ...RegisterOption) *sql.DB {
+	return openDB(c, lookupDriver(c.Driver()), opts...)
+}
+
+// openDB returns a DB using the given connector, whose driver is registered as
+// d if d is not nil.
+func openDB(c driver.Connector, d *tracedDriver, opts ...RegisterOption) *sql.DB {
+	cfg := new(registerConfig)
+	driverName := ""
+	if d != nil {
+		*cfg = *d.config
+		driverName = d.driverName
+	} else {
+		defaults(cfg)
+		driverName = path.Base(driverPkgPath(c.Driver()))
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if cfg.serviceName == "" {
+		cfg.serviceName = driverName + ".db"
+	}
+	meta, err := internal.ParseDSN(driverName, cfg.dsn)
+	if err != nil {
+		// the DSN is only used for tagging
+		meta = nil
+	}
+	tc := &tracedConnector{
+		Connector: c,
+		tp: traceParams{
+			config:     cfg,
+			driverName: driverName,
+			meta:       meta,
+		},
+	}
+	if cfg.dbStatsInterval > 0 {
+		tc.tp.stats = &dbStats{interval: cfg.dbStatsInterval}
+	}
+	db := sql.OpenDB(tc)
+	if tc.tp.stats != nil {
+		// the stats are sampled by the first traced operation.
+		tc.tp.stats.db = db
+	}
+	return db
+}
+
+// lookupDriver returns the traced driver registered for the type of d, if any.
+func lookupDriver(d driver.Driver) *tracedDriver {
+	var found *tracedDriver
+	registeredDrivers.Range(func(_, v interface{}) bool {
+		if td := v.(*tracedDriver); reflect.TypeOf(td.Driver) == reflect.TypeOf(d) {
+			found = td
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// driverPkgPath returns the import path of the package of the type of d.
+func driverPkgPath(d driver.Driver) string {
+	t := reflect.TypeOf(d)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath()
+}
+
+var _ driver.Connector = (*tracedConnector)(nil)
+
+// tracedConnector wraps a driver.Connector so that the connections it opens
+// are traced.
+type tracedConnector struct {
+	driver.Connector
+	tp traceParams
+}
+
+// Connect implements driver.Connector.
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, traceParams: &c.tp}, nil
+}
+
+var _ driver.Connector = dsnConnector{}
+
+// dsnConnector opens connections of a driver using a data source name, as
+// sql.Open does.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+// Connect implements driver.Connector.
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector.
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
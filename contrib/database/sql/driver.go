@@ -41,7 +41,7 @@ func (d *tracedDriver) Open(dsn string) (c driver.Conn, err error) {
 		config:     d.config,
 		meta:       meta,
 	}
-	return &tracedConn{conn, tp}, err
+	return &tracedConn{Conn: conn, traceParams: tp}, err
 }
 
 // traceParams stores all information relative to the tracing
@@ -50,6 +50,7 @@ type traceParams struct {
 	driverName string
 	resource   string
 	meta       map[string]string
+	stats      *dbStats
 }
 
 // tryTrace will create a span using the given arguments, but will act as a no-op when err is driver.ErrSkip.
@@ -61,6 +62,28 @@ func (tp *traceParams) tryTrace(ctx context.Context, resource string, query stri
 		// See: https://github.com/DataDog/dd-trace-go/issues/270
 		return
 	}
+	if tp.config.ignoredOperations[resource] {
+		return
+	}
+	span := tp.startSpan(ctx, resource, query, startTime)
+	span.FinishWithOptionsExt(tracer.WithError(err))
+}
+
+// traceQuery traces a query like tryTrace. When rows tracing is enabled, the
+// span is finished once the returned rows are closed instead.
+func (tp *traceParams) traceQuery(ctx context.Context, query string, startTime time.Time, rows driver.Rows, err error) driver.Rows {
+	if err != nil || !tp.config.traceRows {
+		tp.tryTrace(ctx, "Query", query, startTime, err)
+		return rows
+	}
+	return &tracedRows{
+		Rows: rows,
+		span: tp.startSpan(ctx, "Query", query, startTime),
+	}
+}
+
+// startSpan starts a span using the given arguments.
+func (tp *traceParams) startSpan(ctx context.Context, resource string, query string, startTime time.Time) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeSQL),
 		tracer.ServiceName(tp.config.serviceName),
@@ -70,6 +93,9 @@ func (tp *traceParams) tryTrace(ctx context.Context, resource string, query stri
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
 	}
 	span, _ := tracer.StartSpanFromContext(ctx, resource, opts...)
+	if tp.stats != nil {
+		tp.stats.setTags(span, resource)
+	}
 	if query != "" {
 		resource = query
 		span.SetTag(ext.DBStatement, query)
@@ -79,7 +105,7 @@ func (tp *traceParams) tryTrace(ctx context.Context, resource string, query stri
 		span.SetTag(k, v)
 	}
 	span.SetTag(ext.DBType, tp.driverName)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	return span
 }
 
 // tracedDriverName returns the name of the traced version for the given driver name.
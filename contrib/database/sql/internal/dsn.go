@@ -2,13 +2,15 @@ package internal // import "github.com/adityayuga/signalfx-go-tracing/contrib/da
 
 import (
 	"net"
+	"net/url"
 	"strings"
 
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 )
 
-// ParseDSN parses various supported DSN types (currently mysql and postgres) into a
-// map of key/value pairs which can be used as valid tags.
+// ParseDSN parses various supported DSN types (mysql, postgres, sqlserver, sqlite
+// and clickhouse) into a map of key/value pairs which can be used as valid tags.
+// Passwords are never part of the result.
 func ParseDSN(driverName, dsn string) (meta map[string]string, err error) {
 	meta = make(map[string]string)
 	switch driverName {
@@ -17,11 +19,23 @@ func ParseDSN(driverName, dsn string) (meta map[string]string, err error) {
 		if err != nil {
 			return
 		}
-	case "postgres":
+	case "postgres", "pgx":
 		meta, err = parsePostgresDSN(dsn)
 		if err != nil {
 			return
 		}
+	case "sqlserver", "mssql":
+		meta, err = parseSQLServerDSN(dsn)
+		if err != nil {
+			return
+		}
+	case "sqlite3", "sqlite":
+		meta = parseSQLiteDSN(dsn)
+	case "clickhouse":
+		meta, err = parseClickHouseDSN(dsn)
+		if err != nil {
+			return
+		}
 	default:
 		// not supported
 	}
@@ -35,8 +49,8 @@ func reduceKeys(meta map[string]string) map[string]string {
 		"user":             ext.DBUser,
 		"application_name": ext.DBApplication,
 		"dbname":           ext.DBInstance,
-		"host":             ext.TargetHost,
-		"port":             ext.TargetPort,
+		"host":             ext.PeerHostname,
+		"port":             ext.PeerPort,
 	}
 	m := make(map[string]string)
 	for k, v := range meta {
@@ -81,3 +95,79 @@ func parsePostgresDSN(dsn string) (map[string]string, error) {
 	delete(meta, "password")
 	return meta, nil
 }
+
+// parseSQLServerDSN parses a sqlserver-type dsn, in either URL or ADO form, into a map.
+func parseSQLServerDSN(dsn string) (map[string]string, error) {
+	if strings.HasPrefix(dsn, "sqlserver://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]string{
+			"host":   u.Hostname(),
+			"port":   u.Port(),
+			"dbname": u.Query().Get("database"),
+		}
+		if u.User != nil {
+			m["user"] = u.User.Username()
+		}
+		return m, nil
+	}
+	m := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(dsn, "odbc:"), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		k, v := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+		switch k {
+		case "server", "data source", "address", "addr", "network address":
+			// the server may be given as host\instance or host,port
+			if i := strings.IndexAny(v, `\,`); i >= 0 {
+				if v[i] == ',' {
+					m["port"] = v[i+1:]
+				}
+				v = v[:i]
+			}
+			m["host"] = v
+		case "port":
+			m["port"] = v
+		case "user id", "uid", "user":
+			m["user"] = v
+		case "database", "initial catalog":
+			m["dbname"] = v
+		}
+	}
+	return m, nil
+}
+
+// parseSQLiteDSN parses a sqlite-type dsn, which is a file path or URI, into a map.
+func parseSQLiteDSN(dsn string) map[string]string {
+	path := strings.TrimPrefix(dsn, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	return map[string]string{"dbname": path}
+}
+
+// parseClickHouseDSN parses a clickhouse-type dsn into a map.
+func parseClickHouseDSN(dsn string) (map[string]string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	m := map[string]string{
+		"host":   u.Hostname(),
+		"port":   u.Port(),
+		"user":   q.Get("username"),
+		"dbname": q.Get("database"),
+	}
+	if u.User != nil {
+		m["user"] = u.User.Username()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		m["dbname"] = db
+	}
+	return m, nil
+}
@@ -1,11 +1,21 @@
 package sql
 
+import "time"
+
 type registerConfig struct {
-	serviceName   string
-	analyticsRate float64
+	serviceName     string
+	analyticsRate   float64
+	traceRows       bool
+	dbStatsInterval time.Duration
+	dsn             string
+
+	traceTransactions bool
+	mergePrepare      bool
+	ignoredOperations map[string]bool
 }
 
-// RegisterOption represents an option that can be passed to Register.
+// RegisterOption represents an option that can be passed to Register, Open or
+// OpenDB.
 type RegisterOption func(*registerConfig)
 
 func defaults(cfg *registerConfig) {
@@ -20,6 +30,66 @@ func WithServiceName(name string) RegisterOption {
 	}
 }
 
+// WithDSN sets the data source name from which OpenDB derives the tags of the
+// database, such as its instance, user, host and port. Passwords are never
+// recorded. Open sets it to its data source name.
+func WithDSN(dsn string) RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.dsn = dsn
+	}
+}
+
+// WithRowsTracing makes query spans cover the iteration of the returned rows,
+// finishing when the rows are closed and recording the number of rows scanned.
+func WithRowsTracing() RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.traceRows = true
+	}
+}
+
+// WithDBStats tags the spans of databases opened using Open or OpenDB with the
+// stats of their connection pool (open and in-use connections, wait count and
+// wait duration). The stats are refreshed at most once per interval.
+func WithDBStats(interval time.Duration) RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.dbStatsInterval = interval
+	}
+}
+
+// WithTransactionSpans traces transactions with a span covering them from
+// their beginning until their commit or rollback, parenting the spans of their
+// statements.
+func WithTransactionSpans() RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.traceTransactions = true
+	}
+}
+
+// WithIgnoredOperations drops the spans of the given operations, such as
+// "Prepare", "Close" or "Ping".
+func WithIgnoredOperations(ops ...string) RegisterOption {
+	return func(cfg *registerConfig) {
+		// the map may be shared with the config given to Register
+		ignored := make(map[string]bool, len(cfg.ignoredOperations)+len(ops))
+		for op := range cfg.ignoredOperations {
+			ignored[op] = true
+		}
+		for _, op := range ops {
+			ignored[op] = true
+		}
+		cfg.ignoredOperations = ignored
+	}
+}
+
+// WithMergedPrepare replaces the span of the preparation of a statement with
+// its first execution, whose span then covers both. Failed preparations are
+// still traced.
+func WithMergedPrepare() RegisterOption {
+	return func(cfg *registerConfig) {
+		cfg.mergePrepare = true
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) RegisterOption {
 	if on {
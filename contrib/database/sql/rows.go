@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"io"
+	"reflect"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// tagRows is the number of rows scanned from the results of a query.
+const tagRows = "sql.rows"
+
+var (
+	_ driver.Rows                           = (*tracedRows)(nil)
+	_ driver.RowsNextResultSet              = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeLength           = (*tracedRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*tracedRows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*tracedRows)(nil)
+)
+
+// tracedRows is a traced version of driver.Rows. It finishes the span of its
+// query when closed. The optional interfaces of driver.Rows are forwarded to
+// the wrapped rows, falling back to the defaults of the database/sql package.
+type tracedRows struct {
+	driver.Rows
+	span ddtrace.Span
+	rows int
+	err  error
+}
+
+// Next counts the rows scanned and records any error other than io.EOF.
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch err {
+	case nil:
+		r.rows++
+	case io.EOF:
+	default:
+		r.err = err
+	}
+	return err
+}
+
+// Close finishes the span after closing the rows.
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	if r.span != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		r.span.SetTag(tagRows, r.rows)
+		r.span.FinishWithOptionsExt(tracer.WithError(r.err))
+		r.span = nil
+	}
+	return err
+}
+
+// HasNextResultSet implements driver.RowsNextResultSet.
+func (r *tracedRows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+	return false
+}
+
+// NextResultSet implements driver.RowsNextResultSet.
+func (r *tracedRows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+	return io.EOF
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (r *tracedRows) ColumnTypeScanType(index int) reflect.Type {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return rs.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *tracedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return rs.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeLength implements driver.RowsColumnTypeLength.
+func (r *tracedRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return rs.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable.
+func (r *tracedRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return rs.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+// ColumnTypePrecisionScale implements driver.RowsColumnTypePrecisionScale.
+func (r *tracedRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return rs.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
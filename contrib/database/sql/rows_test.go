@@ -0,0 +1,181 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver is a driver whose queries return the rows 1 to n, failing with
+// err after them if it is not nil.
+type fakeDriver struct {
+	n   int
+	err error
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeStmt struct{ c *fakeConn }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{n: s.c.d.n, err: s.c.d.err}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{n: c.d.n, err: c.d.err}, nil
+}
+
+type fakeRows struct {
+	i, n int
+	err  error
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	r.i++
+	dest[0] = int64(r.i)
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string { return "INT" }
+
+func TestRowsTracing(t *testing.T) {
+	Register("fake-rows", &fakeDriver{n: 3}, WithRowsTracing())
+	Register("fake-rows-error", &fakeDriver{n: 1, err: errors.New("broken")}, WithRowsTracing())
+
+	t.Run("rows", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		db, err := Open("fake-rows", "")
+		assert.NoError(err)
+		defer db.Close()
+
+		rows, err := db.Query("SELECT id FROM t")
+		assert.NoError(err)
+		types, err := rows.ColumnTypes()
+		assert.NoError(err)
+		assert.Equal("INT", types[0].DatabaseTypeName())
+		for rows.Next() {
+			assert.Len(mt.FinishedSpans(), 0)
+		}
+		assert.NoError(rows.Err())
+		rows.Close()
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal("SELECT id FROM t", spans[0].Tag(ext.ResourceName))
+		assert.Equal(3, spans[0].Tag(tagRows))
+		assert.Nil(spans[0].Tag(ext.Error))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		assert := assert.New(t)
+
+		db, err := Open("fake-rows-error", "")
+		assert.NoError(err)
+		defer db.Close()
+
+		rows, err := db.Query("SELECT id FROM t")
+		assert.NoError(err)
+		for rows.Next() {
+		}
+		assert.Error(rows.Err())
+
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		assert.Equal(1, spans[0].Tag(tagRows))
+		assert.Equal(rows.Err(), spans[0].Tag(ext.Error))
+	})
+}
+
+func TestDBStats(t *testing.T) {
+	Register("fake-stats", &fakeDriver{}, WithDBStats(time.Hour))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	db, err := Open("fake-stats", "")
+	assert.NoError(err)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id FROM t")
+	assert.NoError(err)
+	rows.Close()
+	rows, err = db.Query("SELECT id FROM t")
+	assert.NoError(err)
+	rows.Close()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	// the stats are sampled when the first query is traced, and kept for the interval
+	for _, s := range spans {
+		assert.Equal(1, s.Tag(tagOpenConnections))
+		assert.Equal(1, s.Tag(tagInUse))
+		assert.Equal(int64(0), s.Tag(tagWaitCount))
+		assert.Equal(0.0, s.Tag(tagWaitDuration))
+	}
+}
+
+func TestDBStatsStatementClose(t *testing.T) {
+	Register("fake-stats-close", &fakeDriver{}, WithDBStats(time.Nanosecond))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	db, err := Open("fake-stats-close", "")
+	assert.NoError(err)
+
+	// database/sql closes the statements of idle connections while holding the
+	// lock of the database, so their spans must not sample the stats.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stmt, err := db.Prepare("SELECT id FROM t")
+		assert.NoError(err)
+		assert.NoError(stmt.Close())
+	}()
+	select {
+	case <-done:
+		db.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing a statement blocked")
+	}
+}
@@ -15,8 +15,12 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"sync"
 )
 
+// registeredDrivers holds the traced drivers registered using Register, by name.
+var registeredDrivers sync.Map // map[string]*tracedDriver
+
 // Register tells the sql integration package about the driver that we will be tracing. It must
 // be called before Open, if that connection is to be traced. It uses the driverName suffixed
 // with ".db" as the default service name.
@@ -37,11 +41,13 @@ func Register(driverName string, driver driver.Driver, opts ...RegisterOption) {
 	if cfg.serviceName == "" {
 		cfg.serviceName = driverName + ".db"
 	}
-	sql.Register(name, &tracedDriver{
+	d := &tracedDriver{
 		Driver:     driver,
 		driverName: driverName,
 		config:     cfg,
-	})
+	}
+	sql.Register(name, d)
+	registeredDrivers.Store(name, d)
 }
 
 // errNotRegistered is returned when there is an attempt to open a database connection towards a driver
@@ -50,11 +56,21 @@ var errNotRegistered = errors.New("sqltrace: Register must be called before Open
 
 // Open returns connection to a DB using a the traced version of the given driver. In order for Open
 // to work, the driver must first be registered using Register or RegisterWithServiceName. If this
-// did not occur, Open will return an error.
-func Open(driverName, dataSourceName string) (*sql.DB, error) {
+// did not occur, Open will return an error. The given options apply to the returned DB only,
+// overriding the options given to Register.
+func Open(driverName, dataSourceName string, opts ...RegisterOption) (*sql.DB, error) {
 	name := tracedDriverName(driverName)
 	if !driverExists(name) {
 		return nil, errNotRegistered
 	}
-	return sql.Open(name, dataSourceName)
+	d, ok := registeredDrivers.Load(name)
+	if !ok || (len(opts) == 0 && d.(*tracedDriver).config.dbStatsInterval == 0) {
+		return sql.Open(name, dataSourceName)
+	}
+	td := d.(*tracedDriver)
+	c := dsnConnector{
+		dsn:    dataSourceName,
+		driver: td.Driver,
+	}
+	return openDB(c, td, append([]RegisterOption{WithDSN(dataSourceName)}, opts...)...), nil
 }
@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+)
+
+const (
+	// tagOpenConnections is the number of established connections of the pool.
+	tagOpenConnections = "sql.pool.open_connections"
+	// tagInUse is the number of connections of the pool currently in use.
+	tagInUse = "sql.pool.in_use"
+	// tagWaitCount is the total number of connections waited for.
+	tagWaitCount = "sql.pool.wait_count"
+	// tagWaitDuration is the total time blocked waiting for a connection, in milliseconds.
+	tagWaitDuration = "sql.pool.wait_duration_ms"
+)
+
+// dbStats samples the stats of the connection pool of a database.
+type dbStats struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu         sync.Mutex
+	last       time.Time
+	stats      sql.DBStats
+	refreshing bool
+}
+
+// get returns the stats of the database, refreshing them if they are older
+// than the interval. Unless sync is set, they are refreshed in the background,
+// as the database may be locked by the caller, e.g. when closing statements.
+func (s *dbStats) get(sync bool) sql.DBStats {
+	s.mu.Lock()
+	if s.refreshing || time.Since(s.last) < s.interval {
+		defer s.mu.Unlock()
+		return s.stats
+	}
+	s.refreshing = true
+	if !sync {
+		defer s.mu.Unlock()
+		go s.refresh()
+		return s.stats
+	}
+	s.mu.Unlock()
+	return s.refresh()
+}
+
+// refresh samples and returns the stats of the database.
+func (s *dbStats) refresh() sql.DBStats {
+	stats := s.db.Stats()
+	s.mu.Lock()
+	s.stats = stats
+	s.last = time.Now()
+	s.refreshing = false
+	s.mu.Unlock()
+	return stats
+}
+
+// setTags tags the span of the given operation with the stats of the database.
+// They are sampled by the caller, unless it is closing a statement, which
+// database/sql may do while holding the lock of the database.
+func (s *dbStats) setTags(span ddtrace.Span, resource string) {
+	stats := s.get(resource != "Close")
+	span.SetTag(tagOpenConnections, stats.OpenConnections)
+	span.SetTag(tagInUse, stats.InUse)
+	span.SetTag(tagWaitCount, stats.WaitCount)
+	span.SetTag(tagWaitDuration, stats.WaitDuration.Seconds()*1000)
+}
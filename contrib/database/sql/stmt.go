@@ -13,8 +13,21 @@ var _ driver.Stmt = (*tracedStmt)(nil)
 type tracedStmt struct {
 	driver.Stmt
 	*traceParams
+	conn  *tracedConn
 	ctx   context.Context
 	query string
+	// prepareStart is the start of the preparation of the statement until its
+	// first execution when prepare spans are merged
+	prepareStart time.Time
+}
+
+// startTime returns the start time of an execution of the statement.
+func (s *tracedStmt) startTime() time.Time {
+	if start := s.prepareStart; !start.IsZero() {
+		s.prepareStart = time.Time{}
+		return start
+	}
+	return time.Now()
 }
 
 // Close sends a span before closing a statement
@@ -27,10 +40,10 @@ func (s *tracedStmt) Close() (err error) {
 
 // ExecContext is needed to implement the driver.StmtExecContext interface
 func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res driver.Result, err error) {
-	start := time.Now()
+	start := s.startTime()
 	if stmtExecContext, ok := s.Stmt.(driver.StmtExecContext); ok {
 		res, err := stmtExecContext.ExecContext(ctx, args)
-		s.tryTrace(ctx, "Exec", s.query, start, err)
+		s.tryTrace(s.conn.txContext(ctx), "Exec", s.query, start, err)
 		return res, err
 	}
 	dargs, err := namedValueToValue(args)
@@ -43,17 +56,16 @@ func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue)
 	default:
 	}
 	res, err = s.Exec(dargs)
-	s.tryTrace(ctx, "Exec", s.query, start, err)
+	s.tryTrace(s.conn.txContext(ctx), "Exec", s.query, start, err)
 	return res, err
 }
 
 // QueryContext is needed to implement the driver.StmtQueryContext interface
 func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
-	start := time.Now()
+	start := s.startTime()
 	if stmtQueryContext, ok := s.Stmt.(driver.StmtQueryContext); ok {
 		rows, err := stmtQueryContext.QueryContext(ctx, args)
-		s.tryTrace(ctx, "Query", s.query, start, err)
-		return rows, err
+		return s.traceQuery(s.conn.txContext(ctx), s.query, start, rows, err), err
 	}
 	dargs, err := namedValueToValue(args)
 	if err != nil {
@@ -65,8 +77,7 @@ func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue)
 	default:
 	}
 	rows, err = s.Query(dargs)
-	s.tryTrace(ctx, "Query", s.query, start, err)
-	return rows, err
+	return s.traceQuery(s.conn.txContext(ctx), s.query, start, rows, err), err
 }
 
 // copied from stdlib database/sql package: src/database/sql/ctxutil.go
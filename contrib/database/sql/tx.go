@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql/driver"
 	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 )
 
 var _ driver.Tx = (*tracedTx)(nil)
@@ -12,7 +15,10 @@ var _ driver.Tx = (*tracedTx)(nil)
 type tracedTx struct {
 	driver.Tx
 	*traceParams
-	ctx context.Context
+	ctx  context.Context
+	conn *tracedConn
+	// span covers the whole transaction when transaction spans are enabled
+	span ddtrace.Span
 }
 
 // Commit sends a span at the end of the transaction
@@ -20,6 +26,7 @@ func (t *tracedTx) Commit() (err error) {
 	start := time.Now()
 	err = t.Tx.Commit()
 	t.tryTrace(t.ctx, "Commit", "", start, err)
+	t.finish(err)
 	return err
 }
 
@@ -28,5 +35,15 @@ func (t *tracedTx) Rollback() (err error) {
 	start := time.Now()
 	err = t.Tx.Rollback()
 	t.tryTrace(t.ctx, "Rollback", "", start, err)
+	t.finish(err)
 	return err
 }
+
+// finish finishes the span of the transaction, if any.
+func (t *tracedTx) finish(err error) {
+	if t.span == nil {
+		return
+	}
+	t.conn.tx = nil
+	t.span.FinishWithOptionsExt(tracer.WithError(err))
+}
@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionSpans(t *testing.T) {
+	Register("fake-tx", &fakeDriver{}, WithTransactionSpans())
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	db, err := Open("fake-tx", "")
+	assert.NoError(err)
+	defer db.Close()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	tx, err := db.BeginTx(ctx, nil)
+	assert.NoError(err)
+	_, err = tx.ExecContext(ctx, "UPDATE t SET name = 'a'")
+	assert.NoError(err)
+	assert.NoError(tx.Commit())
+
+	// statements outside of the transaction are not its children
+	_, err = db.ExecContext(ctx, "UPDATE t SET name = 'b'")
+	assert.NoError(err)
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	byName := make(map[string][]mocktracer.Span)
+	for _, s := range spans {
+		byName[s.OperationName()] = append(byName[s.OperationName()], s)
+	}
+	txSpan := byName["Transaction"][0]
+	assert.Equal(parent.(mocktracer.Span).SpanID(), txSpan.ParentID())
+	assert.Equal("Transaction", txSpan.Tag(ext.ResourceName))
+	assert.Equal(txSpan.SpanID(), byName["Begin"][0].ParentID())
+	assert.Equal(txSpan.SpanID(), byName["Commit"][0].ParentID())
+	assert.Len(byName["Exec"], 2)
+	assert.Equal(txSpan.SpanID(), byName["Exec"][0].ParentID())
+	assert.Equal(parent.(mocktracer.Span).SpanID(), byName["Exec"][1].ParentID())
+}
+
+func TestStatementSpans(t *testing.T) {
+	Register("fake-ignored", &fakeDriver{}, WithIgnoredOperations("Prepare", "Close"))
+	Register("fake-merged", &fakeDriver{}, WithMergedPrepare(), WithIgnoredOperations("Close"))
+
+	for _, name := range []string{"fake-ignored", "fake-merged"} {
+		t.Run(name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			assert := assert.New(t)
+
+			db, err := Open(name, "")
+			assert.NoError(err)
+			defer db.Close()
+
+			// without support for ExecerContext, the statement is prepared,
+			// executed and closed
+			_, err = db.Exec("UPDATE t SET name = ?", "a")
+			assert.NoError(err)
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			assert.Equal("Exec", spans[0].OperationName())
+			assert.Equal("UPDATE t SET name = ?", spans[0].Tag(ext.DBStatement))
+		})
+	}
+}
@@ -2,10 +2,12 @@
 package restful
 
 import (
+	"net/http"
 	"strconv"
 
 	"github.com/emicklei/go-restful"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -18,6 +20,11 @@ func FilterFunc(configOpts ...Option) restful.FilterFunction {
 		opt(cfg)
 	}
 	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if cfg.ignore.Ignore(req.Request) {
+			req.Request = httputil.PropagateContext(req.Request)
+			chain.ProcessFilter(req, resp)
+			return
+		}
 		opts := []ddtrace.StartSpanOption{
 			tracer.ServiceName(cfg.serviceName),
 			tracer.ResourceName(req.SelectedRoutePath()),
@@ -33,6 +40,7 @@ func FilterFunc(configOpts ...Option) restful.FilterFunction {
 		}
 		span, ctx := tracer.StartSpanFromContext(req.Request.Context(), "http.request", opts...)
 		defer span.Finish()
+		defer tagPanic(span, resp, cfg.recoverPanics)
 
 		// pass the span through the request context
 		req.Request = req.Request.WithContext(ctx)
@@ -44,6 +52,24 @@ func FilterFunc(configOpts ...Option) restful.FilterFunction {
 	}
 }
 
+// tagPanic records on span a panic raised by the filter chain, if any. When
+// recoverPanics is false the panic is propagated, otherwise a 500 is written.
+// It must be deferred directly.
+func tagPanic(span ddtrace.Span, resp *restful.Response, recoverPanics bool) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if recoverPanics {
+		resp.WriteHeader(http.StatusInternalServerError)
+	}
+	span.SetTag(ext.HTTPCode, strconv.Itoa(http.StatusInternalServerError))
+	httputil.TagPanic(span, p)
+	if !recoverPanics {
+		panic(p)
+	}
+}
+
 // Filter is deprecated. Please use FilterFunc.
 func Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	opts := []ddtrace.StartSpanOption{
@@ -57,6 +83,7 @@ func Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterC
 	}
 	span, ctx := tracer.StartSpanFromContext(req.Request.Context(), "http.request", opts...)
 	defer span.Finish()
+	defer tagPanic(span, resp, false)
 
 	// pass the span through the request context
 	req.Request = req.Request.WithContext(ctx)
@@ -2,6 +2,7 @@ package restful
 
 import (
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -106,6 +107,37 @@ func TestPropagation(t *testing.T) {
 	container.ServeHTTP(w, r)
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ws := new(restful.WebService)
+	ws.Filter(FilterFunc(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	))
+	ws.Route(ws.GET("/").To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/healthz").To(func(request *restful.Request, response *restful.Response) {}))
+	router := restful.NewContainer()
+	router.Add(ws)
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		ws := new(restful.WebService)
@@ -2,13 +2,12 @@
 package redigo
 
 import (
-	"bytes"
 	"context"
-	"fmt"
 	"net"
 	"net/url"
 	"strconv"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/redisutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -135,23 +134,6 @@ func (tc Conn) Do(commandName string, args ...interface{}) (reply interface{}, e
 		// See https://godoc.org/github.com/garyburd/redigo/redis#hdr-Pipelining
 		span.SetTag(ext.ResourceName, "redigo.Conn.Flush")
 	}
-	var b bytes.Buffer
-	b.WriteString(commandName)
-	for _, arg := range args {
-		b.WriteString(" ")
-		switch arg := arg.(type) {
-		case string:
-			b.WriteString(arg)
-		case int:
-			b.WriteString(strconv.Itoa(arg))
-		case int32:
-			b.WriteString(strconv.FormatInt(int64(arg), 10))
-		case int64:
-			b.WriteString(strconv.FormatInt(arg, 10))
-		case fmt.Stringer:
-			b.WriteString(arg.String())
-		}
-	}
-	span.SetTag("redis.raw_command", b.String())
+	span.SetTag("redis.raw_command", redisutil.RawCommand(commandName, args))
 	return tc.Conn.Do(commandName, args...)
 }
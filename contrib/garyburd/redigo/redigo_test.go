@@ -43,7 +43,7 @@ func TestClient(t *testing.T) {
 	assert.Equal("SET", span.Tag(ext.ResourceName))
 	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 	assert.Equal("6379", span.Tag(ext.TargetPort))
-	assert.Equal("SET 1 truck", span.Tag("redis.raw_command"))
+	assert.Equal("SET 1 ?", span.Tag("redis.raw_command"))
 	assert.Equal("2", span.Tag("redis.args_length"))
 }
 
@@ -135,7 +135,7 @@ func TestCommandsToSring(t *testing.T) {
 	assert.Equal("SADD", span.Tag(ext.ResourceName))
 	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 	assert.Equal("6379", span.Tag(ext.TargetPort))
-	assert.Equal("SADD testSet a 0 1 2 [57, 8]", span.Tag("redis.raw_command"))
+	assert.Equal("SADD testSet ? ? ? ? ?", span.Tag("redis.raw_command"))
 }
 
 func TestPool(t *testing.T) {
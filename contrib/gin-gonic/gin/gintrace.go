@@ -3,8 +3,10 @@ package gin // import "github.com/adityayuga/signalfx-go-tracing/contrib/gin-gon
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -20,6 +22,11 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		opt(cfg)
 	}
 	return func(c *gin.Context) {
+		if cfg.ignore.Ignore(c.Request) {
+			c.Request = httputil.PropagateContext(c.Request)
+			c.Next()
+			return
+		}
 		operationName := c.FullPath()
 		opts := []ddtrace.StartSpanOption{
 			tracer.ServiceName(service),
@@ -37,6 +44,20 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		}
 		span, ctx := tracer.StartSpanFromContext(c.Request.Context(), operationName, opts...)
 		defer span.Finish()
+		defer func() {
+			if p := recover(); p != nil {
+				if !c.Writer.Written() {
+					span.SetTag(ext.HTTPCode, strconv.Itoa(http.StatusInternalServerError))
+					if cfg.recoverPanics {
+						c.AbortWithStatus(http.StatusInternalServerError)
+					}
+				}
+				httputil.TagPanic(span, p)
+				if !cfg.recoverPanics {
+					panic(p)
+				}
+			}
+		}()
 
 		// pass the span through the request context
 		c.Request = c.Request.WithContext(ctx)
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -116,6 +117,39 @@ func TestError(t *testing.T) {
 	assert.Equal(wantErr.Error(), span.Tag(ext.Error).(error).Error())
 }
 
+func TestPanic(t *testing.T) {
+	for name, recoverPanics := range map[string]bool{"propagated": false, "recovered": true} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			router := gin.New()
+			router.Use(Middleware("foobar", WithPanicRecovery(recoverPanics)))
+			router.GET("/panic", func(c *gin.Context) {
+				panic("oh no")
+			})
+			r := httptest.NewRequest("GET", "/panic", nil)
+			w := httptest.NewRecorder()
+			if recoverPanics {
+				router.ServeHTTP(w, r)
+				assert.Equal(500, w.Code)
+			} else {
+				assert.PanicsWithValue("oh no", func() { router.ServeHTTP(w, r) })
+			}
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			if len(spans) < 1 {
+				t.Fatalf("no spans")
+			}
+			span := spans[0]
+			assert.Equal("500", span.Tag(ext.HTTPCode))
+			assert.Equal("panic: oh no", span.Tag(ext.Error).(error).Error())
+		})
+	}
+}
+
 func TestHTML(t *testing.T) {
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -197,6 +231,35 @@ func TestPropagation(t *testing.T) {
 	router.ServeHTTP(w, r)
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := gin.New()
+	router.Use(Middleware("foobar",
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	))
+	router.GET("/", func(_ *gin.Context) {})
+	router.GET("/healthz", func(_ *gin.Context) {})
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		router := gin.New()
@@ -1,14 +1,22 @@
 package gin
 
-import "github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
+import (
+	"net/http"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
+	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
+)
 
 type config struct {
 	analyticsRate float64
+	recoverPanics bool
+	ignore        httputil.IgnoreRules
 }
 
 func newConfig() *config {
 	return &config{
 		analyticsRate: globalconfig.AnalyticsRate(),
+		ignore:        httputil.DefaultIgnoreRules(),
 	}
 }
 
@@ -30,3 +38,42 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithIgnoreRequest serves the requests for which fn returns true without tracing
+// them. The span context propagated in the headers of an ignored request is
+// still passed on to the handler through the request context, so that the spans
+// it starts continue the trace of the caller.
+func WithIgnoreRequest(fn func(*http.Request) bool) Option {
+	return func(cfg *config) {
+		cfg.ignore.Funcs = append(cfg.ignore.Funcs, fn)
+	}
+}
+
+// WithIgnorePaths serves the requests whose path matches any of the given glob
+// patterns without tracing them, as with WithIgnoreRequest. In patterns, '*'
+// matches any sequence of characters, including '/', and '?' matches any single
+// character. The patterns add to those set by tracing.WithHTTPIgnorePaths.
+func WithIgnorePaths(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.ignore.Paths = append(cfg.ignore.Paths, globs...)
+	}
+}
+
+// WithIgnoreUserAgents serves the requests whose User-Agent header matches any of
+// the given glob patterns without tracing them, as with WithIgnoreRequest. The
+// patterns follow the syntax of WithIgnorePaths and add to those set by
+// tracing.WithHTTPIgnoreUserAgents.
+func WithIgnoreUserAgents(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.ignore.UserAgents = append(cfg.ignore.UserAgents, globs...)
+	}
+}
+
+// WithPanicRecovery sets whether panics raised by handlers are recovered and
+// answered with a 500 status. By default, the panic is recorded on the span
+// and propagated to the caller.
+func WithPanicRecovery(on bool) Option {
+	return func(cfg *config) {
+		cfg.recoverPanics = on
+	}
+}
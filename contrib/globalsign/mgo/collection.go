@@ -1,6 +1,8 @@
 package mgo
 
 import (
+	"context"
+
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 
 	"github.com/globalsign/mgo"
@@ -15,10 +17,21 @@ type Collection struct {
 	tags map[string]string
 }
 
+// WithContext returns a copy of the Collection whose spans are children of the span in ctx.
+func (c *Collection) WithContext(ctx context.Context) *Collection {
+	newcfg := *c.cfg
+	newcfg.ctx = ctx
+	return &Collection{
+		Collection: c.Collection,
+		cfg:        &newcfg,
+		tags:       c.tags,
+	}
+}
+
 // Create invokes and traces Collection.Create
 func (c *Collection) Create(info *mgo.CollectionInfo) error {
-	c.tags = setTagsForCommand(c.tags, "collection.create")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.create")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.Create(info)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -26,8 +39,8 @@ func (c *Collection) Create(info *mgo.CollectionInfo) error {
 
 // DropCollection invokes and traces Collection.DropCollection
 func (c *Collection) DropCollection() error {
-	c.tags = setTagsForCommand(c.tags, "collection.dropcollection")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.dropcollection")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.DropCollection()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -35,8 +48,8 @@ func (c *Collection) DropCollection() error {
 
 // EnsureIndexKey invokes and traces Collection.EnsureIndexKey
 func (c *Collection) EnsureIndexKey(key ...string) error {
-	c.tags = setTagsForCommand(c.tags, "collection.ensureindexkey")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.ensureindexkey")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.EnsureIndexKey(key...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -44,8 +57,8 @@ func (c *Collection) EnsureIndexKey(key ...string) error {
 
 // EnsureIndex invokes and traces Collection.EnsureIndex
 func (c *Collection) EnsureIndex(index mgo.Index) error {
-	c.tags = setTagsForCommand(c.tags, "collection.ensureindex")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.ensureindex")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.EnsureIndex(index)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -53,8 +66,8 @@ func (c *Collection) EnsureIndex(index mgo.Index) error {
 
 // DropIndex invokes and traces Collection.DropIndex
 func (c *Collection) DropIndex(key ...string) error {
-	c.tags = setTagsForCommand(c.tags, "collection.dropindex")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.dropindex")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.DropIndex(key...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -62,8 +75,8 @@ func (c *Collection) DropIndex(key ...string) error {
 
 // DropIndexName invokes and traces Collection.DropIndexName
 func (c *Collection) DropIndexName(name string) error {
-	c.tags = setTagsForCommand(c.tags, "collection.dropindexname")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.dropindexname")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.DropIndexName(name)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -71,8 +84,8 @@ func (c *Collection) DropIndexName(name string) error {
 
 // Indexes invokes and traces Collection.Indexes
 func (c *Collection) Indexes() (indexes []mgo.Index, err error) {
-	c.tags = setTagsForCommand(c.tags, "collection.indexes")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.indexes")
+	span := newChildSpanFromContext(c.cfg, tags)
 	indexes, err = c.Collection.Indexes()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return indexes, err
@@ -80,8 +93,8 @@ func (c *Collection) Indexes() (indexes []mgo.Index, err error) {
 
 // Insert invokes and traces Collectin.Insert
 func (c *Collection) Insert(docs ...interface{}) error {
-	c.tags = setTagsForCommand(c.tags, "collection.insert")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.insert")
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.Insert(docs...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -92,7 +105,7 @@ func (c *Collection) Find(query interface{}) *Query {
 	return &Query{
 		Query: c.Collection.Find(query),
 		cfg:   c.cfg,
-		tags:  c.tags,
+		tags:  withStatement(c.tags, query),
 	}
 }
 
@@ -101,14 +114,14 @@ func (c *Collection) FindId(id interface{}) *Query { // nolint
 	return &Query{
 		Query: c.Collection.FindId(id),
 		cfg:   c.cfg,
-		tags:  c.tags,
+		tags:  withStatement(c.tags, bson.D{{Name: "_id", Value: id}}),
 	}
 }
 
 // Count invokes and traces Collection.Count
 func (c *Collection) Count() (n int, err error) {
-	c.tags = setTagsForCommand(c.tags, "collection.count")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForCommand(c.tags, "collection.count")
+	span := newChildSpanFromContext(c.cfg, tags)
 	n, err = c.Collection.Count()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return n, err
@@ -119,15 +132,13 @@ func (c *Collection) Bulk() *Bulk {
 	return &Bulk{
 		Bulk: c.Collection.Bulk(),
 		cfg:  c.cfg,
+		tags: c.tags,
 	}
 }
 
-// NewIter invokes and traces Collection.Iter
+// NewIter invokes Collection.NewIter and traces the iteration of the resulting cursor.
 func (c *Collection) NewIter(session *mgo.Session, firstBatch []bson.Raw, cursorId int64, err error) *Iter { // nolint
-	return &Iter{
-		Iter: c.Collection.NewIter(session, firstBatch, cursorId, err),
-		cfg:  c.cfg,
-	}
+	return newIter(c.Collection.NewIter(session, firstBatch, cursorId, err), c.cfg, c.tags, "collection.newiter", 0)
 }
 
 // Pipe invokes and traces Collection.Pipe
@@ -135,14 +146,14 @@ func (c *Collection) Pipe(pipeline interface{}) *Pipe {
 	return &Pipe{
 		Pipe: c.Collection.Pipe(pipeline),
 		cfg:  c.cfg,
-		tags: c.tags,
+		tags: withStatement(c.tags, pipeline),
 	}
 }
 
 // Update invokes and traces Collection.Update
 func (c *Collection) Update(selector interface{}, update interface{}) error {
-	c.tags = setTagsForCommand(c.tags, "collection.update")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.update", selector)
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.Update(selector, update)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -150,8 +161,8 @@ func (c *Collection) Update(selector interface{}, update interface{}) error {
 
 // UpdateId invokes and traces Collection.UpdateId
 func (c *Collection) UpdateId(id interface{}, update interface{}) error { // nolint
-	c.tags = setTagsForCommand(c.tags, "collection.updateid")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.updateid", bson.D{{Name: "_id", Value: id}})
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.UpdateId(id, update)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -159,8 +170,8 @@ func (c *Collection) UpdateId(id interface{}, update interface{}) error { // nol
 
 // UpdateAll invokes and traces Collection.UpdateAll
 func (c *Collection) UpdateAll(selector interface{}, update interface{}) (info *mgo.ChangeInfo, err error) {
-	c.tags = setTagsForCommand(c.tags, "collection.updateall")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.updateall", selector)
+	span := newChildSpanFromContext(c.cfg, tags)
 	info, err = c.Collection.UpdateAll(selector, update)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
@@ -168,8 +179,8 @@ func (c *Collection) UpdateAll(selector interface{}, update interface{}) (info *
 
 // Upsert invokes and traces Collection.Upsert
 func (c *Collection) Upsert(selector interface{}, update interface{}) (info *mgo.ChangeInfo, err error) {
-	c.tags = setTagsForCommand(c.tags, "collection.upsert")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.upsert", selector)
+	span := newChildSpanFromContext(c.cfg, tags)
 	info, err = c.Collection.Upsert(selector, update)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
@@ -177,8 +188,8 @@ func (c *Collection) Upsert(selector interface{}, update interface{}) (info *mgo
 
 // UpsertId invokes and traces Collection.UpsertId
 func (c *Collection) UpsertId(id interface{}, update interface{}) (info *mgo.ChangeInfo, err error) { // nolint
-	c.tags = setTagsForCommand(c.tags, "collection.upsertid")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.upsertid", bson.D{{Name: "_id", Value: id}})
+	span := newChildSpanFromContext(c.cfg, tags)
 	info, err = c.Collection.UpsertId(id, update)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
@@ -186,8 +197,8 @@ func (c *Collection) UpsertId(id interface{}, update interface{}) (info *mgo.Cha
 
 // Remove invokes and traces Collection.Remove
 func (c *Collection) Remove(selector interface{}) error {
-	c.tags = setTagsForCommand(c.tags, "collection.remove")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.remove", selector)
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.Remove(selector)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -195,8 +206,8 @@ func (c *Collection) Remove(selector interface{}) error {
 
 // RemoveId invokes and traces Collection.RemoveId
 func (c *Collection) RemoveId(id interface{}) error { // nolint
-	c.tags = setTagsForCommand(c.tags, "collection.removeid")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.removeid", bson.D{{Name: "_id", Value: id}})
+	span := newChildSpanFromContext(c.cfg, tags)
 	err := c.Collection.RemoveId(id)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -204,21 +215,14 @@ func (c *Collection) RemoveId(id interface{}) error { // nolint
 
 // RemoveAll invokes and traces Collection.RemoveAll
 func (c *Collection) RemoveAll(selector interface{}) (info *mgo.ChangeInfo, err error) {
-	c.tags = setTagsForCommand(c.tags, "collection.removeall")
-	span := newChildSpanFromContext(c.cfg, c.tags)
+	tags := tagsForStatement(c.tags, "collection.removeall", selector)
+	span := newChildSpanFromContext(c.cfg, tags)
 	info, err = c.Collection.RemoveAll(selector)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
 }
 
-// Repair invokes and traces Collection.Repair
+// Repair invokes Collection.Repair and traces the iteration of the resulting cursor.
 func (c *Collection) Repair() *Iter {
-	c.tags = setTagsForCommand(c.tags, "collection.repair")
-	span := newChildSpanFromContext(c.cfg, c.tags)
-	iter := c.Collection.Repair()
-	span.Finish()
-	return &Iter{
-		Iter: iter,
-		cfg:  c.cfg,
-	}
+	return newIter(c.Collection.Repair(), c.cfg, c.tags, "collection.repair", 0)
 }
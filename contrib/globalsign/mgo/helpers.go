@@ -1,20 +1,118 @@
 package mgo
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/jsonutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+
+	"github.com/globalsign/mgo/bson"
 )
 
-func setTagsForCommand(tags map[string]string, command string) map[string]string {
-	if tags == nil {
-		tags = make(map[string]string)
+// tagsForCommand returns a copy of tags with the resource name and statement
+// of the given command set. The statement of tags, if any, is kept.
+func tagsForCommand(tags map[string]string, command string) map[string]string {
+	newtags := make(map[string]string, len(tags)+2)
+	for k, v := range tags {
+		newtags[k] = v
+	}
+	newtags[ext.ResourceName] = fmt.Sprintf("mongo.%s", command)
+	if _, ok := tags[ext.DBStatement]; ok {
+		return newtags
 	}
-
-	tags[ext.ResourceName] = fmt.Sprintf("mongo.%s", command)
 	if dbInstance, ok := tags[ext.DBInstance]; ok {
-		tags[ext.DBStatement] = fmt.Sprintf("%s %s", command, dbInstance)
+		newtags[ext.DBStatement] = fmt.Sprintf("%s %s", command, dbInstance)
+	}
+	return newtags
+}
+
+// tagsForStatement returns a copy of tags with the resource name of the given
+// command set, and its obfuscated query as the statement.
+func tagsForStatement(tags map[string]string, command string, query interface{}) map[string]string {
+	if query == nil {
+		return tagsForCommand(tags, command)
+	}
+	newtags := tagsForCommand(tags, command)
+	newtags[ext.DBStatement] = obfuscate(query)
+	return newtags
+}
+
+// withStatement returns a copy of tags with the obfuscated query set as the
+// statement, for the operations of queries and pipelines.
+func withStatement(tags map[string]string, query interface{}) map[string]string {
+	newtags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		newtags[k] = v
+	}
+	if query != nil {
+		newtags[ext.DBStatement] = obfuscate(query)
+	}
+	return newtags
+}
+
+// obfuscate returns the extended JSON representation of the given query or
+// pipeline where all values are replaced by "?", keeping field names and operators.
+func obfuscate(query interface{}) string {
+	// the query is wrapped in a document to support pipelines, which are arrays,
+	// and read back so that structs are represented by their BSON fields.
+	b, err := bson.Marshal(bson.D{{Name: "q", Value: query}})
+	if err != nil {
+		return "?"
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(b, &doc); err != nil || len(doc) != 1 {
+		return "?"
 	}
+	js, err := bson.MarshalJSON(extJSON(doc[0].Value))
+	if err != nil {
+		return "?"
+	}
+	stmt, err := jsonutil.Obfuscate(js)
+	if err != nil {
+		return "?"
+	}
+	return stmt
+}
 
-	return tags
+// extJSON returns v with the documents it holds replaced by extJSONDoc, as
+// bson.MarshalJSON does not represent bson.D as a document.
+func extJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.D:
+		return extJSONDoc(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = extJSON(v[i])
+		}
+	}
+	return v
+}
+
+// extJSONDoc is a document marshalled to extended JSON with its fields in order.
+type extJSONDoc bson.D
+
+// MarshalJSON implements json.Marshaler.
+func (d extJSONDoc) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, e := range d {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(e.Name)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		v, err := bson.MarshalJSON(extJSON(e.Value))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(bytes.TrimSpace(v))
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
 }
@@ -2,7 +2,10 @@
 package mgo // import "github.com/adityayuga/signalfx-go-tracing/contrib/globalsign/mgo"
 
 import (
+	"context"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
@@ -11,6 +14,15 @@ import (
 	"github.com/globalsign/mgo"
 )
 
+const (
+	// tagDocuments is the tag holding the number of documents returned by an iterator.
+	tagDocuments = "mongo.iter.documents"
+	// tagBatchSize is the tag holding the batch size configured for the cursor of an iterator.
+	tagBatchSize = "mongo.iter.batch_size"
+	// tagBatches is the tag holding the number of batches fetched by an iterator.
+	tagBatches = "mongo.iter.batches"
+)
+
 // Dial opens a connection to a MongoDB server and configures it
 // for tracing.
 func Dial(url string, opts ...DialOption) (*Session, error) {
@@ -39,6 +51,18 @@ type Session struct {
 	tags map[string]string
 }
 
+// WithContext returns a copy of the Session whose spans, and those of the databases
+// and collections obtained from it, are children of the span in ctx.
+func (s *Session) WithContext(ctx context.Context) *Session {
+	newcfg := *s.cfg
+	newcfg.ctx = ctx
+	return &Session{
+		Session: s.Session,
+		cfg:     &newcfg,
+		tags:    s.tags,
+	}
+}
+
 func newChildSpanFromContext(cfg *mongoConfig, tags map[string]string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(cfg.serviceName),
@@ -62,8 +86,8 @@ func newChildSpanFromContext(cfg *mongoConfig, tags map[string]string) ddtrace.S
 
 // Run invokes and traces Session.Run
 func (s *Session) Run(cmd interface{}, result interface{}) (err error) {
-	s.tags = setTagsForCommand(s.tags, "session.run")
-	span := newChildSpanFromContext(s.cfg, s.tags)
+	tags := tagsForCommand(s.tags, "session.run")
+	span := newChildSpanFromContext(s.cfg, tags)
 	err = s.Session.Run(cmd, result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return
@@ -90,6 +114,17 @@ func (s *Session) DB(name string) *Database {
 	}
 }
 
+// WithContext returns a copy of the Database whose spans are children of the span in ctx.
+func (db *Database) WithContext(ctx context.Context) *Database {
+	newcfg := *db.cfg
+	newcfg.ctx = ctx
+	return &Database{
+		Database: db.Database,
+		cfg:      &newcfg,
+		tags:     db.tags,
+	}
+}
+
 // C returns a new Collection from this Database.
 func (db *Database) C(name string) *Collection {
 	return &Collection{
@@ -99,46 +134,140 @@ func (db *Database) C(name string) *Collection {
 	}
 }
 
-// Iter is an mgo.Iter instance that will be traced.
+// Iter is an mgo.Iter instance that will be traced. Rather than tracing every call,
+// the iteration over its cursor is summarised into a single span, which is started
+// by the first call reading from the cursor, finished once the iterator is
+// exhausted, fails or is closed, and tagged with the number of documents returned.
+// When the batch size of the cursor is set with Query.Batch or Pipe.Batch, the
+// span is also tagged with it and with the number of batches fetched, derived
+// from the number of documents returned as mgo does not expose its getMore
+// operations.
 type Iter struct {
 	*mgo.Iter
-	cfg  *mongoConfig
-	tags map[string]string
+	cfg   *mongoConfig
+	tags  map[string]string
+	batch int // the batch size of the cursor, or 0 for the server default
+
+	mu      sync.Mutex // guards the fields below
+	started bool
+	span    ddtrace.Span // nil until started and once finished
+	docs    int
+}
+
+// newIter returns an Iter tracing the iteration of iter under the given command,
+// whose cursor fetches batches of the given size.
+func newIter(iter *mgo.Iter, cfg *mongoConfig, tags map[string]string, command string, batch int) *Iter {
+	return &Iter{
+		Iter:  iter,
+		cfg:   cfg,
+		tags:  tagsForCommand(tags, command),
+		batch: batch,
+	}
+}
+
+// batchSize returns the batch size used by mgo when given n, which interprets
+// a batch size of 1 as 2 as the server would otherwise close the cursor.
+func batchSize(n int) int {
+	if n == 1 {
+		return 2
+	}
+	return n
+}
+
+// start starts the span of the iterator, if not already started.
+func (iter *Iter) start() {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+	if iter.started {
+		return
+	}
+	iter.started = true
+	iter.span = newChildSpanFromContext(iter.cfg, iter.tags)
+}
+
+// count adds n to the number of documents returned.
+func (iter *Iter) count(n int) {
+	iter.mu.Lock()
+	iter.docs += n
+	iter.mu.Unlock()
+}
+
+// finish finishes the span of the iterator, if started and not already finished.
+func (iter *Iter) finish(err error) {
+	iter.mu.Lock()
+	defer iter.mu.Unlock()
+	if iter.span == nil {
+		return
+	}
+	iter.span.SetTag(tagDocuments, iter.docs)
+	if iter.batch > 0 {
+		iter.span.SetTag(tagBatchSize, iter.batch)
+		// the first batch is returned by the query, even when empty
+		iter.span.SetTag(tagBatches, max(1, (iter.docs+iter.batch-1)/iter.batch))
+	}
+	iter.span.FinishWithOptionsExt(tracer.WithError(err))
+	iter.span = nil
 }
 
-// Next invokes and traces Iter.Next
+// Next invokes Iter.Next, counting the documents returned. The iteration of a
+// tailable cursor is not considered finished when Next times out, as it may be
+// resumed.
 func (iter *Iter) Next(result interface{}) bool {
-	iter.tags = setTagsForCommand(iter.tags, "iter.next")
-	span := newChildSpanFromContext(iter.cfg, iter.tags)
-	r := iter.Iter.Next(result)
-	span.Finish()
-	return r
+	iter.start()
+	if !iter.Iter.Next(result) {
+		if !iter.Iter.Timeout() {
+			iter.finish(iter.Iter.Err())
+		}
+		return false
+	}
+	iter.count(1)
+	return true
 }
 
-// For invokes and traces Iter.For
+// For invokes Iter.For, counting the documents returned.
 func (iter *Iter) For(result interface{}, f func() error) (err error) {
-	iter.tags = setTagsForCommand(iter.tags, "iter.for")
-	span := newChildSpanFromContext(iter.cfg, iter.tags)
-	err = iter.Iter.For(result, f)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	iter.start()
+	err = iter.Iter.For(result, func() error {
+		iter.count(1)
+		return f()
+	})
+	iter.finish(err)
 	return err
 }
 
-// All invokes and traces Iter.All
+// All invokes Iter.All, counting the documents returned.
 func (iter *Iter) All(result interface{}) (err error) {
-	iter.tags = setTagsForCommand(iter.tags, "iter.all")
-	span := newChildSpanFromContext(iter.cfg, iter.tags)
+	iter.start()
 	err = iter.Iter.All(result)
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	if v := reflect.ValueOf(result); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		iter.count(v.Elem().Len())
+	}
+	iter.finish(err)
+	return err
+}
+
+// Err invokes Iter.Err, finishing the span of the iterator when the iteration failed.
+func (iter *Iter) Err() error {
+	err := iter.Iter.Err()
+	if err != nil {
+		iter.finish(err)
+	}
 	return err
 }
 
-// Close invokes and traces Iter.Close
+// Done invokes Iter.Done, finishing the span of the iterator once it is exhausted.
+func (iter *Iter) Done() bool {
+	done := iter.Iter.Done()
+	if done {
+		iter.finish(iter.Iter.Err())
+	}
+	return done
+}
+
+// Close invokes Iter.Close, finishing the span of the iterator.
 func (iter *Iter) Close() (err error) {
-	iter.tags = setTagsForCommand(iter.tags, "iter.close")
-	span := newChildSpanFromContext(iter.cfg, iter.tags)
 	err = iter.Iter.Close()
-	span.FinishWithOptionsExt(tracer.WithError(err))
+	iter.finish(err)
 	return err
 }
 
@@ -151,8 +280,8 @@ type Bulk struct {
 
 // Run invokes and traces Bulk.Run
 func (b *Bulk) Run() (result *mgo.BulkResult, err error) {
-	b.tags = setTagsForCommand(b.tags, "bulk")
-	span := newChildSpanFromContext(b.cfg, b.tags)
+	tags := tagsForCommand(b.tags, "bulk")
+	span := newChildSpanFromContext(b.cfg, tags)
 	result, err = b.Bulk.Run()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 
@@ -2,7 +2,6 @@ package mgo
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -19,12 +18,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var integration bool
+
 func TestMain(m *testing.M) {
-	_, ok := os.LookupEnv("INTEGRATION")
-	if !ok {
-		fmt.Println("--- SKIP: to enable integration test, set the INTEGRATION environment variable")
-		os.Exit(0)
-	}
+	_, integration = os.LookupEnv("INTEGRATION")
 	os.Exit(m.Run())
 }
 
@@ -57,6 +54,9 @@ func testMongoCollectionCommand(assert *assert.Assertions, command func(*Collect
 }
 
 func TestCollection_Insert(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -76,6 +76,9 @@ func TestCollection_Insert(t *testing.T) {
 }
 
 func TestCollection_Update(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -96,6 +99,9 @@ func TestCollection_Update(t *testing.T) {
 }
 
 func TestCollection_UpdateId(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -113,11 +119,16 @@ func TestCollection_UpdateId(t *testing.T) {
 	}
 
 	spans := testMongoCollectionCommand(assert, insert)
-	assert.Equal(5, len(spans))
-	assert.Equal("mongo.collection.updateid", spans[3].OperationName())
+	// the iterator is neither exhausted nor closed, so its span is not finished
+	assert.Equal(3, len(spans))
+	assert.Equal("mongo.collection.updateid", spans[1].OperationName())
+	assert.Equal(`{"_id":"?"}`, spans[1].Tag(ext.DBStatement))
 }
 
 func TestCollection_Upsert(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -136,12 +147,16 @@ func TestCollection_Upsert(t *testing.T) {
 	}
 
 	spans := testMongoCollectionCommand(assert, insert)
-	assert.Equal(6, len(spans))
+	assert.Equal(4, len(spans))
 	assert.Equal("mongo.collection.upsert", spans[1].OperationName())
-	assert.Equal("mongo.collection.upsertid", spans[4].OperationName())
+	assert.Equal(`{"entity":{"name":"?","value":"?"}}`, spans[1].Tag(ext.DBStatement))
+	assert.Equal("mongo.collection.upsertid", spans[2].OperationName())
 }
 
 func TestCollection_UpdateAll(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -162,6 +177,9 @@ func TestCollection_UpdateAll(t *testing.T) {
 }
 
 func TestCollection_FindId(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -180,10 +198,13 @@ func TestCollection_FindId(t *testing.T) {
 	}
 
 	spans := testMongoCollectionCommand(assert, insert)
-	assert.Equal(6, len(spans))
+	assert.Equal(2, len(spans))
 }
 
 func TestCollection_Remove(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -204,6 +225,9 @@ func TestCollection_Remove(t *testing.T) {
 }
 
 func TestCollection_RemoveId(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -225,11 +249,14 @@ func TestCollection_RemoveId(t *testing.T) {
 	}
 
 	spans := testMongoCollectionCommand(assert, removeByID)
-	assert.Equal(5, len(spans))
-	assert.Equal("mongo.collection.removeid", spans[3].OperationName())
+	assert.Equal(3, len(spans))
+	assert.Equal("mongo.collection.removeid", spans[1].OperationName())
 }
 
 func TestCollection_RemoveAll(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -250,6 +277,9 @@ func TestCollection_RemoveAll(t *testing.T) {
 }
 
 func TestCollection_DropCollection(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	insert := func(collection *Collection) {
@@ -262,6 +292,9 @@ func TestCollection_DropCollection(t *testing.T) {
 }
 
 func TestCollection_Create(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	insert := func(collection *Collection) {
@@ -274,6 +307,9 @@ func TestCollection_Create(t *testing.T) {
 }
 
 func TestCollection_Count(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	insert := func(collection *Collection) {
@@ -286,6 +322,9 @@ func TestCollection_Count(t *testing.T) {
 }
 
 func TestCollection_IndexCommands(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	indexTest := func(collection *Collection) {
@@ -307,6 +346,9 @@ func TestCollection_IndexCommands(t *testing.T) {
 }
 
 func TestCollection_FindAndIter(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -328,17 +370,45 @@ func TestCollection_FindAndIter(t *testing.T) {
 		var all []bson.D
 		iter.All(&all)
 		iter.Close()
+
+		// no span is started for iterators which are never read
+		collection.Find(nil).Iter().Close()
 	}
 
 	spans := testMongoCollectionCommand(assert, insert)
-	assert.Equal(8, len(spans))
+	// the iteration is summarised into a single span
+	assert.Equal(5, len(spans))
 	assert.Equal("mongo.query.iter", spans[3].OperationName())
-	assert.Equal("mongo.iter.next", spans[4].OperationName())
-	assert.Equal("mongo.iter.all", spans[5].OperationName())
-	assert.Equal("mongo.iter.close", spans[6].OperationName())
+	assert.Equal(3, spans[3].Tag("mongo.iter.documents"))
+}
+
+func TestPipe_Errors(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+
+	var oneErr, explainErr error
+	spans := testMongoCollectionCommand(assert, func(collection *Collection) {
+		// an unknown stage fails the aggregation
+		pipeline := []bson.M{{"$unknown": 1}}
+		oneErr = collection.Pipe(pipeline).One(&bson.M{})
+		explainErr = collection.Pipe(pipeline).Explain(&bson.M{})
+	})
+
+	assert.Error(oneErr)
+	assert.Error(explainErr)
+	assert.Equal(3, len(spans))
+	assert.Equal("mongo.pipe.findone", spans[0].OperationName())
+	assert.Equal("mongo.pipe.explain", spans[1].OperationName())
+	assert.Equal(oneErr, spans[0].Tag(ext.Error))
+	assert.Equal(explainErr, spans[1].Tag(ext.Error))
 }
 
 func TestCollection_Bulk(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	entity := bson.D{
@@ -360,6 +430,9 @@ func TestCollection_Bulk(t *testing.T) {
 }
 
 func TestAnalyticsSettings(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...DialOption) {
 		assert := assert.New(t)
 
@@ -433,6 +506,9 @@ func TestAnalyticsSettings(t *testing.T) {
 }
 
 func TestWithZipkin(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 
 	zipkin := zipkinserver.Start()
@@ -531,3 +607,96 @@ func TestWithZipkin(t *testing.T) {
 		assert.Contains(ann["error.object"], "&mgo.QueryError{")
 	})
 }
+
+func TestWithContext(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	session, err := Dial("localhost:27017", WithServiceName("unit-tests"))
+	assert.Nil(err)
+	defer session.Close()
+
+	entity := bson.D{bson.DocElem{Name: "entity", Value: 0}}
+	parentSpan, ctx := tracer.StartSpanFromContext(context.Background(), "mgo-unittest")
+	collection := session.DB("my_db").C("MyCollection")
+	collection.WithContext(ctx).Insert(entity)
+	parentSpan.Finish()
+	collection.Insert(entity)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	assert.Equal(spans[1].SpanID(), spans[0].ParentID())
+	// the collection itself is left unbound
+	assert.Equal(uint64(0), spans[2].ParentID())
+}
+
+func TestObfuscate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`{"name":"?","age":{"$gt":"?"}}`, obfuscate(bson.D{
+		{Name: "name", Value: "alice"},
+		{Name: "age", Value: bson.M{"$gt": 30}},
+	}))
+	assert.Equal(`{"_id":{"$in":["?"]}}`, obfuscate(bson.M{"_id": bson.M{"$in": []int{1, 2, 3}}}))
+	assert.Equal(`[{"$match":{"status":"?"}},{"$limit":"?"}]`, obfuscate([]bson.M{
+		{"$match": bson.M{"status": "active"}},
+		{"$limit": 10},
+	}))
+	assert.Equal(`{"name":"?"}`, obfuscate(struct{ Name string }{"bob"}))
+	assert.Equal(`{"_id":{"$oid":"?"},"tags":["?"]}`, obfuscate(bson.D{
+		{Name: "_id", Value: bson.NewObjectId()},
+		{Name: "tags", Value: []string{"a", "b"}},
+	}))
+}
+
+func TestTagsForCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	tags := map[string]string{ext.DBInstance: "my_db"}
+	newtags := tagsForCommand(tags, "collection.insert")
+	assert.Equal("mongo.collection.insert", newtags[ext.ResourceName])
+	assert.Equal("collection.insert my_db", newtags[ext.DBStatement])
+	// the given tags are left unchanged
+	assert.Equal(map[string]string{ext.DBInstance: "my_db"}, tags)
+
+	newtags = tagsForStatement(tags, "collection.remove", bson.M{"name": "alice"})
+	assert.Equal(`{"name":"?"}`, newtags[ext.DBStatement])
+	assert.Equal(`{"name":"?"}`, tagsForCommand(newtags, "query.one")[ext.DBStatement])
+}
+
+func TestIterBatches(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for _, tt := range []struct {
+		batch, docs int
+		batches     interface{}
+	}{
+		{batch: 10, docs: 25, batches: 3},
+		{batch: 10, docs: 20, batches: 2},
+		{batch: 10, docs: 0, batches: 1},
+		{batch: 0, docs: 25, batches: nil},
+	} {
+		mt.Reset()
+		iter := newIter(nil, newConfig(), nil, "query.iter", tt.batch)
+		iter.start()
+		iter.count(tt.docs)
+		iter.finish(nil)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, tt.docs, spans[0].Tag(tagDocuments))
+		assert.Equal(t, tt.batches, spans[0].Tag(tagBatches))
+		if tt.batch > 0 {
+			assert.Equal(t, tt.batch, spans[0].Tag(tagBatchSize))
+		} else {
+			assert.Nil(t, spans[0].Tag(tagBatchSize))
+		}
+	}
+	assert.Equal(t, 2, batchSize(1))
+	assert.Equal(t, 100, batchSize(100))
+}
@@ -8,21 +8,22 @@ import (
 // Pipe is an mgo.Pipe instance along with the data necessary for tracing.
 type Pipe struct {
 	*mgo.Pipe
-	cfg  *mongoConfig
-	tags map[string]string
+	cfg   *mongoConfig
+	tags  map[string]string
+	batch int
 }
 
-// Iter invokes and traces Pipe.Iter
+// Batch invokes Pipe.Batch, recording the batch size on the span of the
+// iterator of the pipeline.
+func (p *Pipe) Batch(n int) *Pipe {
+	p.Pipe.Batch(n)
+	p.batch = n
+	return p
+}
+
+// Iter invokes Pipe.Iter and traces the iteration of the resulting cursor.
 func (p *Pipe) Iter() *Iter {
-	p.tags = setTagsForCommand(p.tags, "pipe.iter")
-	span := newChildSpanFromContext(p.cfg, p.tags)
-	iter := p.Pipe.Iter()
-	span.Finish()
-	return &Iter{
-		Iter: iter,
-		cfg:  p.cfg,
-		tags: p.tags,
-	}
+	return newIter(p.Pipe.Iter(), p.cfg, p.tags, "pipe.iter", p.batch)
 }
 
 // All invokes and traces Pipe.All
@@ -31,19 +32,19 @@ func (p *Pipe) All(result interface{}) error {
 }
 
 // One invokes and traces Pipe.One
-func (p *Pipe) One(result interface{}) (err error) {
-	p.tags = setTagsForCommand(p.tags, "pipe.findone")
-	span := newChildSpanFromContext(p.cfg, p.tags)
-	defer span.FinishWithOptionsExt(tracer.WithError(err))
-	err = p.Pipe.One(result)
-	return
+func (p *Pipe) One(result interface{}) error {
+	tags := tagsForCommand(p.tags, "pipe.findone")
+	span := newChildSpanFromContext(p.cfg, tags)
+	err := p.Pipe.One(result)
+	span.FinishWithOptionsExt(tracer.WithError(err))
+	return err
 }
 
 // Explain invokes and traces Pipe.Explain
-func (p *Pipe) Explain(result interface{}) (err error) {
-	p.tags = setTagsForCommand(p.tags, "pipe.explain")
-	span := newChildSpanFromContext(p.cfg, p.tags)
-	defer span.FinishWithOptionsExt(tracer.WithError(err))
-	err = p.Pipe.Explain(result)
-	return
+func (p *Pipe) Explain(result interface{}) error {
+	tags := tagsForCommand(p.tags, "pipe.explain")
+	span := newChildSpanFromContext(p.cfg, tags)
+	err := p.Pipe.Explain(result)
+	span.FinishWithOptionsExt(tracer.WithError(err))
+	return err
 }
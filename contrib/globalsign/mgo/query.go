@@ -11,26 +11,28 @@ import (
 // Query is an mgo.Query instance along with the data necessary for tracing.
 type Query struct {
 	*mgo.Query
-	cfg  *mongoConfig
-	tags map[string]string
+	cfg   *mongoConfig
+	tags  map[string]string
+	batch int
 }
 
-// Iter invokes and traces Query.Iter
+// Batch invokes Query.Batch, recording the batch size on the spans of the
+// iterators of the query.
+func (q *Query) Batch(n int) *Query {
+	q.Query.Batch(n)
+	q.batch = batchSize(n)
+	return q
+}
+
+// Iter invokes Query.Iter and traces the iteration of the resulting cursor.
 func (q *Query) Iter() *Iter {
-	q.tags = setTagsForCommand(q.tags, "query.iter")
-	span := newChildSpanFromContext(q.cfg, q.tags)
-	iter := q.Query.Iter()
-	span.Finish()
-	return &Iter{
-		Iter: iter,
-		cfg:  q.cfg,
-	}
+	return newIter(q.Query.Iter(), q.cfg, q.tags, "query.iter", q.batch)
 }
 
 // All invokes and traces Query.All
 func (q *Query) All(result interface{}) error {
-	q.tags = setTagsForCommand(q.tags, "query.all")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.all")
+	span := newChildSpanFromContext(q.cfg, tags)
 	err := q.Query.All(result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -38,8 +40,8 @@ func (q *Query) All(result interface{}) error {
 
 // Apply invokes and traces Query.Apply. Triggered by update/upsert or remove
 func (q *Query) Apply(change mgo.Change, result interface{}) (info *mgo.ChangeInfo, err error) {
-	q.tags = setTagsForCommand(q.tags, getChangeCommand(info))
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, getChangeCommand(change))
+	span := newChildSpanFromContext(q.cfg, tags)
 	info, err = q.Query.Apply(change, result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
@@ -47,8 +49,8 @@ func (q *Query) Apply(change mgo.Change, result interface{}) (info *mgo.ChangeIn
 
 // Count invokes and traces Query.Count
 func (q *Query) Count() (n int, err error) {
-	q.tags = setTagsForCommand(q.tags, "query.count")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.count")
+	span := newChildSpanFromContext(q.cfg, tags)
 	n, err = q.Query.Count()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return n, err
@@ -56,8 +58,8 @@ func (q *Query) Count() (n int, err error) {
 
 // Distinct invokes and traces Query.Distinct
 func (q *Query) Distinct(key string, result interface{}) error {
-	q.tags = setTagsForCommand(q.tags, "query.distinct")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.distinct")
+	span := newChildSpanFromContext(q.cfg, tags)
 	err := q.Query.Distinct(key, result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -65,8 +67,8 @@ func (q *Query) Distinct(key string, result interface{}) error {
 
 // Explain invokes and traces Query.Explain
 func (q *Query) Explain(result interface{}) error {
-	q.tags = setTagsForCommand(q.tags, "query.explain")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.explain")
+	span := newChildSpanFromContext(q.cfg, tags)
 	err := q.Query.Explain(result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -74,8 +76,8 @@ func (q *Query) Explain(result interface{}) error {
 
 // For invokes and traces Query.For
 func (q *Query) For(result interface{}, f func() error) error {
-	q.tags = setTagsForCommand(q.tags, "query.for")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.for")
+	span := newChildSpanFromContext(q.cfg, tags)
 	err := q.Query.For(result, f)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -83,8 +85,8 @@ func (q *Query) For(result interface{}, f func() error) error {
 
 // MapReduce invokes and traces Query.MapReduce
 func (q *Query) MapReduce(job *mgo.MapReduce, result interface{}) (info *mgo.MapReduceInfo, err error) {
-	q.tags = setTagsForCommand(q.tags, "query.mapreduce")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.mapreduce")
+	span := newChildSpanFromContext(q.cfg, tags)
 	info, err = q.Query.MapReduce(job, result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return info, err
@@ -92,31 +94,26 @@ func (q *Query) MapReduce(job *mgo.MapReduce, result interface{}) (info *mgo.Map
 
 // One invokes and traces Query.One
 func (q *Query) One(result interface{}) error {
-	q.tags = setTagsForCommand(q.tags, "query.findone")
-	span := newChildSpanFromContext(q.cfg, q.tags)
+	tags := tagsForCommand(q.tags, "query.findone")
+	span := newChildSpanFromContext(q.cfg, tags)
 	err := q.Query.One(result)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
-// Tail invokes and traces Query.Tail
+// Tail invokes Query.Tail and traces the iteration of the resulting cursor.
 func (q *Query) Tail(timeout time.Duration) *Iter {
-	q.tags = setTagsForCommand(q.tags, "query.tail")
-	span := newChildSpanFromContext(q.cfg, q.tags)
-	iter := q.Query.Tail(timeout)
-	span.Finish()
-	return &Iter{
-		Iter: iter,
-		cfg:  q.cfg,
-	}
+	return newIter(q.Query.Tail(timeout), q.cfg, q.tags, "query.tail", q.batch)
 }
 
-func getChangeCommand(change *mgo.ChangeInfo) string {
-	if change.Updated > 0 {
-		return "query.update"
-	}
-	if change.Removed > 0 {
+// getChangeCommand returns the command applied by the given change. The
+// change is used rather than its result, which is only known once applied.
+func getChangeCommand(change mgo.Change) string {
+	if change.Remove {
 		return "query.remove"
 	}
-	return "query.upsert"
+	if change.Upsert {
+		return "query.upsert"
+	}
+	return "query.update"
 }
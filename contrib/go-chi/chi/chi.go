@@ -9,6 +9,7 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -23,6 +24,10 @@ func Middleware(opts ...Option) func(next http.Handler) http.Handler {
 	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.ignore.Ignore(r) {
+				next.ServeHTTP(w, httputil.PropagateContext(r))
+				return
+			}
 			opts := []ddtrace.StartSpanOption{
 				tracer.SpanType(ext.SpanTypeWeb),
 				tracer.ServiceName(cfg.serviceName),
@@ -40,26 +45,40 @@ func Middleware(opts ...Option) func(next http.Handler) http.Handler {
 			defer span.Finish()
 
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				p := recover()
 
-			// pass the span through the request context and serve the request to the next middleware
-			next.ServeHTTP(ww, r.WithContext(ctx))
+				// set the resource name as we get it only once the handler is executed
+				resourceName := chi.RouteContext(r.Context()).RoutePattern()
+				if resourceName == "" {
+					resourceName = "unknown"
+				}
+				resourceName = r.Method + " " + resourceName
+				span.SetTag(ext.ResourceName, resourceName)
 
-			// set the resource name as we get it only once the handler is executed
-			resourceName := chi.RouteContext(r.Context()).RoutePattern()
-			if resourceName == "" {
-				resourceName = "unknown"
-			}
-			resourceName = r.Method + " " + resourceName
-			span.SetTag(ext.ResourceName, resourceName)
+				// set the status code
+				status := ww.Status()
+				if p != nil && status == 0 {
+					status = http.StatusInternalServerError
+					if cfg.recoverPanics {
+						ww.WriteHeader(status)
+					}
+				}
+				span.SetTag(ext.HTTPCode, strconv.Itoa(status))
 
-			// set the status code
-			status := ww.Status()
-			span.SetTag(ext.HTTPCode, strconv.Itoa(status))
+				if p != nil {
+					httputil.TagPanic(span, p)
+					if !cfg.recoverPanics {
+						panic(p)
+					}
+				} else if status >= 500 && status < 600 {
+					// mark 5xx server error
+					span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+				}
+			}()
 
-			if status >= 500 && status < 600 {
-				// mark 5xx server error
-				span.SetTag(ext.Error, fmt.Errorf("%d: %s", status, http.StatusText(status)))
-			}
+			// pass the span through the request context and serve the request to the next middleware
+			next.ServeHTTP(ww, r.WithContext(ctx))
 		})
 	}
 }
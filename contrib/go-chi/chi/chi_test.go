@@ -106,6 +106,40 @@ func TestError(t *testing.T) {
 	assert.Equal(wantErr, span.Tag(ext.Error).(error).Error())
 }
 
+func TestPanic(t *testing.T) {
+	for name, recoverPanics := range map[string]bool{"propagated": false, "recovered": true} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			router := chi.NewRouter()
+			router.Use(Middleware(WithServiceName("foobar"), WithPanicRecovery(recoverPanics)))
+			router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+				panic("oh no")
+			})
+			r := httptest.NewRequest("GET", "/panic", nil)
+			w := httptest.NewRecorder()
+			if recoverPanics {
+				router.ServeHTTP(w, r)
+				assert.Equal(500, w.Code)
+			} else {
+				assert.PanicsWithValue("oh no", func() { router.ServeHTTP(w, r) })
+			}
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			if len(spans) < 1 {
+				t.Fatalf("no spans")
+			}
+			span := spans[0]
+			assert.Equal("GET /panic", span.Tag(ext.ResourceName))
+			assert.Equal("500", span.Tag(ext.HTTPCode))
+			assert.Equal("panic: oh no", span.Tag(ext.Error).(error).Error())
+		})
+	}
+}
+
 func TestGetSpanNotInstrumented(t *testing.T) {
 	assert := assert.New(t)
 	router := chi.NewRouter()
@@ -144,6 +178,35 @@ func TestPropagation(t *testing.T) {
 	router.ServeHTTP(w, r)
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := chi.NewRouter()
+	router.Use(Middleware(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		router := chi.NewRouter()
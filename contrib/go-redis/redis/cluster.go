@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-redis/redis"
+)
+
+// ClusterClient is used to trace requests to a Redis cluster. The spans of
+// single commands are tagged with the address of the node serving them.
+type ClusterClient struct {
+	*redis.ClusterClient
+	*params
+
+	ctx context.Context
+}
+
+var _ redis.UniversalClient = (*ClusterClient)(nil)
+
+// NewClusterClient returns a new ClusterClient that is traced with the default
+// tracer under the service name "redis.client".
+func NewClusterClient(opt *redis.ClusterOptions, opts ...ClientOption) *ClusterClient {
+	return WrapClusterClient(redis.NewClusterClient(opt), opts...)
+}
+
+// WrapClusterClient wraps a given redis.ClusterClient with a tracer. It should be
+// called before the client is used, so that every node it connects to is traced.
+func WrapClusterClient(c *redis.ClusterClient, opts ...ClientOption) *ClusterClient {
+	params := newParams(opts)
+	params.db = "0"
+	params.inflight = new(sync.Map)
+	tc := &ClusterClient{ClusterClient: c, params: params, ctx: context.Background()}
+	opt := c.Options()
+	onNewNode := opt.OnNewNode
+	opt.OnNewNode = func(node *redis.Client) {
+		traceNode(params, node)
+		if onNewNode != nil {
+			onNewNode(node)
+		}
+	}
+	tc.ClusterClient.WrapProcess(createWrapper(params, tc.Context))
+	return tc
+}
+
+// Pipeline creates a traced Pipeline from a ClusterClient.
+func (c *ClusterClient) Pipeline() redis.Pipeliner {
+	return &Pipeliner{c.ClusterClient.Pipeline(), c.params, c.Context(), false}
+}
+
+// Pipelined executes the commands queued by fn in a traced pipeline.
+func (c *ClusterClient) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.Pipeline().Pipelined(fn)
+}
+
+// TxPipeline creates a traced Pipeline which wraps the queued commands in
+// MULTI/EXEC transactions.
+func (c *ClusterClient) TxPipeline() redis.Pipeliner {
+	return &Pipeliner{c.ClusterClient.TxPipeline(), c.params, c.Context(), true}
+}
+
+// TxPipelined executes the commands queued by fn in traced MULTI/EXEC transactions.
+func (c *ClusterClient) TxPipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.TxPipeline().Pipelined(fn)
+}
+
+// WithContext returns a copy of the ClusterClient bound to ctx. Spans emitted by
+// the commands and pipelines of the copy are children of the span in ctx, while
+// the ClusterClient itself is left unchanged.
+func (c *ClusterClient) WithContext(ctx context.Context) *ClusterClient {
+	clone := c.ClusterClient.WithContext(ctx)
+	clone.WrapProcess(bindContext(c.params, ctx))
+	return &ClusterClient{ClusterClient: clone, params: c.params, ctx: ctx}
+}
+
+// Context returns the context the client is bound to.
+func (c *ClusterClient) Context() context.Context {
+	return c.ctx
+}
+
+// Ring is used to trace requests to a ring of Redis shards. The spans of single
+// commands are tagged with the address of the shard serving them.
+type Ring struct {
+	*redis.Ring
+	*params
+
+	ctx context.Context
+}
+
+var _ redis.Cmdable = (*Ring)(nil)
+
+// NewRing returns a new Ring that is traced with the default tracer under the
+// service name "redis.client".
+func NewRing(opt *redis.RingOptions, opts ...ClientOption) *Ring {
+	return WrapRing(redis.NewRing(opt), opts...)
+}
+
+// WrapRing wraps a given redis.Ring with a tracer. Shards which are down when
+// the ring is wrapped are not tagged on the spans of the commands they serve.
+func WrapRing(c *redis.Ring, opts ...ClientOption) *Ring {
+	params := newParams(opts)
+	params.db = strconv.Itoa(c.Options().DB)
+	params.inflight = new(sync.Map)
+	tc := &Ring{Ring: c, params: params, ctx: context.Background()}
+	c.ForEachShard(func(shard *redis.Client) error {
+		traceNode(params, shard)
+		return nil
+	})
+	tc.Ring.WrapProcess(createWrapper(params, tc.Context))
+	return tc
+}
+
+// Pipeline creates a traced Pipeline from a Ring.
+func (c *Ring) Pipeline() redis.Pipeliner {
+	return &Pipeliner{c.Ring.Pipeline(), c.params, c.Context(), false}
+}
+
+// Pipelined executes the commands queued by fn in a traced pipeline.
+func (c *Ring) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.Pipeline().Pipelined(fn)
+}
+
+// WithContext returns a copy of the Ring bound to ctx. Spans emitted by the
+// commands and pipelines of the copy are children of the span in ctx, while the
+// Ring itself is left unchanged.
+func (c *Ring) WithContext(ctx context.Context) *Ring {
+	clone := c.Ring.WithContext(ctx)
+	clone.WrapProcess(bindContext(c.params, ctx))
+	return &Ring{Ring: clone, params: c.params, ctx: ctx}
+}
+
+// Context returns the context the ring is bound to.
+func (c *Ring) Context() context.Context {
+	return c.ctx
+}
+
+// NewUniversalClient returns a new traced redis.UniversalClient. Depending on the
+// given options, it is either a *Client or a *ClusterClient, as described in the
+// documentation of github.com/go-redis/redis.NewUniversalClient.
+func NewUniversalClient(opt *redis.UniversalOptions, opts ...ClientOption) redis.UniversalClient {
+	return WrapUniversalClient(redis.NewUniversalClient(opt), opts...)
+}
+
+// WrapUniversalClient wraps a given redis.UniversalClient with a tracer. Clients
+// other than *redis.Client and *redis.ClusterClient are returned untraced.
+func WrapUniversalClient(c redis.UniversalClient, opts ...ClientOption) redis.UniversalClient {
+	switch c := c.(type) {
+	case *redis.Client:
+		return WrapClient(c, opts...)
+	case *redis.ClusterClient:
+		return WrapClusterClient(c, opts...)
+	}
+	return c
+}
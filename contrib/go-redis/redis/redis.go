@@ -2,14 +2,13 @@
 package redis
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"strconv"
-	"strings"
 	"sync"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/redisutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -22,7 +21,6 @@ type Client struct {
 	*redis.Client
 	*params
 
-	mu  sync.RWMutex // guards ctx
 	ctx context.Context
 }
 
@@ -34,6 +32,7 @@ type Pipeliner struct {
 	*params
 
 	ctx context.Context
+	tx  bool // whether the pipeline is a MULTI/EXEC transaction
 }
 
 var _ redis.Pipeliner = (*Pipeliner)(nil)
@@ -44,6 +43,33 @@ type params struct {
 	port   string
 	db     string
 	config *clientConfig
+
+	// inflight maps the commands being processed by a multi-node client to their
+	// spans, so that the node serving a command can tag it with its address.
+	// It is nil for clients connected to a single node.
+	inflight *sync.Map
+
+	// contexts maps the commands being processed by copies of a client bound to
+	// a context with WithContext to that context.
+	contexts *sync.Map
+}
+
+func newParams(opts []ClientOption) *params {
+	cfg := new(clientConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &params{config: cfg, contexts: new(sync.Map)}
+}
+
+// splitAddr returns the host and port of the given Redis address.
+func splitAddr(addr string) (host, port string) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, "6379"
+	}
+	return host, port
 }
 
 // NewClient returns a new Client that is traced with the default tracer under
@@ -54,34 +80,34 @@ func NewClient(opt *redis.Options, opts ...ClientOption) *Client {
 
 // WrapClient wraps a given redis.Client with a tracer under the given service name.
 func WrapClient(c *redis.Client, opts ...ClientOption) *Client {
-	cfg := new(clientConfig)
-	defaults(cfg)
-	for _, fn := range opts {
-		fn(cfg)
-	}
+	params := newParams(opts)
 	opt := c.Options()
-	host, port, err := net.SplitHostPort(opt.Addr)
-	if err != nil {
-		host = opt.Addr
-		port = "6379"
-	}
-	params := &params{
-		host:   host,
-		port:   port,
-		db:     strconv.Itoa(opt.DB),
-		config: cfg,
-	}
-	tc := &Client{Client: c, params: params}
-	tc.Client.WrapProcess(createWrapperFromClient(tc))
+	params.host, params.port = splitAddr(opt.Addr)
+	params.db = strconv.Itoa(opt.DB)
+	tc := &Client{Client: c, params: params, ctx: context.Background()}
+	tc.Client.WrapProcess(createWrapper(params, tc.Context))
 	return tc
 }
 
 // Pipeline creates a Pipeline from a Client
 func (c *Client) Pipeline() redis.Pipeliner {
-	c.mu.RLock()
-	ctx := c.ctx
-	c.mu.RUnlock()
-	return &Pipeliner{c.Client.Pipeline(), c.params, ctx}
+	return &Pipeliner{c.Client.Pipeline(), c.params, c.Context(), false}
+}
+
+// Pipelined executes the commands queued by fn in a traced pipeline.
+func (c *Client) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.Pipeline().Pipelined(fn)
+}
+
+// TxPipeline creates a traced Pipeline which wraps the queued commands in a
+// MULTI/EXEC transaction.
+func (c *Client) TxPipeline() redis.Pipeliner {
+	return &Pipeliner{c.Client.TxPipeline(), c.params, c.Context(), true}
+}
+
+// TxPipelined executes the commands queued by fn in a traced MULTI/EXEC transaction.
+func (c *Client) TxPipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return c.TxPipeline().Pipelined(fn)
 }
 
 // ExecWithContext calls Pipeline.Exec(). It ensures that the resulting Redis calls
@@ -95,22 +121,45 @@ func (c *Pipeliner) Exec() ([]redis.Cmder, error) {
 	return c.execWithContext(c.ctx)
 }
 
+// Pipelined executes the commands queued by fn, ensuring that the pipeline is traced.
+func (c *Pipeliner) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	if err := fn(c); err != nil {
+		return nil, err
+	}
+	cmds, err := c.Exec()
+	_ = c.Close()
+	return cmds, err
+}
+
 func (c *Pipeliner) execWithContext(ctx context.Context) ([]redis.Cmder, error) {
 	p := c.params
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeRedis),
 		tracer.ServiceName(p.config.serviceName),
 		tracer.ResourceName("redis"),
-		tracer.Tag(ext.TargetHost, p.host),
-		tracer.Tag(ext.TargetPort, p.port),
 		tracer.Tag("out.db", p.db),
 	}
+	if p.host != "" {
+		// the commands of a pipeline run by a multi-node client may be
+		// served by several nodes, whose addresses are not known here.
+		opts = append(opts,
+			tracer.Tag(ext.TargetHost, p.host),
+			tracer.Tag(ext.TargetPort, p.port),
+		)
+	}
+	if c.tx {
+		opts = append(opts, tracer.Tag("redis.transaction", true))
+	}
 	if rate := p.config.analyticsRate; rate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
 	}
 	span, _ := tracer.StartSpanFromContext(ctx, "redis.command", opts...)
 	cmds, err := c.Pipeliner.Exec()
-	span.SetTag(ext.ResourceName, commandsToString(cmds))
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+	span.SetTag(ext.ResourceName, redisutil.PipelineResource(names))
 	span.SetTag("redis.pipeline_length", strconv.Itoa(len(cmds)))
 	var finishOpts []ddtrace.FinishOption
 	if err != redis.Nil {
@@ -121,60 +170,57 @@ func (c *Pipeliner) execWithContext(ctx context.Context) ([]redis.Cmder, error)
 	return cmds, err
 }
 
-// commandsToString returns a string representation of a slice of redis Commands, separated by newlines.
-func commandsToString(cmds []redis.Cmder) string {
-	var b bytes.Buffer
-	for _, cmd := range cmds {
-		b.WriteString(cmderToString(cmd))
-		b.WriteString("\n")
-	}
-	return b.String()
-}
-
-// WithContext sets a context on a Client. Use it to ensure that emitted spans have the correct parent.
+// WithContext returns a copy of the Client bound to ctx. Spans emitted by the
+// commands and pipelines of the copy are children of the span in ctx, while the
+// Client itself is left unchanged, so it can be shared by concurrent requests.
 func (c *Client) WithContext(ctx context.Context) *Client {
-	c.mu.Lock()
-	c.ctx = ctx
-	c.mu.Unlock()
-	return c
+	clone := c.Client.WithContext(ctx)
+	clone.WrapProcess(bindContext(c.params, ctx))
+	return &Client{Client: clone, params: c.params, ctx: ctx}
 }
 
-// Context returns the active context in the client.
+// Context returns the context the client is bound to.
 func (c *Client) Context() context.Context {
-	c.mu.RLock()
-	ctx := c.ctx
-	c.mu.RUnlock()
-	return ctx
+	return c.ctx
 }
 
-// createWrapperFromClient returns a new createWrapper function which wraps the processor with tracing
-// information obtained from the provided Client. To understand this functionality better see the
-// documentation for the github.com/go-redis/redis.(*baseClient).WrapProcess function.
-func createWrapperFromClient(tc *Client) func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+// createWrapper returns a new createWrapper function which wraps the processor with tracing
+// information obtained from the provided params. The parent of the spans is the context a
+// command was bound to with bindContext, or else the context returned by ctx. To understand this functionality better see the documentation for
+// the github.com/go-redis/redis.(*baseClient).WrapProcess function.
+func createWrapper(p *params, ctx func() context.Context) func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
 	return func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
 		return func(cmd redis.Cmder) error {
-			tc.mu.RLock()
-			ctx := tc.ctx
-			tc.mu.RUnlock()
-			raw := cmderToString(cmd)
-			parts := strings.Split(raw, " ")
-			length := len(parts) - 1
-			p := tc.params
+			name, args := cmdNameArgs(cmd)
 			opts := []ddtrace.StartSpanOption{
 				tracer.SpanType(ext.SpanTypeRedis),
 				tracer.ServiceName(p.config.serviceName),
-				tracer.ResourceName(parts[0]),
-				tracer.Tag(ext.TargetHost, p.host),
-				tracer.Tag(ext.TargetPort, p.port),
+				tracer.ResourceName(name),
 				tracer.Tag("out.db", p.db),
-				tracer.Tag("redis.raw_command", raw),
-				tracer.Tag("redis.args_length", strconv.Itoa(length)),
+				tracer.Tag("redis.raw_command", redisutil.RawCommand(name, args)),
+				tracer.Tag("redis.args_length", strconv.Itoa(len(args))),
+			}
+			if p.host != "" {
+				opts = append(opts,
+					tracer.Tag(ext.TargetHost, p.host),
+					tracer.Tag(ext.TargetPort, p.port),
+				)
 			}
 			if rate := p.config.analyticsRate; rate > 0 {
 				opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
 			}
-			span, _ := tracer.StartSpanFromContext(ctx, "redis.command", opts...)
+			parent, ok := p.contexts.Load(cmd)
+			if !ok {
+				parent = ctx()
+			}
+			span, _ := tracer.StartSpanFromContext(parent.(context.Context), "redis.command", opts...)
+			if p.inflight != nil {
+				p.inflight.Store(cmd, span)
+			}
 			err := oldProcess(cmd)
+			if p.inflight != nil {
+				p.inflight.Delete(cmd)
+			}
 			var finishOpts []ddtrace.FinishOption
 			if err != redis.Nil {
 				finishOpts = append(finishOpts, tracer.WithError(err))
@@ -185,27 +231,48 @@ func createWrapperFromClient(tc *Client) func(oldProcess func(cmd redis.Cmder) e
 	}
 }
 
-func cmderToString(cmd redis.Cmder) string {
-	// We want to support multiple versions of the go-redis library. In
-	// older versions Cmder implements the Stringer interface, while in
-	// newer versions that was removed, and this String method which
-	// sometimes returns an error is used instead. By doing a type assertion
-	// we can support both versions.
-	switch v := cmd.(type) {
-	case fmt.Stringer:
-		return v.String()
-	case interface{ String() (string, error) }:
-		str, err := v.String()
-		if err == nil {
-			return str
+// bindContext returns a process wrapper which binds the commands processed by a copy
+// of a client to ctx, for the tracing wrapper of the client to pick it up.
+func bindContext(p *params, ctx context.Context) func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+	return func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			if _, loaded := p.contexts.LoadOrStore(cmd, ctx); loaded {
+				// the command comes from a copy of this copy, bound to its own context
+				return oldProcess(cmd)
+			}
+			defer p.contexts.Delete(cmd)
+			return oldProcess(cmd)
 		}
 	}
+}
+
+// traceNode wraps the processor of c, a client connected to a single node of a
+// multi-node client, so that the spans of the commands it serves are tagged with
+// its address.
+func traceNode(p *params, c *redis.Client) {
+	host, port := splitAddr(c.Options().Addr)
+	c.WrapProcess(func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			if v, ok := p.inflight.Load(cmd); ok {
+				span := v.(ddtrace.Span)
+				span.SetTag(ext.TargetHost, host)
+				span.SetTag(ext.TargetPort, port)
+			}
+			return oldProcess(cmd)
+		}
+	})
+}
+
+// cmdNameArgs returns the name of the given command along with its arguments,
+// excluding the name itself.
+func cmdNameArgs(cmd redis.Cmder) (string, []interface{}) {
 	args := cmd.Args()
 	if len(args) == 0 {
-		return ""
+		return cmd.Name(), nil
 	}
-	if str, ok := args[0].(string); ok {
-		return str
+	name, ok := args[0].(string)
+	if !ok {
+		name = fmt.Sprint(args[0])
 	}
-	return ""
+	return name, args[1:]
 }
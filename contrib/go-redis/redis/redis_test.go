@@ -2,7 +2,6 @@ package redis
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"sync"
 	"testing"
@@ -19,16 +18,17 @@ import (
 
 const debug = false
 
+var integration bool
+
 func TestMain(m *testing.M) {
-	_, ok := os.LookupEnv("INTEGRATION")
-	if !ok {
-		fmt.Println("--- SKIP: to enable integration test, set the INTEGRATION environment variable")
-		os.Exit(0)
-	}
+	_, integration = os.LookupEnv("INTEGRATION")
 	os.Exit(m.Run())
 }
 
 func TestClientEvalSha(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -55,6 +55,9 @@ func TestClientEvalSha(t *testing.T) {
 
 // https://github.com/DataDog/dd-trace-go/issues/387
 func TestIssue387(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	client := NewClient(opts, WithServiceName("my-redis"))
 	n := 1000
@@ -75,6 +78,9 @@ func TestIssue387(t *testing.T) {
 }
 
 func TestClient(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -92,11 +98,14 @@ func TestClient(t *testing.T) {
 	assert.Equal("my-redis", span.Tag(ext.ServiceName))
 	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 	assert.Equal("6379", span.Tag(ext.TargetPort))
-	assert.Equal("set test_key test_value: ", span.Tag("redis.raw_command"))
-	assert.Equal("3", span.Tag("redis.args_length"))
+	assert.Equal("set test_key ?", span.Tag("redis.raw_command"))
+	assert.Equal("2", span.Tag("redis.args_length"))
 }
 
 func TestPipeline(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -116,7 +125,7 @@ func TestPipeline(t *testing.T) {
 	assert.Equal("redis.command", span.OperationName())
 	assert.Equal(ext.SpanTypeRedis, span.Tag(ext.SpanType))
 	assert.Equal("my-redis", span.Tag(ext.ServiceName))
-	assert.Equal("expire pipeline_counter 3600: false\n", span.Tag(ext.ResourceName))
+	assert.Equal("expire (1 commands)", span.Tag(ext.ResourceName))
 	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 	assert.Equal("6379", span.Tag(ext.TargetPort))
 	assert.Equal("1", span.Tag("redis.pipeline_length"))
@@ -135,11 +144,14 @@ func TestPipeline(t *testing.T) {
 	assert.Equal("redis.command", span.OperationName())
 	assert.Equal(ext.SpanTypeRedis, span.Tag(ext.SpanType))
 	assert.Equal("my-redis", span.Tag(ext.ServiceName))
-	assert.Equal("expire pipeline_counter 3600: false\nexpire pipeline_counter_1 60: false\n", span.Tag(ext.ResourceName))
+	assert.Equal("expire (2 commands)", span.Tag(ext.ResourceName))
 	assert.Equal("2", span.Tag("redis.pipeline_length"))
 }
 
 func TestChildSpan(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -174,6 +186,9 @@ func TestChildSpan(t *testing.T) {
 }
 
 func TestMultipleCommands(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	opts := &redis.Options{Addr: "127.0.0.1:6379"}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
@@ -193,13 +208,16 @@ func TestMultipleCommands(t *testing.T) {
 	for i := 0; i < 4; i++ {
 		commands[i] = spans[i].Tag("redis.raw_command").(string)
 	}
-	assert.Contains(commands, "set test_key test_value: ")
-	assert.Contains(commands, "get test_key: ")
-	assert.Contains(commands, "incr int_key: 0")
-	assert.Contains(commands, "client list: ")
+	assert.Contains(commands, "set test_key ?")
+	assert.Contains(commands, "get test_key")
+	assert.Contains(commands, "incr int_key")
+	assert.Contains(commands, "client list")
 }
 
 func TestError(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	t.Run("wrong-port", func(t *testing.T) {
 		opts := &redis.Options{Addr: "127.0.0.1:6378"} // wrong port
 		assert := assert.New(t)
@@ -218,7 +236,7 @@ func TestError(t *testing.T) {
 		assert.Equal(err, span.Tag(ext.Error))
 		assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 		assert.Equal("6378", span.Tag(ext.TargetPort))
-		assert.Equal("get key: ", span.Tag("redis.raw_command"))
+		assert.Equal("get key", span.Tag("redis.raw_command"))
 	})
 
 	t.Run("nil", func(t *testing.T) {
@@ -239,10 +257,13 @@ func TestError(t *testing.T) {
 		assert.Empty(span.Tag(ext.Error))
 		assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
 		assert.Equal("6379", span.Tag(ext.TargetPort))
-		assert.Equal("get non_existent_key: ", span.Tag("redis.raw_command"))
+		assert.Equal("get non_existent_key", span.Tag("redis.raw_command"))
 	})
 }
 func TestAnalyticsSettings(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...ClientOption) {
 		client := NewClient(&redis.Options{Addr: "127.0.0.1:6379"}, opts...)
 		client.Set("test_key", "test_value", 0)
@@ -297,3 +318,186 @@ func TestAnalyticsSettings(t *testing.T) {
 		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
 	})
 }
+
+func TestTxPipeline(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	opts := &redis.Options{Addr: "127.0.0.1:6379"}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := NewClient(opts, WithServiceName("my-redis"))
+	_, err := client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Incr("tx_counter")
+		pipe.Expire("tx_counter", time.Hour)
+		return nil
+	})
+	assert.NoError(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("redis.command", span.OperationName())
+	assert.Equal("incr expire (2 commands)", span.Tag(ext.ResourceName))
+	assert.Equal(true, span.Tag("redis.transaction"))
+	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
+	assert.Equal("6379", span.Tag(ext.TargetPort))
+}
+
+func TestClusterClient(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	// a single Redis server serving every slot stands in for a cluster
+	const addr = "127.0.0.1:6379"
+	opts := &redis.ClusterOptions{
+		Addrs: []string{addr},
+		ClusterSlots: func() ([]redis.ClusterSlot, error) {
+			return []redis.ClusterSlot{{
+				Start: 0,
+				End:   16383,
+				Nodes: []redis.ClusterNode{{Addr: addr}},
+			}}, nil
+		},
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := NewClusterClient(opts, WithServiceName("my-redis"))
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "parent.span")
+	client.WithContext(ctx).Set("test_key", "test_value", 0)
+	root.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+
+	span := spans[0]
+	assert.Equal("redis.command", span.OperationName())
+	assert.Equal("my-redis", span.Tag(ext.ServiceName))
+	assert.Equal("set", span.Tag(ext.ResourceName))
+	assert.Equal("set test_key ?", span.Tag("redis.raw_command"))
+	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
+	assert.Equal("6379", span.Tag(ext.TargetPort))
+	assert.Equal(spans[1].SpanID(), span.ParentID())
+
+	mt.Reset()
+	pipeline := client.Pipeline()
+	pipeline.Expire("pipeline_counter", time.Hour)
+	pipeline.Exec()
+
+	spans = mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("expire (1 commands)", spans[0].Tag(ext.ResourceName))
+	assert.Equal("1", spans[0].Tag("redis.pipeline_length"))
+}
+
+func TestRing(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	opts := &redis.RingOptions{
+		Addrs: map[string]string{"shard1": "127.0.0.1:6379"},
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ring := NewRing(opts, WithServiceName("my-redis"))
+	ring.Set("test_key", "test_value", 0)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+
+	span := spans[0]
+	assert.Equal("redis.command", span.OperationName())
+	assert.Equal("set", span.Tag(ext.ResourceName))
+	assert.Equal("127.0.0.1", span.Tag(ext.TargetHost))
+	assert.Equal("6379", span.Tag(ext.TargetPort))
+	assert.Equal("0", span.Tag("out.db"))
+}
+
+func TestRingWithContext(t *testing.T) {
+	opts := &redis.RingOptions{
+		Addrs: map[string]string{"shard1": "127.0.0.1:6379"},
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ring := NewRing(opts, WithServiceName("my-redis"))
+	root, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	ring.WithContext(ctx).Set("test_key", "test_value", 0)
+	ring.Get("test_key")
+	root.Finish()
+
+	parents := make(map[string]uint64)
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() == "redis.command" {
+			parents[s.Tag(ext.ResourceName).(string)] = s.ParentID()
+			assert.Equal("127.0.0.1", s.Tag(ext.TargetHost))
+		}
+	}
+	assert.Equal(root.(mocktracer.Span).SpanID(), parents["set"])
+	// the original ring is left unbound
+	assert.Equal(uint64(0), parents["get"])
+}
+
+func TestUniversalClient(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := NewUniversalClient(&redis.UniversalOptions{
+		Addrs: []string{"127.0.0.1:6379"},
+	}, WithServiceName("my-redis"))
+	assert.IsType(&Client{}, client)
+	client.Set("test_key", "test_value", 0)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("set", spans[0].Tag(ext.ResourceName))
+	assert.Equal("127.0.0.1", spans[0].Tag(ext.TargetHost))
+}
+
+func TestWithContextCopy(t *testing.T) {
+	opts := &redis.Options{Addr: "127.0.0.1:6379"}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	client := NewClient(opts, WithServiceName("my-redis"))
+	root1, ctx1 := tracer.StartSpanFromContext(context.Background(), "parent.1")
+	root2, ctx2 := tracer.StartSpanFromContext(context.Background(), "parent.2")
+	c1 := client.WithContext(ctx1)
+	c2 := c1.WithContext(ctx2)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		c1.Get("key1")
+	}()
+	go func() {
+		defer wg.Done()
+		c2.Get("key2")
+	}()
+	wg.Wait()
+	client.Get("key3")
+	root1.Finish()
+	root2.Finish()
+
+	parents := make(map[string]uint64)
+	for _, s := range mt.FinishedSpans() {
+		if s.OperationName() == "redis.command" {
+			parents[s.Tag("redis.raw_command").(string)] = s.ParentID()
+		}
+	}
+	assert.Equal(root1.(mocktracer.Span).SpanID(), parents["get key1"])
+	assert.Equal(root2.(mocktracer.Span).SpanID(), parents["get key2"])
+	// the original client is left unbound
+	assert.Equal(uint64(0), parents["get key3"])
+}
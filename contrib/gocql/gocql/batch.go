@@ -0,0 +1,92 @@
+package gocql
+
+import (
+	"context"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/gocql/gocql"
+)
+
+// Batch inherits from gocql.Batch, it keeps the tracer and the context.
+type Batch struct {
+	*gocql.Batch
+	*params
+	ctx context.Context
+}
+
+// WrapBatch wraps a gocql.Batch into a traced Batch under the given service name.
+// Statements can be added before or after wrapping it, using the Query and Bind
+// methods of the embedded gocql.Batch. As for WrapQuery, methods returning the
+// batch for chaining, other than WithContext and WithTimestamp, should be called
+// before WrapBatch. The resource name defaults to "BATCH".
+func WrapBatch(b *gocql.Batch, opts ...WrapOption) *Batch {
+	cfg := new(queryConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if cfg.resourceName == "" {
+		cfg.resourceName = "BATCH"
+	}
+	return &Batch{b, &params{config: cfg, keyspace: b.Keyspace()}, context.Background()}
+}
+
+// WithContext returns a copy of the traced Batch bound to the specified
+// context, leaving the batch unchanged.
+func (tb *Batch) WithContext(ctx context.Context) *Batch {
+	nb := *tb
+	nb.ctx = ctx
+	nb.Batch = tb.Batch.WithContext(ctx)
+	return &nb
+}
+
+// WithTimestamp rewrites the original function so that the traced Batch is kept.
+func (tb *Batch) WithTimestamp(timestamp int64) *Batch {
+	tb.Batch = tb.Batch.WithTimestamp(timestamp)
+	return tb
+}
+
+// ExecuteBatch executes the batch on the given session, wrapping the call
+// to session.ExecuteBatch in a span.
+func (tb *Batch) ExecuteBatch(session *gocql.Session) error {
+	span := tb.newChildSpan(tb.ctx)
+	err := session.ExecuteBatch(tb.withSpan(span))
+	span.SetTag(tagAttempts, tb.Attempts())
+	tb.finishSpan(span, err)
+	return err
+}
+
+// newChildSpan creates a new span from the params and the context.
+func (tb *Batch) newChildSpan(ctx context.Context) ddtrace.Span {
+	p := tb.params
+	opts := []ddtrace.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeCassandra),
+		tracer.ServiceName(p.config.serviceName),
+		tracer.ResourceName(p.config.resourceName),
+		tracer.Tag(ext.CassandraConsistencyLevel, tb.GetConsistency().String()),
+		tracer.Tag(ext.CassandraKeyspace, p.keyspace),
+		tracer.Tag(ext.CassandraBatchSize, tb.Size()),
+	}
+	if rate := p.config.analyticsRate; rate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, ext.CassandraBatch, opts...)
+	return span
+}
+
+// withSpan returns the batch bound to a context holding span, so that the
+// attempts traced by an Observer are its children.
+func (tb *Batch) withSpan(span ddtrace.Span) *gocql.Batch {
+	return tb.Batch.WithContext(tracer.ContextWithSpan(tb.ctx, span))
+}
+
+func (tb *Batch) finishSpan(span ddtrace.Span, err error) {
+	if tb.params.config.noDebugStack {
+		span.FinishWithOptionsExt(tracer.WithError(err), tracer.NoDebugStack())
+	} else {
+		span.FinishWithOptionsExt(tracer.WithError(err))
+	}
+}
@@ -30,3 +30,20 @@ func Example() {
 	// Execute your query as usual
 	tracedQuery.Exec()
 }
+
+// To trace every attempt at executing a query, including retries, set an Observer
+// on the cluster configuration. WrapSession additionally traces each query and batch.
+func Example_session() {
+	obs := gocqltrace.NewObserver(gocqltrace.WithServiceName("ServiceName"))
+	cluster := gocql.NewCluster("127.0.0.1")
+	cluster.QueryObserver = obs
+	cluster.BatchObserver = obs
+	s, _ := cluster.CreateSession()
+	session := gocqltrace.WrapSession(s, gocqltrace.WithServiceName("ServiceName"))
+
+	session.Query("SELECT * FROM trace.person WHERE name = ?", "Cassandra").Exec()
+
+	batch := session.NewBatch(gocql.LoggedBatch)
+	batch.Query("INSERT INTO trace.person (name, age) VALUES (?, ?)", "Kate", 80)
+	session.ExecuteBatch(batch)
+}
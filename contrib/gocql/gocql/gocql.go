@@ -66,7 +66,7 @@ func WrapQuery(q *gocql.Query, opts ...WrapOption) *Query {
 // WithContext adds the specified context to the traced Query structure.
 func (tq *Query) WithContext(ctx context.Context) *Query {
 	tq.ctx = ctx
-	tq.Query.WithContext(ctx)
+	tq.Query = tq.Query.WithContext(ctx)
 	return tq
 }
 
@@ -107,10 +107,16 @@ func (tq *Query) Exec() error {
 	return tq.Iter().Close()
 }
 
+// withSpan returns the query bound to a context holding span, so that the
+// attempts traced by an Observer are its children.
+func (tq *Query) withSpan(span ddtrace.Span) *gocql.Query {
+	return tq.Query.WithContext(tracer.ContextWithSpan(tq.ctx, span))
+}
+
 // MapScan wraps in a span query.MapScan call.
 func (tq *Query) MapScan(m map[string]interface{}) error {
 	span := tq.newChildSpan(tq.ctx)
-	err := tq.Query.MapScan(m)
+	err := tq.withSpan(span).MapScan(m)
 	tq.finishSpan(span, err)
 	return err
 }
@@ -118,7 +124,7 @@ func (tq *Query) MapScan(m map[string]interface{}) error {
 // Scan wraps in a span query.Scan call.
 func (tq *Query) Scan(dest ...interface{}) error {
 	span := tq.newChildSpan(tq.ctx)
-	err := tq.Query.Scan(dest...)
+	err := tq.withSpan(span).Scan(dest...)
 	tq.finishSpan(span, err)
 	return err
 }
@@ -126,7 +132,7 @@ func (tq *Query) Scan(dest ...interface{}) error {
 // ScanCAS wraps in a span query.ScanCAS call.
 func (tq *Query) ScanCAS(dest ...interface{}) (applied bool, err error) {
 	span := tq.newChildSpan(tq.ctx)
-	applied, err = tq.Query.ScanCAS(dest...)
+	applied, err = tq.withSpan(span).ScanCAS(dest...)
 	tq.finishSpan(span, err)
 	return applied, err
 }
@@ -134,8 +140,10 @@ func (tq *Query) ScanCAS(dest ...interface{}) (applied bool, err error) {
 // Iter starts a new span at query.Iter call.
 func (tq *Query) Iter() *Iter {
 	span := tq.newChildSpan(tq.ctx)
-	iter := tq.Query.Iter()
+	q := tq.withSpan(span)
+	iter := q.Iter()
 	span.SetTag(ext.CassandraRowCount, strconv.Itoa(iter.NumRows()))
+	span.SetTag(tagAttempts, q.Attempts())
 	span.SetTag(ext.CassandraConsistencyLevel, tq.GetConsistency().String())
 
 	columns := iter.Columns()
@@ -143,14 +151,23 @@ func (tq *Query) Iter() *Iter {
 		span.SetTag(ext.CassandraKeyspace, columns[0].Keyspace)
 	}
 	tIter := &Iter{iter, span}
-	if tIter.Host() != nil {
-		tIter.span.SetTag(ext.TargetHost, tIter.Iter.Host().HostID())
-		tIter.span.SetTag(ext.TargetPort, strconv.Itoa(tIter.Iter.Host().Port()))
-		tIter.span.SetTag(ext.CassandraCluster, tIter.Iter.Host().DataCenter())
-	}
+	tagHost(span, iter.Host())
 	return tIter
 }
 
+// tagHost sets the tags of the given coordinator host on span.
+func tagHost(span ddtrace.Span, host *gocql.HostInfo) {
+	if host == nil {
+		return
+	}
+	span.SetTag(ext.TargetHost, host.HostID())
+	span.SetTag(ext.TargetPort, strconv.Itoa(host.Port()))
+	span.SetTag(ext.CassandraCluster, host.DataCenter())
+	if addr := host.ConnectAddress(); addr != nil {
+		span.SetTag(ext.PeerHostname, addr.String())
+	}
+}
+
 // Close closes the Iter and finish the span created on Iter call.
 func (tIter *Iter) Close() error {
 	err := tIter.Iter.Close()
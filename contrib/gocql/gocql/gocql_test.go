@@ -2,10 +2,11 @@ package gocql
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
@@ -32,12 +33,12 @@ func newCassandraCluster() *gocql.ClusterConfig {
 	return cluster
 }
 
+var integration bool
+
 // TestMain sets up the Keyspace and table if they do not exist
 func TestMain(m *testing.M) {
-	_, ok := os.LookupEnv("INTEGRATION")
-	if !ok {
-		fmt.Println("--- SKIP: to enable integration test, set the INTEGRATION environment variable")
-		os.Exit(0)
+	if _, integration = os.LookupEnv("INTEGRATION"); !integration {
+		os.Exit(m.Run())
 	}
 	cluster := newCassandraCluster()
 	session, err := cluster.CreateSession()
@@ -53,6 +54,9 @@ func TestMain(m *testing.M) {
 }
 
 func TestErrorWrapper(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -83,6 +87,9 @@ func TestErrorWrapper(t *testing.T) {
 }
 
 func TestChildWrapperSpan(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -122,6 +129,9 @@ func TestChildWrapperSpan(t *testing.T) {
 }
 
 func TestAnalyticsSettings(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...WrapOption) {
 		cluster := newCassandraCluster()
 		session, err := cluster.CreateSession()
@@ -180,3 +190,173 @@ func TestAnalyticsSettings(t *testing.T) {
 		assertRate(t, mt, 0.23, WithAnalyticsRate(0.23))
 	})
 }
+
+func TestWrapBatch(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cluster := newCassandraCluster()
+	cluster.Keyspace = "trace"
+	session, err := cluster.CreateSession()
+	assert.Nil(err)
+
+	parentSpan, ctx := tracer.StartSpanFromContext(context.Background(), "parentSpan")
+	b := session.NewBatch(gocql.UnloggedBatch)
+	b.Query("INSERT INTO trace.person (name, age, description) VALUES (?, ?, ?)", "Kate", 80, "Cassandra's sister")
+	b.Query("INSERT INTO trace.person (name, age, description) VALUES (?, ?, ?)", "Lucas", 60, "Another person")
+	tb := WrapBatch(b, WithServiceName("TestServiceName")).WithContext(ctx)
+	err = tb.ExecuteBatch(session)
+	assert.Nil(err)
+	parentSpan.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	span := spans[0]
+	assert.Equal(ext.CassandraBatch, span.OperationName())
+	assert.Equal(parentSpan.(mocktracer.Span).SpanID(), span.ParentID())
+	assert.Equal("BATCH", span.Tag(ext.ResourceName))
+	assert.Equal("TestServiceName", span.Tag(ext.ServiceName))
+	assert.Equal("trace", span.Tag(ext.CassandraKeyspace))
+	assert.Equal(2, span.Tag(ext.CassandraBatchSize))
+	assert.Equal(1, span.Tag(tagAttempts))
+	assert.Equal("QUORUM", span.Tag(ext.CassandraConsistencyLevel))
+}
+
+func TestObserver(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	obs := NewObserver(WithServiceName("TestServiceName"))
+	cluster := newCassandraCluster()
+	cluster.QueryObserver = obs
+	cluster.BatchObserver = obs
+	session, err := cluster.CreateSession()
+	assert.Nil(err)
+
+	// queries are traced without being wrapped
+	err = session.Query("SELECT * from trace.person").Exec()
+	assert.Nil(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal(ext.CassandraQuery, span.OperationName())
+	assert.Equal("SELECT * from trace.person", span.Tag(ext.ResourceName))
+	assert.Equal("TestServiceName", span.Tag(ext.ServiceName))
+	assert.Equal(0, span.Tag(tagAttempt))
+	assert.Equal("9042", span.Tag(ext.TargetPort))
+	assert.Equal("127.0.0.1", span.Tag(ext.PeerHostname))
+
+	// attempts of wrapped queries are children of their span
+	mt.Reset()
+	err = WrapSession(session).Query("SELECT * from trace.person").Exec()
+	assert.Nil(err)
+
+	spans = mt.FinishedSpans()
+	assert.Len(spans, 2)
+	attempt, query := spans[0], spans[1]
+	assert.Equal(query.SpanID(), attempt.ParentID())
+	assert.Equal(1, query.Tag(tagAttempts))
+	assert.Equal(0, attempt.Tag(tagAttempt))
+}
+
+func TestObserverSpans(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	obs := NewObserver(WithServiceName("TestServiceName"))
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+	start := time.Now()
+	obs.ObserveQuery(ctx, gocql.ObservedQuery{
+		Keyspace:  "trace",
+		Statement: "SELECT * from trace.person",
+		Start:     start,
+		End:       start.Add(time.Millisecond),
+		Rows:      2,
+		Err:       errors.New("timeout"),
+		Attempt:   1,
+	})
+	obs.ObserveBatch(context.Background(), gocql.ObservedBatch{
+		Keyspace:   "trace",
+		Statements: []string{"INSERT", "INSERT"},
+		Start:      start,
+		End:        start.Add(time.Millisecond),
+		Attempt:    2,
+	})
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	query, batch := spans[0], spans[1]
+	assert.Equal(ext.CassandraQuery, query.OperationName())
+	assert.Equal(parent.(mocktracer.Span).SpanID(), query.ParentID())
+	assert.Equal("SELECT * from trace.person", query.Tag(ext.ResourceName))
+	assert.Equal("TestServiceName", query.Tag(ext.ServiceName))
+	assert.Equal("trace", query.Tag(ext.CassandraKeyspace))
+	assert.Equal("2", query.Tag(ext.CassandraRowCount))
+	assert.Equal(1, query.Tag(tagAttempt))
+	assert.NotNil(query.Tag(ext.Error))
+	assert.Equal(start, query.StartTime())
+	assert.Equal(start.Add(time.Millisecond), query.FinishTime())
+
+	assert.Equal(ext.CassandraBatch, batch.OperationName())
+	assert.Equal(uint64(0), batch.ParentID())
+	assert.Equal("BATCH", batch.Tag(ext.ResourceName))
+	assert.Equal(2, batch.Tag(ext.CassandraBatchSize))
+	assert.Equal(2, batch.Tag(tagAttempt))
+	assert.Nil(batch.Tag(ext.Error))
+}
+
+func TestBatchWithContext(t *testing.T) {
+	assert := assert.New(t)
+
+	b := WrapBatch(gocql.NewBatch(gocql.LoggedBatch))
+	ctx := context.WithValue(context.Background(), "key", "value")
+	bound := b.WithContext(ctx)
+	assert.Equal(ctx, bound.ctx)
+	assert.Equal(ctx, bound.Batch.Context())
+	// the batch given to WithContext is left unchanged
+	assert.Equal(context.Background(), b.ctx)
+	assert.NotEqual(ctx, b.Batch.Context())
+	assert.True(b.params == bound.params)
+}
+
+func TestWrapSession(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cluster := newCassandraCluster()
+	s, err := cluster.CreateSession()
+	assert.Nil(err)
+	session := WrapSession(s, WithServiceName("TestServiceName"))
+
+	err = session.Query("SELECT * from trace.person WHERE name = ?", "Cassandra").Exec()
+	assert.Nil(err)
+
+	b := session.NewBatch(gocql.LoggedBatch)
+	b.Query("INSERT INTO trace.person (name, age) VALUES (?, ?)", "Kate", 80)
+	err = session.ExecuteBatch(b)
+	assert.Nil(err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	assert.Equal(ext.CassandraQuery, spans[0].OperationName())
+	assert.Equal("SELECT * from trace.person WHERE name = ?", spans[0].Tag(ext.ResourceName))
+	assert.Equal("TestServiceName", spans[0].Tag(ext.ServiceName))
+	assert.Equal(ext.CassandraBatch, spans[1].OperationName())
+	assert.Equal("TestServiceName", spans[1].Tag(ext.ServiceName))
+	assert.Equal(1, spans[1].Tag(ext.CassandraBatchSize))
+}
@@ -0,0 +1,104 @@
+package gocql
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// tagAttempt is the tag holding the index of an attempt, retries and
+	// speculative executions having a non-zero index.
+	tagAttempt = "cassandra.attempt"
+	// tagAttempts is the tag holding the number of attempts made to execute a
+	// query or batch.
+	tagAttempts = "cassandra.attempts"
+)
+
+// Observer is a gocql.QueryObserver and gocql.BatchObserver tracing every
+// attempt at executing a query or batch, recording its latency, coordinator
+// host and keyspace. Set it as the QueryObserver and BatchObserver of a
+// gocql.ClusterConfig to trace all the queries of the sessions it creates,
+// without wrapping them. The spans of the attempts of a traced Query or Batch
+// are its children.
+type Observer struct {
+	cfg *queryConfig
+}
+
+var (
+	_ gocql.QueryObserver = (*Observer)(nil)
+	_ gocql.BatchObserver = (*Observer)(nil)
+)
+
+// NewObserver returns a new Observer. The resource name of its spans defaults
+// to the statement of queries, and to "BATCH" for batches.
+func NewObserver(opts ...WrapOption) *Observer {
+	cfg := new(queryConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	return &Observer{cfg: cfg}
+}
+
+// ObserveQuery implements gocql.QueryObserver.
+func (o *Observer) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	resource := o.cfg.resourceName
+	if resource == "" {
+		resource = q.Statement
+	}
+	span := o.startSpan(ctx, ext.CassandraQuery, resource, q.Keyspace, q.Start, q.Host)
+	span.SetTag(tagAttempt, q.Attempt)
+	span.SetTag(ext.CassandraRowCount, strconv.Itoa(q.Rows))
+	o.finishSpan(span, q.End, q.Err)
+}
+
+// ObserveBatch implements gocql.BatchObserver.
+func (o *Observer) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	resource := o.cfg.resourceName
+	if resource == "" {
+		resource = "BATCH"
+	}
+	span := o.startSpan(ctx, ext.CassandraBatch, resource, b.Keyspace, b.Start, b.Host)
+	span.SetTag(tagAttempt, b.Attempt)
+	span.SetTag(ext.CassandraBatchSize, len(b.Statements))
+	o.finishSpan(span, b.End, b.Err)
+}
+
+func (o *Observer) startSpan(ctx context.Context, name, resource, keyspace string, start time.Time, host *gocql.HostInfo) ddtrace.Span {
+	if resource == "" {
+		// avoid having an empty resource as it will cause the trace
+		// to be dropped.
+		resource = "_"
+	}
+	opts := []ddtrace.StartSpanOption{
+		tracer.StartTime(start),
+		tracer.SpanType(ext.SpanTypeCassandra),
+		tracer.ServiceName(o.cfg.serviceName),
+		tracer.ResourceName(resource),
+		tracer.Tag(ext.CassandraKeyspace, keyspace),
+	}
+	if rate := o.cfg.analyticsRate; rate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, rate))
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span, _ := tracer.StartSpanFromContext(ctx, name, opts...)
+	tagHost(span, host)
+	return span
+}
+
+func (o *Observer) finishSpan(span ddtrace.Span, end time.Time, err error) {
+	opts := []ddtrace.FinishOption{tracer.FinishTime(end), tracer.WithError(err)}
+	if o.cfg.noDebugStack {
+		opts = append(opts, tracer.NoDebugStack())
+	}
+	span.FinishWithOptionsExt(opts...)
+}
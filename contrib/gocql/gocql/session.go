@@ -0,0 +1,40 @@
+package gocql
+
+import (
+	"github.com/gocql/gocql"
+)
+
+// Session inherits from gocql.Session, it creates traced queries and batches.
+type Session struct {
+	*gocql.Session
+	opts []WrapOption
+}
+
+// WrapSession wraps a gocql.Session so that the queries and batches it creates
+// are traced with the given options. Options apply to all of them, so that
+// WithResourceName should not be used unless a single resource is desired.
+// To also trace each attempt at executing them, including retries, set an
+// Observer on the gocql.ClusterConfig creating the session.
+func WrapSession(s *gocql.Session, opts ...WrapOption) *Session {
+	return &Session{s, opts}
+}
+
+// Query returns a traced Query for the given statement.
+func (s *Session) Query(stmt string, values ...interface{}) *Query {
+	return WrapQuery(s.Session.Query(stmt, values...), s.opts...)
+}
+
+// Bind returns a traced Query for the given statement, lazily binding its values.
+func (s *Session) Bind(stmt string, b func(q *gocql.QueryInfo) ([]interface{}, error)) *Query {
+	return WrapQuery(s.Session.Bind(stmt, b), s.opts...)
+}
+
+// NewBatch returns a new traced Batch of the given type.
+func (s *Session) NewBatch(typ gocql.BatchType) *Batch {
+	return WrapBatch(s.Session.NewBatch(typ), s.opts...)
+}
+
+// ExecuteBatch executes and traces the given batch.
+func (s *Session) ExecuteBatch(b *Batch) error {
+	return b.ExecuteBatch(s.Session)
+}
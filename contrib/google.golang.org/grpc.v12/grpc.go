@@ -13,6 +13,7 @@ import (
 
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 )
@@ -27,11 +28,29 @@ func UnaryServerInterceptor(opts ...InterceptorOption) grpc.UnaryServerIntercept
 	if cfg.serviceName == "" {
 		cfg.serviceName = "grpc.server"
 	}
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		span, ctx := startSpanFromContext(ctx, info.FullMethod, cfg.serviceName, cfg.analyticsRate)
-		resp, err := handler(ctx, req)
-		span.FinishWithOptionsExt(tracer.WithError(err))
-		return resp, err
+		if p, ok := peer.FromContext(ctx); ok {
+			grpcutil.SetPeerTags(span, p.Addr)
+		}
+		if len(cfg.metadataTags) > 0 {
+			md, _ := metadata.FromContext(ctx)
+			grpcutil.SetMetadataTags(span, md, cfg.metadataTags)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				// record the panic with the stack of the panicking handler
+				err = grpc.Errorf(codes.Internal, "panic: %v", p)
+				span.SetTag(tagCode, codes.Internal.String())
+				span.FinishWithOptionsExt(tracer.WithError(err))
+				if !cfg.recoverPanics {
+					panic(p)
+				}
+				return
+			}
+			span.FinishWithOptionsExt(tracer.WithError(err))
+		}()
+		return handler(ctx, req)
 	}
 }
 
@@ -49,7 +68,9 @@ func startSpanFromContext(ctx context.Context, method, service string, rate floa
 	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
 		opts = append(opts, tracer.ChildOf(sctx))
 	}
-	return tracer.StartSpanFromContext(ctx, "grpc.server", opts...)
+	span, ctx := tracer.StartSpanFromContext(ctx, "grpc.server", opts...)
+	grpcutil.SetMethodTags(span, method)
+	return span, ctx
 }
 
 // UnaryClientInterceptor will add tracing to a gprc client.
@@ -75,10 +96,12 @@ func UnaryClientInterceptor(opts ...InterceptorOption) grpc.UnaryClientIntercept
 			spanopts = append(spanopts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 		}
 		span, ctx = tracer.StartSpanFromContext(ctx, "grpc.client", spanopts...)
+		grpcutil.SetMethodTags(span, method)
 		md, ok := metadata.FromContext(ctx)
 		if !ok {
 			md = metadata.MD{}
 		}
+		grpcutil.SetMetadataTags(span, md, cfg.metadataTags)
 		_ = tracer.Inject(span.Context(), grpcutil.MDCarrier(md))
 		ctx = metadata.NewContext(ctx, md)
 		opts = append(opts, grpc.Peer(&p))
@@ -1,8 +1,12 @@
 package grpc
 
+import "strings"
+
 type interceptorConfig struct {
 	serviceName   string
 	analyticsRate float64
+	recoverPanics bool
+	metadataTags  []string
 }
 
 // InterceptorOption represents an option that can be passed to the grpc unary
@@ -21,6 +25,27 @@ func WithServiceName(name string) InterceptorOption {
 	}
 }
 
+// WithPanicRecovery sets whether panics raised by server handlers are recovered
+// and answered with codes.Internal. By default, the panic is recorded on the span
+// and propagated. This option only applies to the server interceptor.
+func WithPanicRecovery(on bool) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		cfg.recoverPanics = on
+	}
+}
+
+// WithMetadataTags sets the metadata keys whose values are captured as
+// "grpc.metadata.<key>" span tags. The server interceptor tags the incoming
+// metadata and the client interceptor the outgoing metadata. Keys are case
+// insensitive.
+func WithMetadataTags(keys ...string) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		for _, k := range keys {
+			cfg.metadataTags = append(cfg.metadataTags, strings.ToLower(k))
+		}
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) InterceptorOption {
 	if on {
@@ -21,12 +21,12 @@ type clientStream struct {
 }
 
 func (cs *clientStream) RecvMsg(m interface{}) (err error) {
-	if cs.cfg.traceStreamMessages {
+	if cs.cfg.traceStreamMessagesFor(cs.method) {
 		span, _ := startSpanFromContext(
 			cs.Context(),
 			cs.method,
 			"grpc.message",
-			cs.cfg.clientServiceName(),
+			cs.cfg.clientServiceNameFor(cs.method),
 			cs.cfg.analyticsRate,
 		)
 
@@ -40,12 +40,12 @@ func (cs *clientStream) RecvMsg(m interface{}) (err error) {
 }
 
 func (cs *clientStream) SendMsg(m interface{}) (err error) {
-	if cs.cfg.traceStreamMessages {
+	if cs.cfg.traceStreamMessagesFor(cs.method) {
 		span, _ := startSpanFromContext(
 			cs.Context(),
 			cs.method,
 			"grpc.message",
-			cs.cfg.clientServiceName(),
+			cs.cfg.clientServiceNameFor(cs.method),
 			cs.cfg.analyticsRate,
 		)
 
@@ -67,6 +67,9 @@ func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
 		fn(cfg)
 	}
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !cfg.traced(method) {
+			return streamer(injectSpanIntoContext(ctx), desc, cc, method, opts...)
+		}
 		var stream grpc.ClientStream
 		if cfg.traceStreamCalls {
 			span, err := doClientRequest(ctx, cfg, method, opts,
@@ -121,6 +124,9 @@ func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
 		fn(cfg)
 	}
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !cfg.traced(method) {
+			return invoker(injectSpanIntoContext(ctx), method, req, reply, cc, opts...)
+		}
 		span, err := doClientRequest(ctx, cfg, method, opts,
 			func(ctx context.Context, opts []grpc.CallOption) error {
 				return invoker(ctx, method, req, reply, cc, opts...)
@@ -141,11 +147,12 @@ func doClientRequest(
 		ctx,
 		method,
 		"grpc.client",
-		cfg.clientServiceName(),
+		cfg.clientServiceNameFor(method),
 		cfg.analyticsRate,
 	)
 
 	span.SetTag(ext.SpanKind, ext.SpanKindClient)
+	setClientMetadataTags(ctx, span, cfg)
 
 	ctx = injectSpanIntoContext(ctx)
 
@@ -160,6 +167,14 @@ func doClientRequest(
 	return span, err
 }
 
+// setClientMetadataTags tags a client span with the configured outgoing metadata.
+func setClientMetadataTags(ctx context.Context, span ddtrace.Span, cfg *config) {
+	if len(cfg.metadataTags) > 0 {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		grpcutil.SetMetadataTags(span, md, cfg.metadataTags)
+	}
+}
+
 // setSpanTargetFromPeer sets the target tags in a span based on the gRPC peer.
 func setSpanTargetFromPeer(span ddtrace.Span, p peer.Peer) {
 	// if the peer was set, set the tags
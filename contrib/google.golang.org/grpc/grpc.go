@@ -5,6 +5,7 @@ package grpc // import "github.com/adityayuga/signalfx-go-tracing/contrib/google
 
 import (
 	"io"
+	"sync/atomic"
 
 	"github.com/adityayuga/signalfx-go-tracing/contrib/google.golang.org/internal/grpcutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
@@ -14,6 +15,7 @@ import (
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 )
 
@@ -33,7 +35,49 @@ func startSpanFromContext(
 	if sctx, err := tracer.Extract(grpcutil.MDCarrier(md)); err == nil {
 		opts = append(opts, tracer.ChildOf(sctx))
 	}
-	return tracer.StartSpanFromContext(ctx, operation, opts...)
+	span, ctx := tracer.StartSpanFromContext(ctx, operation, opts...)
+	grpcutil.SetMethodTags(span, method)
+	return span, ctx
+}
+
+// propagateContext returns a copy of ctx holding the span context found in its
+// incoming metadata, so that the spans started by the handler of an untraced
+// RPC continue the trace of the caller. It returns ctx unchanged if it already
+// holds a span or if the metadata carries no span context.
+func propagateContext(ctx context.Context) context.Context {
+	if _, ok := tracer.SpanFromContext(ctx); ok {
+		return ctx
+	}
+	md, _ := metadata.FromIncomingContext(ctx) // nil is ok
+	sctx, err := tracer.Extract(grpcutil.MDCarrier(md))
+	if err != nil {
+		return ctx
+	}
+	return tracer.ContextWithSpan(ctx, remoteSpan{ctx: sctx})
+}
+
+// remoteSpan is a span which is never recorded. It only carries the context
+// of a span from another process, so that it can become the parent of local spans.
+type remoteSpan struct {
+	ddtrace.NoopSpan
+	ctx ddtrace.SpanContext
+}
+
+// Context implements ddtrace.Span.
+func (s remoteSpan) Context() ddtrace.SpanContext { return s.ctx }
+
+// finishWithPanic finishes the span of a server handler which panicked with p,
+// recording the panic as a codes.Internal error. Unless panics are to be
+// recovered, it propagates the panic, otherwise it returns the error which
+// should be sent to the client. It must be called from the deferred function
+// which recovered p.
+func finishWithPanic(span ddtrace.Span, p interface{}, cfg *config) error {
+	err := status.Errorf(codes.Internal, "panic: %v", p)
+	finishWithError(span, err, cfg)
+	if !cfg.recoverPanics {
+		panic(p)
+	}
+	return err
 }
 
 // finishWithError applies finish option and a tag with gRPC status code, disregarding OK, EOF and Canceled errors.
@@ -54,3 +98,60 @@ func finishWithError(span ddtrace.Span, err error, cfg *config) {
 	}
 	span.FinishWithOptionsExt(finishOptions...)
 }
+
+// payloadSizes accumulates the sizes of the messages received and sent within
+// an RPC traced by a stats handler.
+type payloadSizes struct {
+	in, out int64
+}
+
+type payloadSizesKey struct{}
+
+// untracedKey is the key of the bool context value reporting whether the RPC
+// of a context is left untraced by the stats handler.
+type untracedKey struct{}
+
+// withPayloadSizes returns a copy of ctx holding new payloadSizes, marking its
+// RPC as traced by the stats handler.
+func withPayloadSizes(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, untracedKey{}, false)
+	return context.WithValue(ctx, payloadSizesKey{}, new(payloadSizes))
+}
+
+// withUntraced returns a copy of ctx marking its RPC as untraced by the stats
+// handler, hiding the payloadSizes of any enclosing traced RPC.
+func withUntraced(ctx context.Context) context.Context {
+	return context.WithValue(ctx, untracedKey{}, true)
+}
+
+// payloadSizesFromContext returns the payloadSizes held by ctx, if any. A nil
+// result means that the RPC of ctx is not traced by the stats handler.
+func payloadSizesFromContext(ctx context.Context) *payloadSizes {
+	if untraced, _ := ctx.Value(untracedKey{}).(bool); untraced {
+		return nil
+	}
+	ps, _ := ctx.Value(payloadSizesKey{}).(*payloadSizes)
+	return ps
+}
+
+// add records the size of the payload carried by rs, if any. Messages may be
+// received and sent concurrently within streams.
+func (ps *payloadSizes) add(rs stats.RPCStats) {
+	switch rs := rs.(type) {
+	case *stats.InPayload:
+		atomic.AddInt64(&ps.in, int64(rs.Length))
+	case *stats.OutPayload:
+		atomic.AddInt64(&ps.out, int64(rs.Length))
+	}
+}
+
+// setTags sets the request and response size tags of span. The request size
+// is the size of the messages received by servers and sent by clients.
+func (ps *payloadSizes) setTags(span ddtrace.Span, client bool) {
+	req, resp := atomic.LoadInt64(&ps.in), atomic.LoadInt64(&ps.out)
+	if client {
+		req, resp = resp, req
+	}
+	span.SetTag(tagRequestSize, req)
+	span.SetTag(tagResponseSize, resp)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/google.golang.org/internal/grpcutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -243,6 +244,33 @@ func TestStreaming(t *testing.T) {
 			len(spans))
 		checkSpans(t, rig, spans)
 	})
+
+	t.Run("MethodCallsOnly", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rig, err := newRig(true, WithMethodStreamMessages("/grpc.Fixture/StreamPing", false))
+		if err != nil {
+			t.Fatalf("error setting up rig: %s", err)
+		}
+		defer rig.Close()
+
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "a",
+			tracer.ServiceName("b"),
+			tracer.ResourceName("c"))
+
+		runPings(t, ctx, rig.client)
+
+		span.Finish()
+
+		waitForSpans(mt, 3, 5*time.Second)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 3,
+			"expected 1 server call + 1 client call + 1 parent ctx, but got %v",
+			len(spans))
+		checkSpans(t, rig, spans)
+	})
 }
 
 func TestChild(t *testing.T) {
@@ -383,11 +411,174 @@ func TestStreamSendsErrorCode(t *testing.T) {
 	assert.Equal(t, gotLastSpanCode, wantCode, "last span should contain error code")
 }
 
+func TestPanicRecovery(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(false, WithPanicRecovery(true))
+	if err != nil {
+		t.Fatalf("error setting up rig: %s", err)
+	}
+	defer rig.Close()
+
+	_, err = rig.client.Ping(context.Background(), &FixtureRequest{Name: "panic"})
+	assert.Equal(codes.Internal, status.Code(err))
+
+	waitForSpans(mt, 1, 2*time.Second)
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("grpc.server", span.OperationName())
+	assert.Equal(codes.Internal.String(), span.Tag(tagCode))
+	assert.Contains(span.Tag(ext.Error).(error).Error(), "panic: oh no")
+}
+
 // fixtureServer a dummy implemenation of our grpc fixtureServer.
 type fixtureServer struct {
 	lastRequestMetadata atomic.Value
 }
 
+func TestMethodFilters(t *testing.T) {
+	ping := func(t *testing.T, opts ...Option) []mocktracer.Span {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rig, err := newRig(true, opts...)
+		if err != nil {
+			t.Fatalf("error setting up rig: %s", err)
+		}
+		defer rig.Close()
+
+		_, err = rig.client.Ping(context.Background(), &FixtureRequest{Name: "pass"})
+		assert.NoError(t, err)
+		waitForSpans(mt, 2, 100*time.Millisecond)
+		return mt.FinishedSpans()
+	}
+
+	t.Run("ignored", func(t *testing.T) {
+		assert.Len(t, ping(t, WithIgnoredMethods("/grpc.Fixture/Ping")), 0)
+	})
+
+	t.Run("ignored-glob", func(t *testing.T) {
+		assert.Len(t, ping(t, WithIgnoredMethods("/grpc.Fixture/*")), 0)
+		// '*' matches across '/', as in the ignored paths of HTTP integrations
+		assert.Len(t, ping(t, WithIgnoredMethods("/grpc.*")), 0)
+	})
+
+	t.Run("not-included", func(t *testing.T) {
+		assert.Len(t, ping(t, WithIncludedMethods("/grpc.health.v1.Health/*")), 0)
+	})
+
+	t.Run("included", func(t *testing.T) {
+		assert.Len(t, ping(t, WithIncludedMethods("/grpc.Fixture/*")), 2)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		assert.Len(t, ping(t, WithMethodFilter(func(m string) bool { return m != "/grpc.Fixture/Ping" })), 0)
+		assert.Len(t, ping(t, WithMethodFilter(func(m string) bool { return true })), 2)
+	})
+
+	t.Run("service-name", func(t *testing.T) {
+		spans := ping(t, WithMethodServiceName("/grpc.Fixture/Ping", "pinger"))
+		assert.Len(t, spans, 2)
+		for _, span := range spans {
+			assert.Equal(t, "pinger", span.Tag(ext.ServiceName))
+		}
+	})
+}
+
+func TestIgnoredMethodsPropagation(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(false, WithIgnoredMethods("/grpc.Fixture/*"))
+	if err != nil {
+		t.Fatalf("error setting up rig: %s", err)
+	}
+	defer rig.Close()
+
+	parent := tracer.StartSpan("parent")
+	md := metadata.New(nil)
+	err = tracer.Inject(parent.Context(), grpcutil.MDCarrier(md))
+	assert.NoError(err)
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	_, err = rig.client.Ping(ctx, &FixtureRequest{Name: "child"})
+	assert.NoError(err)
+
+	stream, err := rig.client.StreamPing(ctx)
+	assert.NoError(err)
+	assert.NoError(stream.Send(&FixtureRequest{Name: "child"}))
+	_, err = stream.Recv()
+	assert.NoError(err)
+	assert.NoError(stream.CloseSend())
+	parent.Finish()
+
+	// the spans started by the handlers continue the trace of the caller,
+	// while the ignored RPCs are not traced
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 3)
+	for _, span := range spans[:2] {
+		assert.Equal("child", span.OperationName())
+		assert.Equal(parent.(mocktracer.Span).TraceID(), span.TraceID())
+		assert.Equal(parent.(mocktracer.Span).SpanID(), span.ParentID())
+	}
+}
+
+func TestPayloadSizesContext(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	assert.Nil(payloadSizesFromContext(ctx))
+
+	traced := withPayloadSizes(ctx)
+	assert.NotNil(payloadSizesFromContext(traced))
+
+	// an untraced RPC hides the sizes of the enclosing one
+	untraced := withUntraced(traced)
+	assert.Nil(payloadSizesFromContext(untraced))
+
+	// a traced RPC within an untraced one has its own sizes
+	nested := withPayloadSizes(untraced)
+	assert.NotNil(payloadSizesFromContext(nested))
+	assert.False(payloadSizesFromContext(nested) == payloadSizesFromContext(traced))
+}
+
+func TestTags(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	rig, err := newRig(true, WithMetadataTags("X-Tenant", "x-missing"))
+	if err != nil {
+		t.Fatalf("error setting up rig: %s", err)
+	}
+	defer rig.Close()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "acme", "x-secret", "s3cr3t")
+	_, err = rig.client.Ping(ctx, &FixtureRequest{Name: "pass"})
+	assert.NoError(err)
+
+	waitForSpans(mt, 2, 2*time.Second)
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 2)
+	for _, span := range spans {
+		assert.Equal("grpc.Fixture", span.Tag("rpc.service"))
+		assert.Equal("Ping", span.Tag("rpc.method"))
+		assert.Equal("acme", span.Tag("grpc.metadata.x-tenant"))
+		assert.Nil(span.Tag("grpc.metadata.x-secret"))
+		assert.Nil(span.Tag("grpc.metadata.x-missing"))
+	}
+	server := spans[0]
+	if server.OperationName() != "grpc.server" {
+		server = spans[1]
+	}
+	assert.Equal("127.0.0.1", server.Tag(ext.PeerHostIPV4))
+	assert.NotEmpty(server.Tag(ext.PeerPort))
+}
+
 func (s *fixtureServer) StreamPing(srv Fixture_StreamPingServer) error {
 	for {
 		msg, err := srv.Recv()
@@ -423,6 +614,8 @@ func (s *fixtureServer) Ping(ctx context.Context, in *FixtureRequest) (*FixtureR
 		return &FixtureReply{Message: "disabled"}, nil
 	case in.Name == "invalid":
 		return nil, status.Error(codes.InvalidArgument, "invalid")
+	case in.Name == "panic":
+		panic("oh no")
 	}
 	return &FixtureReply{Message: "passed"}, nil
 }
@@ -1,6 +1,10 @@
 package grpc
 
 import (
+	"strings"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/globutil"
+
 	"google.golang.org/grpc/codes"
 )
 
@@ -15,6 +19,15 @@ type config struct {
 	traceStreamCalls    bool
 	traceStreamMessages bool
 	noDebugStack        bool
+	recoverPanics       bool
+	metadataTags        []string
+	traceConnections    bool
+
+	includedMethods      []string
+	ignoredMethods       []string
+	methodFilters        []func(fullMethod string) bool
+	methodServiceNames   map[string]string
+	methodStreamMessages map[string]bool
 }
 
 func (cfg *config) serverServiceName() string {
@@ -31,6 +44,60 @@ func (cfg *config) clientServiceName() string {
 	return cfg.serviceName
 }
 
+// serverServiceNameFor returns the service name of the server spans of the given
+// method, taking per-method overrides into account.
+func (cfg *config) serverServiceNameFor(fullMethod string) string {
+	if name, ok := cfg.methodServiceNames[fullMethod]; ok {
+		return name
+	}
+	return cfg.serverServiceName()
+}
+
+// clientServiceNameFor returns the service name of the client spans of the given
+// method, taking per-method overrides into account.
+func (cfg *config) clientServiceNameFor(fullMethod string) string {
+	if name, ok := cfg.methodServiceNames[fullMethod]; ok {
+		return name
+	}
+	return cfg.clientServiceName()
+}
+
+// traceStreamMessagesFor reports whether the messages of streams of the given
+// method are traced, taking per-method overrides into account.
+func (cfg *config) traceStreamMessagesFor(fullMethod string) bool {
+	if enabled, ok := cfg.methodStreamMessages[fullMethod]; ok {
+		return enabled
+	}
+	return cfg.traceStreamMessages
+}
+
+// traced reports whether calls to the given method should be traced, according
+// to the method include and ignore lists and filters.
+func (cfg *config) traced(fullMethod string) bool {
+	if len(cfg.includedMethods) > 0 && !matchMethod(cfg.includedMethods, fullMethod) {
+		return false
+	}
+	if matchMethod(cfg.ignoredMethods, fullMethod) {
+		return false
+	}
+	for _, fn := range cfg.methodFilters {
+		if !fn(fullMethod) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMethod reports whether fullMethod matches any of the given patterns.
+func matchMethod(patterns []string, fullMethod string) bool {
+	for _, p := range patterns {
+		if globutil.Match(p, fullMethod) {
+			return true
+		}
+	}
+	return false
+}
+
 // InterceptorOption represents an option that can be passed to the grpc unary
 // client and server interceptors.
 // InterceptorOption is deprecated in favor of Option.
@@ -75,6 +142,90 @@ func NoDebugStack() Option {
 	}
 }
 
+// WithPanicRecovery sets whether panics raised by server handlers are recovered
+// and answered with codes.Internal. By default, the panic is recorded on the span
+// and propagated. This option only applies to the server interceptors.
+func WithPanicRecovery(on bool) Option {
+	return func(cfg *config) {
+		cfg.recoverPanics = on
+	}
+}
+
+// WithIncludedMethods restricts tracing to the calls of the methods matching any
+// of the given patterns. Patterns are matched against full method names such as
+// "/grpc.health.v1.Health/Check". In patterns, '*' matches any sequence of
+// characters and '?' matches any single character, the same glob syntax as
+// the ignored paths of the HTTP integrations, e.g. "/grpc.health.v1.Health/*"
+// matches all the methods of a service.
+func WithIncludedMethods(patterns ...string) Option {
+	return func(cfg *config) {
+		cfg.includedMethods = append(cfg.includedMethods, patterns...)
+	}
+}
+
+// WithIgnoredMethods disables tracing of the calls of the methods matching any
+// of the given patterns. Patterns follow the syntax of WithIncludedMethods, and
+// take precedence over it.
+func WithIgnoredMethods(patterns ...string) Option {
+	return func(cfg *config) {
+		cfg.ignoredMethods = append(cfg.ignoredMethods, patterns...)
+	}
+}
+
+// WithMethodFilter adds a predicate deciding whether the calls of a method,
+// given by its full name, are traced. Calls are only traced if all the
+// predicates return true.
+func WithMethodFilter(fn func(fullMethod string) bool) Option {
+	return func(cfg *config) {
+		cfg.methodFilters = append(cfg.methodFilters, fn)
+	}
+}
+
+// WithMethodServiceName sets the service name of the spans of the given full
+// method, overriding WithServiceName.
+func WithMethodServiceName(fullMethod, name string) Option {
+	return func(cfg *config) {
+		if cfg.methodServiceNames == nil {
+			cfg.methodServiceNames = make(map[string]string)
+		}
+		cfg.methodServiceNames[fullMethod] = name
+	}
+}
+
+// WithMethodStreamMessages enables or disables tracing of the streaming messages
+// of the given full method, overriding WithStreamMessages. This option does not
+// apply to the stats handler.
+func WithMethodStreamMessages(fullMethod string, enabled bool) Option {
+	return func(cfg *config) {
+		if cfg.methodStreamMessages == nil {
+			cfg.methodStreamMessages = make(map[string]bool)
+		}
+		cfg.methodStreamMessages[fullMethod] = enabled
+	}
+}
+
+// WithMetadataTags sets the metadata keys whose values are captured as
+// "grpc.metadata.<key>" span tags. Servers tag the incoming metadata and
+// clients the outgoing metadata. Keys are case insensitive.
+func WithMetadataTags(keys ...string) Option {
+	return func(cfg *config) {
+		for _, k := range keys {
+			cfg.metadataTags = append(cfg.metadataTags, strings.ToLower(k))
+		}
+	}
+}
+
+// WithConnectionSpans enables or disables tracing of the lifetime of the
+// connections of the stats handlers. When enabled, a "grpc.connection" span is
+// started when a connection is established and finished when it ends, which
+// helps attributing latency to reconnects in long-lived channels. This option
+// only applies to the stats handlers and is disabled by default.
+func WithConnectionSpans(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.traceConnections = enabled
+	}
+}
+
 // NonErrorCodes determines the list of codes which will not be considered errors in instrumentation.
 // This call overrides the default handling of codes.Canceled as a non-error.
 func NonErrorCodes(cs ...codes.Code) InterceptorOption {
@@ -3,6 +3,9 @@ package grpc
 import (
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"github.com/adityayuga/signalfx-go-tracing/contrib/google.golang.org/internal/grpcutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 )
@@ -27,12 +30,12 @@ func (ss *serverStream) Context() context.Context {
 }
 
 func (ss *serverStream) RecvMsg(m interface{}) (err error) {
-	if ss.cfg.traceStreamMessages {
+	if ss.cfg.traceStreamMessagesFor(ss.method) {
 		span, _ := startSpanFromContext(
 			ss.ctx,
 			ss.method,
 			"grpc.message",
-			ss.cfg.serverServiceName(),
+			ss.cfg.serverServiceNameFor(ss.method),
 			ss.cfg.analyticsRate,
 		)
 
@@ -43,12 +46,12 @@ func (ss *serverStream) RecvMsg(m interface{}) (err error) {
 }
 
 func (ss *serverStream) SendMsg(m interface{}) (err error) {
-	if ss.cfg.traceStreamMessages {
+	if ss.cfg.traceStreamMessagesFor(ss.method) {
 		span, _ := startSpanFromContext(
 			ss.ctx,
 			ss.method,
 			"grpc.message",
-			ss.cfg.serverServiceName(),
+			ss.cfg.serverServiceNameFor(ss.method),
 			ss.cfg.analyticsRate,
 		)
 
@@ -58,6 +61,18 @@ func (ss *serverStream) SendMsg(m interface{}) (err error) {
 	return err
 }
 
+// untracedServerStream is the stream of an untraced RPC. Its context carries
+// the span context propagated by the caller.
+type untracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the ServerStream Context.
+func (ss *untracedServerStream) Context() context.Context {
+	return ss.ctx
+}
+
 // StreamServerInterceptor will trace streaming requests to the given gRPC server.
 func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 	cfg := new(config)
@@ -69,6 +84,12 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 		cfg.serviceName = "grpc.server"
 	}
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if !cfg.traced(info.FullMethod) {
+			return handler(srv, &untracedServerStream{
+				ServerStream: ss,
+				ctx:          propagateContext(ss.Context()),
+			})
+		}
 		ctx := ss.Context()
 
 		// if we've enabled call tracing, create a span
@@ -78,13 +99,20 @@ func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
 				ctx,
 				info.FullMethod,
 				"grpc.server",
-				cfg.serviceName,
+				cfg.serverServiceNameFor(info.FullMethod),
 				cfg.analyticsRate,
 			)
 
 			span.SetTag(ext.SpanKind, ext.SpanKindServer)
-
-			defer func() { finishWithError(span, err, cfg) }()
+			setServerTags(ctx, span, cfg)
+
+			defer func() {
+				if p := recover(); p != nil {
+					err = finishWithPanic(span, p, cfg)
+					return
+				}
+				finishWithError(span, err, cfg)
+			}()
 		}
 
 		// call the original handler with a new stream, which traces each send
@@ -107,19 +135,40 @@ func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
 	for _, fn := range opts {
 		fn(cfg)
 	}
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if !cfg.traced(info.FullMethod) {
+			return handler(propagateContext(ctx), req)
+		}
 		span, ctx := startSpanFromContext(
 			ctx,
 			info.FullMethod,
 			"grpc.server",
-			cfg.serverServiceName(),
+			cfg.serverServiceNameFor(info.FullMethod),
 			cfg.analyticsRate,
 		)
 
 		span.SetTag(ext.SpanKind, ext.SpanKindServer)
+		setServerTags(ctx, span, cfg)
+
+		defer func() {
+			if p := recover(); p != nil {
+				err = finishWithPanic(span, p, cfg)
+				return
+			}
+			finishWithError(span, err, cfg)
+		}()
+		return handler(ctx, req)
+	}
+}
 
-		resp, err := handler(ctx, req)
-		finishWithError(span, err, cfg)
-		return resp, err
+// setServerTags tags a server span with the peer of the RPC found in ctx and
+// the configured incoming metadata.
+func setServerTags(ctx context.Context, span ddtrace.Span, cfg *config) {
+	if p, ok := peer.FromContext(ctx); ok {
+		grpcutil.SetPeerTags(span, p.Addr)
+	}
+	if len(cfg.metadataTags) > 0 {
+		md, _ := metadata.FromIncomingContext(ctx)
+		grpcutil.SetMetadataTags(span, md, cfg.metadataTags)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	context "golang.org/x/net/context"
 	"google.golang.org/grpc/stats"
 
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 )
@@ -26,19 +27,29 @@ type clientStatsHandler struct{ cfg *config }
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	_, ctx = startSpanFromContext(
+	if !h.cfg.traced(rti.FullMethodName) {
+		return withUntraced(injectSpanIntoContext(ctx))
+	}
+	var span ddtrace.Span
+	span, ctx = startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		"grpc.client",
-		h.cfg.clientServiceName(),
+		h.cfg.clientServiceNameFor(rti.FullMethodName),
 		h.cfg.analyticsRate,
 	)
+	setClientMetadataTags(ctx, span, h.cfg)
 	ctx = injectSpanIntoContext(ctx)
-	return ctx
+	return withPayloadSizes(ctx)
 }
 
-// HandleRPC processes the RPC ending event by finishing the span from the context.
+// HandleRPC records the target and the payload sizes of the RPC on the span
+// from the context, and finishes it when the RPC ends.
 func (h *clientStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	sizes := payloadSizesFromContext(ctx)
+	if sizes == nil {
+		return
+	}
 	span, ok := tracer.SpanFromContext(ctx)
 	if !ok {
 		return
@@ -52,15 +63,25 @@ func (h *clientStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 			}
 			span.SetTag(ext.TargetPort, port)
 		}
+	case *stats.InPayload, *stats.OutPayload:
+		sizes.add(rs)
 	case *stats.End:
+		sizes.setTags(span, true)
 		finishWithError(span, rs.Error, h.cfg)
 	}
 }
 
-// TagConn implements stats.Handler.
-func (h *clientStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
-	return ctx
+// TagConn starts a new span for the established connection, if connection
+// tracing is enabled.
+func (h *clientStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	if !h.cfg.traceConnections {
+		return ctx
+	}
+	return startConnSpan(ctx, info, h.cfg.clientServiceName(), ext.SpanKindClient)
 }
 
-// HandleConn implements stats.Handler.
-func (h *clientStatsHandler) HandleConn(_ context.Context, _ stats.ConnStats) {}
+// HandleConn processes the connection ending event by finishing the connection
+// span from the context.
+func (h *clientStatsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	finishConnSpan(ctx, cs)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	context "golang.org/x/net/context"
@@ -44,16 +45,62 @@ func TestClientStatsHandler(t *testing.T) {
 	assert.True(span.FinishTime().After(span.StartTime()))
 	assert.Equal("grpc.client", span.OperationName())
 	assert.Equal(map[string]interface{}{
-		"span.type":     ext.AppTypeRPC,
-		"grpc.code":     codes.OK.String(),
-		"service.name":  serviceName,
-		"resource.name": "/grpc.Fixture/Ping",
-		"grpc.method":   "/grpc.Fixture/Ping",
-		ext.TargetHost:  "127.0.0.1",
-		ext.TargetPort:  server.port,
+		"span.type":          ext.AppTypeRPC,
+		"grpc.code":          codes.OK.String(),
+		"service.name":       serviceName,
+		"resource.name":      "/grpc.Fixture/Ping",
+		"grpc.method":        "/grpc.Fixture/Ping",
+		"rpc.service":        "grpc.Fixture",
+		"rpc.method":         "Ping",
+		"grpc.request.size":  int64(6),
+		"grpc.response.size": int64(8),
+		ext.TargetHost:       "127.0.0.1",
+		ext.TargetPort:       server.port,
 	}, span.Tags())
 }
 
+func TestClientStatsHandlerConnections(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server, err := newClientStatsHandlerTestServer(NewClientStatsHandler(WithConnectionSpans(true)))
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	_, err = server.client.Ping(context.Background(), &FixtureRequest{Name: "name"})
+	assert.NoError(err)
+	server.Close()
+
+	waitForSpans(mt, 2, 2*time.Second)
+	var conn mocktracer.Span
+	for _, span := range mt.FinishedSpans() {
+		if span.OperationName() == "grpc.connection" {
+			conn = span
+		}
+	}
+	if assert.NotNil(conn) {
+		assert.Equal(ext.SpanKindClient, conn.Tag(ext.SpanKind))
+		assert.Equal("127.0.0.1", conn.Tag(ext.PeerHostIPV4))
+		assert.NotEmpty(conn.Tag(tagLocalAddr))
+	}
+}
+
+func TestClientStatsHandlerIgnoredMethods(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server, err := newClientStatsHandlerTestServer(NewClientStatsHandler(WithIgnoredMethods("/grpc.Fixture/Ping")))
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	defer server.Close()
+
+	_, err = server.client.Ping(context.Background(), &FixtureRequest{Name: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, mt.FinishedSpans(), 0)
+}
+
 func newClientStatsHandlerTestServer(statsHandler stats.Handler) (*rig, error) {
 	server := grpc.NewServer()
 	fixtureServer := new(fixtureServer)
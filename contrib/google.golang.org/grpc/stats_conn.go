@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc/stats"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/google.golang.org/internal/grpcutil"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+)
+
+// tagLocalAddr is the local address of a traced connection.
+const tagLocalAddr = "grpc.local.addr"
+
+// connSpanKey is the context key of the span tracing a connection. It is not
+// the key used by the tracer, so that the spans of the RPCs sent over the
+// connection are not parented by the connection span.
+type connSpanKey struct{}
+
+// startConnSpan starts a span covering the lifetime of the connection described
+// by info, which is finished by finishConnSpan once the connection ends.
+func startConnSpan(ctx context.Context, info *stats.ConnTagInfo, service, kind string) context.Context {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ServiceName(service),
+		tracer.SpanType(ext.AppTypeRPC),
+		tracer.Tag(ext.SpanKind, kind),
+	}
+	if info.RemoteAddr != nil {
+		opts = append(opts, tracer.ResourceName(info.RemoteAddr.String()))
+	}
+	if info.LocalAddr != nil {
+		opts = append(opts, tracer.Tag(tagLocalAddr, info.LocalAddr.String()))
+	}
+	span := tracer.StartSpan("grpc.connection", opts...)
+	grpcutil.SetPeerTags(span, info.RemoteAddr)
+	return context.WithValue(ctx, connSpanKey{}, span)
+}
+
+// finishConnSpan finishes the connection span found in ctx when cs reports the
+// end of the connection.
+func finishConnSpan(ctx context.Context, cs stats.ConnStats) {
+	span, ok := ctx.Value(connSpanKey{}).(ddtrace.Span)
+	if !ok {
+		return
+	}
+	if _, ok := cs.(*stats.ConnEnd); ok {
+		span.Finish()
+	}
+}
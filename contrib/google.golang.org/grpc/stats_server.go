@@ -2,8 +2,11 @@ package grpc
 
 import (
 	context "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/stats"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/google.golang.org/internal/grpcutil"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 )
 
@@ -25,31 +28,56 @@ type serverStatsHandler struct {
 
 // TagRPC starts a new span for the initiated RPC request.
 func (h *serverStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
-	_, ctx = startSpanFromContext(
+	if !h.cfg.traced(rti.FullMethodName) {
+		return withUntraced(propagateContext(ctx))
+	}
+	span, ctx := startSpanFromContext(
 		ctx,
 		rti.FullMethodName,
 		"grpc.server",
-		h.cfg.serverServiceName(),
+		h.cfg.serverServiceNameFor(rti.FullMethodName),
 		h.cfg.analyticsRate,
 	)
-	return ctx
+	if len(h.cfg.metadataTags) > 0 {
+		md, _ := metadata.FromIncomingContext(ctx)
+		grpcutil.SetMetadataTags(span, md, h.cfg.metadataTags)
+	}
+	return withPayloadSizes(ctx)
 }
 
-// HandleRPC processes the RPC ending event by finishing the span from the context.
+// HandleRPC records the peer and the payload sizes of the RPC on the span from
+// the context, and finishes it when the RPC ends.
 func (h *serverStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	sizes := payloadSizesFromContext(ctx)
+	if sizes == nil {
+		return
+	}
 	span, ok := tracer.SpanFromContext(ctx)
 	if !ok {
 		return
 	}
-	if v, ok := rs.(*stats.End); ok {
-		finishWithError(span, v.Error, h.cfg)
+	switch rs := rs.(type) {
+	case *stats.InHeader:
+		grpcutil.SetPeerTags(span, rs.RemoteAddr)
+	case *stats.InPayload, *stats.OutPayload:
+		sizes.add(rs)
+	case *stats.End:
+		sizes.setTags(span, false)
+		finishWithError(span, rs.Error, h.cfg)
 	}
 }
 
-// TagConn implements stats.Handler.
-func (h *serverStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
-	return ctx
+// TagConn starts a new span for the established connection, if connection
+// tracing is enabled.
+func (h *serverStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	if !h.cfg.traceConnections {
+		return ctx
+	}
+	return startConnSpan(ctx, info, h.cfg.serverServiceName(), ext.SpanKindServer)
 }
 
-// HandleConn implements stats.Handler.
-func (h *serverStatsHandler) HandleConn(_ context.Context, _ stats.ConnStats) {}
+// HandleConn processes the connection ending event by finishing the connection
+// span from the context.
+func (h *serverStatsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	finishConnSpan(ctx, cs)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	context "golang.org/x/net/context"
@@ -39,13 +40,63 @@ func TestServerStatsHandler(t *testing.T) {
 	assert.NotZero(span.StartTime())
 	assert.True(span.FinishTime().After(span.StartTime()))
 	assert.Equal("grpc.server", span.OperationName())
+	tags := span.Tags()
+	assert.NotEmpty(tags[ext.PeerPort])
+	delete(tags, ext.PeerPort)
 	assert.Equal(map[string]interface{}{
-		"span.type":     ext.AppTypeRPC,
-		"grpc.code":     codes.OK.String(),
-		"service.name":  serviceName,
-		"resource.name": "/grpc.Fixture/Ping",
-		"grpc.method":   "/grpc.Fixture/Ping",
-	}, span.Tags())
+		"span.type":          ext.AppTypeRPC,
+		"grpc.code":          codes.OK.String(),
+		"service.name":       serviceName,
+		"resource.name":      "/grpc.Fixture/Ping",
+		"grpc.method":        "/grpc.Fixture/Ping",
+		"rpc.service":        "grpc.Fixture",
+		"rpc.method":         "Ping",
+		"grpc.request.size":  int64(6),
+		"grpc.response.size": int64(8),
+		ext.PeerHostIPV4:     "127.0.0.1",
+	}, tags)
+}
+
+func TestServerStatsHandlerConnections(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server, err := newServerStatsHandlerTestServer(NewServerStatsHandler(WithConnectionSpans(true)))
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	_, err = server.client.Ping(context.Background(), &FixtureRequest{Name: "name"})
+	assert.NoError(err)
+	server.Close()
+
+	waitForSpans(mt, 2, 2*time.Second)
+	var conn mocktracer.Span
+	for _, span := range mt.FinishedSpans() {
+		if span.OperationName() == "grpc.connection" {
+			conn = span
+		}
+	}
+	if assert.NotNil(conn) {
+		assert.Equal(ext.SpanKindServer, conn.Tag(ext.SpanKind))
+		assert.Equal("127.0.0.1", conn.Tag(ext.PeerHostIPV4))
+		assert.NotEmpty(conn.Tag(tagLocalAddr))
+	}
+}
+
+func TestServerStatsHandlerIgnoredMethods(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server, err := newServerStatsHandlerTestServer(NewServerStatsHandler(WithIgnoredMethods("/grpc.Fixture/Ping")))
+	if err != nil {
+		t.Fatalf("failed to start test server: %s", err)
+	}
+	defer server.Close()
+
+	_, err = server.client.Ping(context.Background(), &FixtureRequest{Name: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, mt.FinishedSpans(), 0)
 }
 
 func newServerStatsHandlerTestServer(statsHandler stats.Handler) (*rig, error) {
@@ -4,4 +4,7 @@ package grpc
 const (
 	tagMethod = "grpc.method"
 	tagCode   = "grpc.code"
+
+	tagRequestSize  = "grpc.request.size"
+	tagResponseSize = "grpc.response.size"
 )
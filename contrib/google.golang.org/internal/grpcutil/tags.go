@@ -0,0 +1,70 @@
+package grpcutil
+
+import (
+	"net"
+	"strings"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Tags shared by the gRPC integrations.
+const (
+	TagRPCService     = "rpc.service"
+	TagRPCMethod      = "rpc.method"
+	TagMetadataPrefix = "grpc.metadata."
+)
+
+// SplitMethod splits a gRPC full method name, such as "/package.Service/Method",
+// into its service and method names.
+func SplitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "", fullMethod
+}
+
+// SetMethodTags sets the rpc.service and rpc.method tags of span from the gRPC
+// full method name.
+func SetMethodTags(span ddtrace.Span, fullMethod string) {
+	service, method := SplitMethod(fullMethod)
+	if service != "" {
+		span.SetTag(TagRPCService, service)
+	}
+	span.SetTag(TagRPCMethod, method)
+}
+
+// SetPeerTags sets the peer tags of span from the address of the remote end of
+// a gRPC connection.
+func SetPeerTags(span ddtrace.Span, addr net.Addr) {
+	if addr == nil {
+		return
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return
+	}
+	if ip := net.ParseIP(host); ip == nil {
+		if host != "" {
+			span.SetTag(ext.PeerHostname, host)
+		}
+	} else if ip.To4() != nil {
+		span.SetTag(ext.PeerHostIPV4, host)
+	} else {
+		span.SetTag(ext.PeerHostIPV6, host)
+	}
+	span.SetTag(ext.PeerPort, port)
+}
+
+// SetMetadataTags tags span with the values found in md for the given keys. Keys
+// are expected to be lowercase, like the keys of gRPC metadata.
+func SetMetadataTags(span ddtrace.Span, md metadata.MD, keys []string) {
+	for _, k := range keys {
+		if vals := md[k]; len(vals) > 0 {
+			span.SetTag(TagMetadataPrefix+k, strings.Join(vals, ","))
+		}
+	}
+}
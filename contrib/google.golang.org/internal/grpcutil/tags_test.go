@@ -0,0 +1,66 @@
+package grpcutil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func TestSplitMethod(t *testing.T) {
+	for _, tt := range []struct {
+		in, service, method string
+	}{
+		{"/grpc.Fixture/Ping", "grpc.Fixture", "Ping"},
+		{"/pkg.sub.Service/Do", "pkg.sub.Service", "Do"},
+		{"Ping", "", "Ping"},
+		{"", "", ""},
+	} {
+		service, method := SplitMethod(tt.in)
+		assert.Equal(t, tt.service, service, tt.in)
+		assert.Equal(t, tt.method, method, tt.in)
+	}
+}
+
+func TestSetPeerTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for _, tt := range []struct {
+		addr net.Addr
+		tags map[string]interface{}
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 50051}, map[string]interface{}{ext.PeerHostIPV4: "10.0.0.1", ext.PeerPort: "50051"}},
+		{&net.TCPAddr{IP: net.ParseIP("::1"), Port: 443}, map[string]interface{}{ext.PeerHostIPV6: "::1", ext.PeerPort: "443"}},
+		{nil, map[string]interface{}{}},
+	} {
+		span := tracer.StartSpan("test")
+		SetPeerTags(span, tt.addr)
+		span.Finish()
+		tags := span.(mocktracer.Span).Tags()
+		for k, v := range tt.tags {
+			assert.Equal(t, v, tags[k])
+		}
+		assert.Nil(t, tags[ext.PeerHostname])
+	}
+}
+
+func TestSetMetadataTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	md := metadata.Pairs("x-tenant", "a", "x-tenant", "b", "authorization", "secret")
+	SetMetadataTags(span, md, []string{"x-tenant", "x-missing"})
+	span.Finish()
+
+	tags := span.(mocktracer.Span).Tags()
+	assert.Equal(t, "a,b", tags["grpc.metadata.x-tenant"])
+	assert.Nil(t, tags["grpc.metadata.authorization"])
+	assert.Nil(t, tags["grpc.metadata.x-missing"])
+}
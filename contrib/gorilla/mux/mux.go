@@ -101,5 +101,11 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 	spanopts = append(spanopts, r.config.spanOpts...)
-	httputil.TraceAndServe(r.Router, w, req, r.config.serviceName, route, spanopts...)
+	httputil.TraceAndServeWithConfig(r.Router, w, req, &httputil.TraceConfig{
+		Service:       r.config.serviceName,
+		Resource:      route,
+		SpanOpts:      spanopts,
+		RecoverPanics: r.config.recoverPanics,
+		Ignore:        &r.config.ignore,
+	})
 }
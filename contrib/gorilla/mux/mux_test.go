@@ -122,6 +122,34 @@ func TestImplementingMethods(t *testing.T) {
 	_ = (*Router)(r.UseEncodedPath())
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := NewRouter(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	)
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...RouterOption) {
 		mux := NewRouter(opts...)
@@ -1,6 +1,9 @@
 package mux
 
 import (
+	"net/http"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
 )
@@ -9,6 +12,8 @@ type routerConfig struct {
 	serviceName   string
 	spanOpts      []ddtrace.StartSpanOption // additional span options to be applied
 	analyticsRate float64
+	recoverPanics bool
+	ignore        httputil.IgnoreRules
 }
 
 // RouterOption represents an option that can be passed to NewRouter.
@@ -16,6 +21,7 @@ type RouterOption func(*routerConfig)
 
 func defaults(cfg *routerConfig) {
 	cfg.analyticsRate = globalconfig.AnalyticsRate()
+	cfg.ignore = httputil.DefaultIgnoreRules()
 	cfg.serviceName = "mux.router"
 }
 
@@ -34,6 +40,45 @@ func WithSpanOptions(opts ...ddtrace.StartSpanOption) RouterOption {
 	}
 }
 
+// WithPanicRecovery sets whether panics raised by handlers are recovered and
+// answered with a 500 status. By default, the panic is recorded on the span
+// and propagated to the caller.
+func WithPanicRecovery(on bool) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.recoverPanics = on
+	}
+}
+
+// WithIgnoreRequest serves the requests for which fn returns true without tracing
+// them. The span context propagated in the headers of an ignored request is
+// still passed on to the handler through the request context, so that the spans
+// it starts continue the trace of the caller.
+func WithIgnoreRequest(fn func(*http.Request) bool) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.ignore.Funcs = append(cfg.ignore.Funcs, fn)
+	}
+}
+
+// WithIgnorePaths serves the requests whose path matches any of the given glob
+// patterns without tracing them, as with WithIgnoreRequest. In patterns, '*'
+// matches any sequence of characters, including '/', and '?' matches any single
+// character. The patterns add to those set by tracing.WithHTTPIgnorePaths.
+func WithIgnorePaths(globs ...string) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.ignore.Paths = append(cfg.ignore.Paths, globs...)
+	}
+}
+
+// WithIgnoreUserAgents serves the requests whose User-Agent header matches any of
+// the given glob patterns without tracing them, as with WithIgnoreRequest. The
+// patterns follow the syntax of WithIgnorePaths and add to those set by
+// tracing.WithHTTPIgnoreUserAgents.
+func WithIgnoreUserAgents(globs ...string) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.ignore.UserAgents = append(cfg.ignore.UserAgents, globs...)
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) RouterOption {
 	if on {
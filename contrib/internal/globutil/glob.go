@@ -0,0 +1,43 @@
+// Package globutil provides the glob matching shared by the integrations
+// which let requests or calls be selected by patterns, such as the ignored
+// paths of HTTP servers or the ignored methods of gRPC services.
+package globutil
+
+// Match reports whether s matches the glob pattern, where '*' matches any
+// sequence of characters, including '/', and '?' matches any single character.
+// Any other character of the pattern matches itself.
+func Match(pattern, s string) bool {
+	var (
+		px, sx         int
+		nextPx, nextSx = -1, -1
+	)
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				// try to match the rest at sx, and restart at sx+1 on failure
+				nextPx, nextSx = px, sx+1
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+					continue
+				}
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+					continue
+				}
+			}
+		}
+		if nextPx >= 0 && nextSx <= len(s) {
+			px, sx = nextPx, nextSx
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package globutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"/healthz", "/healthz", true},
+		{"/healthz", "/healthz/live", false},
+		{"/health*", "/healthz/live", true},
+		{"/static/*", "/static/js/app.js", true},
+		{"/static/*", "/static", false},
+		{"*.css", "/static/css/main.css", true},
+		{"/v?/status", "/v1/status", true},
+		{"/v?/status", "/v10/status", false},
+		{"kube-probe/*", "kube-probe/1.18", true},
+		{"*Prometheus*", "Prometheus/2.15.2", true},
+		{"/grpc.health.v1.Health/*", "/grpc.health.v1.Health/Check", true},
+		{"/grpc.health.v1.Health/*", "/grpc.Fixture/Ping", false},
+		{"/grpc.*/Ping", "/grpc.Fixture/Ping", true},
+		{"[a-z]", "a", false},
+		{"*", "", true},
+		{"", "", true},
+		{"", "/", false},
+	} {
+		assert.Equal(t, tt.want, Match(tt.pattern, tt.s), "%q ~ %q", tt.pattern, tt.s)
+	}
+}
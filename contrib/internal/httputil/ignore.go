@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"net/http"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/globutil"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
+)
+
+// IgnoreRules specifies which requests should be served without being traced,
+// such as health checks, metrics scrapes or static assets. The HTTP server
+// integrations configure them through their WithIgnoreRequest, WithIgnorePaths
+// and WithIgnoreUserAgents options, which add to the rules configured globally
+// (see DefaultIgnoreRules).
+//
+// A request is ignored when it matches any of the rules. Ignored requests are
+// served without a span, but the span context propagated in their headers is
+// still passed on to the handler through the request context (see
+// PropagateContext), so that the spans started by the handler are not orphaned.
+type IgnoreRules struct {
+	// Paths holds glob patterns matched against the request path, following
+	// the syntax of globutil.Match: '*' matches any sequence of characters,
+	// including '/', and '?' matches any single character.
+	Paths []string
+
+	// UserAgents holds glob patterns matched against the User-Agent header.
+	UserAgents []string
+
+	// Funcs holds predicates reporting whether a request should be ignored.
+	Funcs []func(*http.Request) bool
+}
+
+// DefaultIgnoreRules returns the ignore rules configured globally, for example
+// through the tracing package.
+func DefaultIgnoreRules() IgnoreRules {
+	return IgnoreRules{
+		Paths:      globalconfig.HTTPIgnorePaths(),
+		UserAgents: globalconfig.HTTPIgnoreUserAgents(),
+	}
+}
+
+// Ignore reports whether the request r matches any of the rules.
+func (rules *IgnoreRules) Ignore(r *http.Request) bool {
+	if rules == nil {
+		return false
+	}
+	for _, p := range rules.Paths {
+		if globutil.Match(p, r.URL.Path) {
+			return true
+		}
+	}
+	if ua := r.UserAgent(); ua != "" {
+		for _, p := range rules.UserAgents {
+			if globutil.Match(p, ua) {
+				return true
+			}
+		}
+	}
+	for _, fn := range rules.Funcs {
+		if fn(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// PropagateContext returns r with a context carrying the span context found in
+// its headers, if any. It is used for requests which aren't traced, so that
+// spans started further down the call chain stay attached to the caller's trace.
+func PropagateContext(r *http.Request) *http.Request {
+	if _, ok := tracer.SpanFromContext(r.Context()); ok {
+		return r
+	}
+	spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header))
+	if err != nil {
+		return r
+	}
+	return r.WithContext(tracer.ContextWithSpan(r.Context(), remoteSpan{ctx: spanctx}))
+}
+
+// remoteSpan is a span which is never recorded. It only carries the context
+// of a span from another process, so that it can become the parent of local spans.
+type remoteSpan struct {
+	ddtrace.NoopSpan
+	ctx ddtrace.SpanContext
+}
+
+// Context implements ddtrace.Span.
+func (s remoteSpan) Context() ddtrace.SpanContext { return s.ctx }
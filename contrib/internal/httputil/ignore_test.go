@@ -0,0 +1,112 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := &IgnoreRules{
+		Paths:      []string{"/metrics"},
+		UserAgents: []string{"kube-probe/*"},
+		Funcs: []func(*http.Request) bool{func(r *http.Request) bool {
+			return r.Method == "OPTIONS"
+		}},
+	}
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	assert.True(rules.Ignore(r))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	assert.False(rules.Ignore(r))
+	r.Header.Set("User-Agent", "kube-probe/1.18")
+	assert.True(rules.Ignore(r))
+
+	r = httptest.NewRequest("OPTIONS", "/", nil)
+	assert.True(rules.Ignore(r))
+
+	rules = nil
+	assert.False(rules.Ignore(r))
+}
+
+func TestTraceAndServeIgnored(t *testing.T) {
+	rules := &IgnoreRules{
+		Paths:      []string{"/health*"},
+		UserAgents: []string{"kube-probe/*"},
+		Funcs: []func(*http.Request) bool{func(r *http.Request) bool {
+			return r.Method == "OPTIONS"
+		}},
+	}
+	for _, tt := range []struct {
+		name      string
+		method    string
+		path      string
+		userAgent string
+		propagate bool
+		ignored   bool
+	}{
+		{name: "path", method: "GET", path: "/healthz", propagate: true, ignored: true},
+		{name: "path-nested", method: "GET", path: "/health/live", propagate: true, ignored: true},
+		{name: "user-agent", method: "GET", path: "/", userAgent: "kube-probe/1.18", propagate: true, ignored: true},
+		{name: "func", method: "OPTIONS", path: "/", propagate: true, ignored: true},
+		{name: "not-propagated", method: "GET", path: "/healthz", ignored: true},
+		{name: "traced", method: "GET", path: "/", propagate: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			assert := assert.New(t)
+			defer mt.Stop()
+
+			parent := tracer.StartSpan("parent")
+			parent.Finish()
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.userAgent != "" {
+				r.Header.Set("User-Agent", tt.userAgent)
+			}
+			if tt.propagate {
+				err := tracer.Inject(parent.Context(), tracer.HTTPHeadersCarrier(r.Header))
+				assert.NoError(err)
+			}
+
+			called := false
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				child, _ := tracer.StartSpanFromContext(r.Context(), "child")
+				child.Finish()
+			}
+			TraceAndServeWithConfig(http.HandlerFunc(handler), httptest.NewRecorder(), r, &TraceConfig{
+				Service:  "service",
+				Resource: "resource",
+				Ignore:   rules,
+			})
+			assert.True(called)
+
+			spans := mt.FinishedSpans()
+			if !tt.ignored {
+				assert.Len(spans, 3)
+				assert.Equal("http.request", spans[2].OperationName())
+				assert.Equal(spans[0].SpanID(), spans[2].ParentID())
+				assert.Equal(spans[2].SpanID(), spans[1].ParentID())
+				return
+			}
+			// ignored requests have no span, but the spans started by their
+			// handler continue the trace propagated by the caller, if any
+			assert.Len(spans, 2)
+			parentSpan, child := spans[0], spans[1]
+			assert.Equal("child", child.OperationName())
+			if tt.propagate {
+				assert.Equal(parentSpan.SpanID(), child.ParentID())
+				assert.Equal(parentSpan.TraceID(), child.TraceID())
+			} else {
+				assert.Equal(uint64(0), child.ParentID())
+			}
+		})
+	}
+}
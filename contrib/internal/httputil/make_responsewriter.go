@@ -32,23 +32,22 @@ package httputil
 
 import (
 	"net/http"
-	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 )
 
-// wrapResponseWriter wraps an underlying http.ResponseWriter so that it can
-// trace the http response codes. It also checks for various http interfaces
+// wrapResponseWriter wraps an underlying http.ResponseWriter with rw so that
+// it can trace the http response codes. It also checks for various http interfaces
 // (Flusher, Pusher, CloseNotifier, Hijacker) and if the underlying
 // http.ResponseWriter implements them it generates an unnamed struct with the
 // appropriate fields.
 //
 // This code is generated because we have to account for all the permutations
 // of the interfaces.
-func wrapResponseWriter(w http.ResponseWriter, span ddtrace.Span) http.ResponseWriter {
+func wrapResponseWriter(w http.ResponseWriter, rw *responseWriter) http.ResponseWriter {
 {{- range .Interfaces }}
 	h{{.}}, ok{{.}} := w.(http.{{.}})
 {{- end }}
 
-	w = newResponseWriter(w, span)
+	w = rw
 	switch {
 {{- range .Combinations }}
 	{{- range . }}
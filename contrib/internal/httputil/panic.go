@@ -0,0 +1,26 @@
+package httputil
+
+import (
+	"fmt"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+)
+
+// PanicError is the error recorded on a span when the traced handler panics.
+type PanicError struct {
+	// Value holds the value the handler panicked with.
+	Value interface{}
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// TagPanic marks the span as failed with the recovered panic value p. It must
+// be called from the deferred function which recovered p, so that the stack
+// recorded on the span is the one of the panicking goroutine.
+func TagPanic(span ddtrace.Span, p interface{}) {
+	span.SetTag(ext.Error, &PanicError{Value: p})
+}
@@ -15,6 +15,35 @@ import (
 
 // TraceAndServe will apply tracing to the given http.Handler using the passed tracer under the given service and resource.
 func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, service, resource string, spanopts ...ddtrace.StartSpanOption) {
+	TraceAndServeWithConfig(h, w, r, &TraceConfig{
+		Service:  service,
+		Resource: resource,
+		SpanOpts: spanopts,
+	})
+}
+
+// TraceConfig defines the configuration for TraceAndServeWithConfig.
+type TraceConfig struct {
+	// Service is the service name of the request span.
+	Service string
+	// Resource is the resource name of the request span.
+	Resource string
+	// SpanOpts are additional options applied to the request span.
+	SpanOpts []ddtrace.StartSpanOption
+	// RecoverPanics makes panics raised by the handler be recovered and
+	// answered with a 500 status instead of being propagated.
+	RecoverPanics bool
+	// Ignore holds the rules of the requests which should be served without
+	// being traced. Their span context is still propagated to the handler.
+	Ignore *IgnoreRules
+}
+
+// TraceAndServeWithConfig will apply tracing to the given http.Handler using the given configuration.
+func TraceAndServeWithConfig(h http.Handler, w http.ResponseWriter, r *http.Request, cfg *TraceConfig) {
+	if cfg.Ignore.Ignore(r) {
+		h.ServeHTTP(w, PropagateContext(r))
+		return
+	}
 	originalURL := url.URL{
 		Scheme: "http",
 		Host: r.Host,
@@ -28,18 +57,35 @@ func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, servi
 
 	opts := append([]ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeWeb),
-		tracer.ServiceName(service),
-		tracer.ResourceName(resource),
+		tracer.ServiceName(cfg.Service),
+		tracer.ResourceName(cfg.Resource),
 		tracer.Tag(ext.HTTPMethod, r.Method),
 		tracer.Tag(ext.HTTPURL, originalURL.String()),
-	}, spanopts...)
+	}, cfg.SpanOpts...)
 	if spanctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
 		opts = append(opts, tracer.ChildOf(spanctx))
 	}
 	span, ctx := tracer.StartSpanFromContext(r.Context(), "http.request", opts...)
 	defer span.Finish()
 
-	w = wrapResponseWriter(w, span)
+	rw := newResponseWriter(w, span)
+	defer func() {
+		if p := recover(); p != nil {
+			if rw.status == 0 {
+				// the net/http server aborts the response of a panicking
+				// handler, which the client sees as a failed request
+				span.SetTag(ext.HTTPCode, strconv.Itoa(http.StatusInternalServerError))
+				if cfg.RecoverPanics {
+					rw.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+				}
+			}
+			TagPanic(span, p)
+			if !cfg.RecoverPanics {
+				panic(p)
+			}
+		}
+	}()
+	w = wrapResponseWriter(w, rw)
 
 	h.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -45,6 +45,54 @@ func TestTraceAndServe(t *testing.T) {
 		assert.Equal("503: Service Unavailable", span.Tag(ext.Error).(error).Error())
 	})
 
+	t.Run("panic", func(t *testing.T) {
+		mt := mocktracer.Start()
+		assert := assert.New(t)
+		defer mt.Stop()
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		assert.NoError(err)
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			panic("oh no")
+		}
+		assert.PanicsWithValue("oh no", func() {
+			TraceAndServe(http.HandlerFunc(handler), w, r, "service", "resource")
+		})
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		span := spans[0]
+		assert.Equal("500", span.Tag(ext.HTTPCode))
+		assert.Equal("panic: oh no", span.Tag(ext.Error).(error).Error())
+		assert.Equal("oh no", span.Tag(ext.Error).(*PanicError).Value)
+	})
+
+	t.Run("panic recovered", func(t *testing.T) {
+		mt := mocktracer.Start()
+		assert := assert.New(t)
+		defer mt.Stop()
+
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		assert.NoError(err)
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			panic("oh no")
+		}
+		assert.NotPanics(func() {
+			TraceAndServeWithConfig(http.HandlerFunc(handler), w, r, &TraceConfig{
+				Service:       "service",
+				Resource:      "resource",
+				RecoverPanics: true,
+			})
+		})
+		assert.Equal(http.StatusInternalServerError, w.Code)
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		span := spans[0]
+		assert.Equal("500", span.Tag(ext.HTTPCode))
+		assert.Equal("panic: oh no", span.Tag(ext.Error).(error).Error())
+	})
+
 	t.Run("Hijacker,Flusher,CloseNotifier", func(t *testing.T) {
 		assert := assert.New(t)
 		called := false
@@ -85,7 +133,7 @@ func TestTraceAndServe(t *testing.T) {
 		_, ok = w.(http.Pusher)
 		assert.True(t, ok)
 
-		w = wrapResponseWriter(w, nil)
+		w = wrapResponseWriter(w, newResponseWriter(w, nil))
 		_, ok = w.(http.ResponseWriter)
 		assert.True(t, ok)
 		_, ok = w.(http.Pusher)
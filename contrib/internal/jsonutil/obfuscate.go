@@ -0,0 +1,113 @@
+// Package jsonutil provides helpers shared by the integrations recording JSON
+// documents, such as queries, on spans.
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// Obfuscate returns a compact copy of the given JSON document where every
+// value is replaced by "?", keeping object keys so that the shape of the
+// document is preserved. Arrays holding only values are collapsed into a
+// single ["?"], so that lists of operands do not grow the result. Data holding
+// several documents, such as newline-delimited JSON, has each of them written
+// on its own line.
+func Obfuscate(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var b strings.Builder
+	for i := 0; i == 0 || dec.More(); i++ {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if _, err := writeObfuscated(dec, &b); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// writeObfuscated reads the next value of dec and writes its obfuscated form
+// to b. It reports whether the value is an object or an array.
+func writeObfuscated(dec *json.Decoder, b *strings.Builder) (container bool, err error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		b.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			key, err := dec.Token()
+			if err != nil {
+				return false, err
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			b.Write(k)
+			b.WriteByte(':')
+			if _, err := writeObfuscated(dec, b); err != nil {
+				return false, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return false, err
+		}
+		b.WriteByte('}')
+		return true, nil
+	case json.Delim('['):
+		var (
+			elems      strings.Builder
+			n          int
+			containers bool
+		)
+		for ; dec.More(); n++ {
+			if n > 0 {
+				elems.WriteByte(',')
+			}
+			c, err := writeObfuscated(dec, &elems)
+			if err != nil {
+				return false, err
+			}
+			containers = containers || c
+		}
+		if _, err := dec.Token(); err != nil {
+			return false, err
+		}
+		switch {
+		case n == 0:
+			b.WriteString("[]")
+		case !containers:
+			b.WriteString(`["?"]`)
+		default:
+			b.WriteByte('[')
+			b.WriteString(elems.String())
+			b.WriteByte(']')
+		}
+		return true, nil
+	}
+	b.WriteString(`"?"`)
+	return false, nil
+}
+
+// Truncate returns s cut to at most n bytes, ending with "..." when it was cut.
+// It does not cut runes in half. A non-positive n leaves s unchanged.
+func Truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	const ellipsis = "..."
+	if n <= len(ellipsis) {
+		return ellipsis[:n]
+	}
+	i := n - len(ellipsis)
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i] + ellipsis
+}
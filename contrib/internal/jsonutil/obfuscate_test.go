@@ -0,0 +1,37 @@
+package jsonutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscate(t *testing.T) {
+	for in, out := range map[string]string{
+		`"value"`:                          `"?"`,
+		`{"name": "alice", "age": 30}`:     `{"name":"?","age":"?"}`,
+		`{"a": {"b": null, "c": true}}`:    `{"a":{"b":"?","c":"?"}}`,
+		`{"ids": {"$in": [1, 2, 3]}}`:      `{"ids":{"$in":["?"]}}`,
+		`{"docs": [{"a": 1}, {"b": "x"}]}`: `{"docs":[{"a":"?"},{"b":"?"}]}`,
+		`{"mixed": [1, {"a": 1}]}`:         `{"mixed":["?",{"a":"?"}]}`,
+		`{"empty": [], "obj": {}}`:         `{"empty":[],"obj":{}}`,
+		`{"quote\"d": "v"}`:                `{"quote\"d":"?"}`,
+		"{\"a\": 1}\n{\"b\": [2]}\n":       "{\"a\":\"?\"}\n{\"b\":[\"?\"]}",
+	} {
+		got, err := Obfuscate([]byte(in))
+		assert.NoError(t, err)
+		assert.Equal(t, out, got, in)
+	}
+
+	_, err := Obfuscate([]byte(`{"a": `))
+	assert.Error(t, err)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abcdef", Truncate("abcdef", 0))
+	assert.Equal(t, "abcdef", Truncate("abcdef", 6))
+	assert.Equal(t, "ab...", Truncate("abcdef", 5))
+	assert.Equal(t, "..", Truncate("abcdef", 2))
+	// multi-byte runes are not cut in half
+	assert.Equal(t, "a...", Truncate("aéééé", 5))
+}
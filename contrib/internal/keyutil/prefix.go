@@ -0,0 +1,40 @@
+// Package keyutil provides the key handling shared by the integrations of
+// key-value stores, such as the key prefixes tagged on their spans.
+package keyutil
+
+import (
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// Prefix returns the first n bytes of key, cut back to the start of the rune
+// they would split. Keys which are not valid UTF-8, such as binary keys, have
+// their first n bytes returned hex-encoded instead.
+func Prefix(key string, n int) string {
+	if n >= len(key) {
+		if utf8.ValidString(key) {
+			return key
+		}
+		return hex.EncodeToString([]byte(key))
+	}
+	// a rune is split if its continuation bytes follow the cut, so look back
+	// for its start no further than the length of a rune
+	for i := n; i >= 0 && i > n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(key[i]) {
+			if utf8.ValidString(key[:i]) {
+				return key[:i]
+			}
+			break
+		}
+	}
+	return hex.EncodeToString([]byte(key[:n]))
+}
+
+// PrefixBytes is the same as Prefix, for keys held in byte slices.
+func PrefixBytes(key []byte, n int) string {
+	if len(key) > n {
+		// only the byte following the cut is needed to find a split rune
+		key = key[:n+1]
+	}
+	return Prefix(string(key), n)
+}
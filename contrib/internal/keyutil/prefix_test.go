@@ -0,0 +1,29 @@
+package keyutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefix(t *testing.T) {
+	for _, tt := range []struct {
+		key  string
+		n    int
+		want string
+	}{
+		{"user:42", 5, "user:"},
+		{"abc", 5, "abc"},
+		{"", 5, ""},
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"日本語", 4, "日"},
+		{"日本語", 2, ""},
+		{"\xff\xfe\x00\x01", 3, "fffe00"},
+		{"\x80\x80\x80\x80\x80", 4, "80808080"},
+		{"ab\xff", 5, "6162ff"},
+	} {
+		assert.Equal(t, tt.want, Prefix(tt.key, tt.n), "%q", tt.key)
+		assert.Equal(t, tt.want, PrefixBytes([]byte(tt.key), tt.n), "%q", tt.key)
+	}
+}
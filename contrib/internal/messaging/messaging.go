@@ -0,0 +1,18 @@
+// Package messaging provides helpers shared by the messaging integrations.
+package messaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashKey returns the hex encoded SHA-256 hash of a message key, which allows
+// correlating the spans of messages sharing a key without recording the key.
+// It returns an empty string for empty keys.
+func HashKey(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
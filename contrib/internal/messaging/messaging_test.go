@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, "", HashKey(nil))
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", HashKey([]byte("foo")))
+	assert.NotEqual(t, HashKey([]byte("foo")), HashKey([]byte("bar")))
+}
+
+func TestRegistry(t *testing.T) {
+	var r Registry
+	p := Partition{Topic: "topic"}
+	s1, s2 := r.NewSet(), r.NewSet()
+	s1.Store("a", p, 1)
+	s2.Store("b", p, 2)
+
+	v, ok := r.Load("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	v, ok = r.Take("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	_, ok = r.Load("a")
+	assert.False(t, ok)
+	_, ok = s1.Take("b")
+	assert.False(t, ok, "values are only taken from their own set")
+
+	// closing a set releases its values
+	s1.Store("c", p, 3)
+	assert.Equal(t, []interface{}{3}, s1.Close())
+	_, ok = r.Load("c")
+	assert.False(t, ok)
+	s1.Store("d", p, 4)
+	_, ok = r.Load("d")
+	assert.False(t, ok, "values stored after Close are dropped")
+
+	assert.Equal(t, []interface{}{2}, s2.Close())
+	assert.Empty(t, r.values)
+}
+
+func TestRegistryEviction(t *testing.T) {
+	var evicted []interface{}
+	r := Registry{Evict: func(v interface{}) { evicted = append(evicted, v) }}
+	p1, p2 := Partition{Topic: "topic", Partition: 1}, Partition{Topic: "topic", Partition: 2}
+	s := r.NewSet()
+	s.Store("a", p1, 1)
+	s.Store("b", p2, 2)
+	s.Store("c", p1, 3)
+	s.Store("d", p1, 4)
+
+	// taking c evicts the values of the skipped messages of its partition
+	v, ok := r.Take("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, []interface{}{1}, evicted)
+	_, ok = r.Load("a")
+	assert.False(t, ok)
+	_, ok = r.Load("b")
+	assert.True(t, ok, "other partitions are left untouched")
+	_, ok = r.Load("d")
+	assert.True(t, ok, "later messages are left untouched")
+
+	// the number of values of a partition is bounded
+	evicted = nil
+	for i := 0; i < maxPartitionValues; i++ {
+		s.Store(i, p2, i)
+	}
+	assert.Equal(t, []interface{}{2}, evicted)
+	assert.Len(t, s.partitions[p2], maxPartitionValues)
+	assert.Len(t, r.values, maxPartitionValues+1)
+
+	assert.Len(t, s.Close(), maxPartitionValues+1)
+	assert.Empty(t, r.values)
+}
@@ -0,0 +1,188 @@
+package messaging
+
+import "sync"
+
+// maxPartitionValues bounds the number of values a Set holds for the messages
+// of a partition. Past it, the values of the oldest messages are evicted.
+const maxPartitionValues = 10000
+
+// Partition identifies the partition of a topic which a message was received from.
+type Partition struct {
+	Topic     string
+	Partition int32
+}
+
+// Registry indexes values, such as spans or tracing options, by the messages
+// received by the wrapped consumers of an integration, so that they can be
+// looked up from a message alone. The values of each consumer are held by a
+// Set, which the consumer releases once closed.
+//
+// As the messages of a partition are processed in order, taking the value of
+// a message evicts the values of the messages received before it from the same
+// partition, which were skipped, for instance by a handler filtering messages.
+// The number of values held for each partition is bounded as well, so that the
+// values of messages which are never looked up do not accumulate.
+type Registry struct {
+	// Evict, when set, is called with the values evicted from the sets of
+	// the registry, outside of its lock.
+	Evict func(v interface{})
+
+	mu     sync.Mutex
+	values map[interface{}]*entry
+}
+
+// entry is the value of a message held by a Set.
+type entry struct {
+	set       *Set
+	msg       interface{}
+	partition Partition
+	value     interface{}
+}
+
+// NewSet returns a new Set of r, to be closed when its consumer is closed.
+func (r *Registry) NewSet() *Set {
+	return &Set{
+		registry:   r,
+		partitions: make(map[Partition][]*entry),
+	}
+}
+
+// Load returns the value of msg held by any open Set of r.
+func (r *Registry) Load(msg interface{}) (interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.values[msg]
+	if !ok {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Take removes and returns the value of msg held by any open Set of r,
+// evicting the values of the messages received before it from its partition.
+func (r *Registry) Take(msg interface{}) (interface{}, bool) {
+	r.mu.Lock()
+	e, ok := r.values[msg]
+	var evicted []interface{}
+	if ok {
+		evicted = r.take(e)
+	}
+	r.mu.Unlock()
+	r.evict(evicted)
+	if !ok {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// take removes e and the entries received before it from its partition,
+// returning the values of the latter. r.mu must be held.
+func (r *Registry) take(e *entry) []interface{} {
+	queue := e.set.partitions[e.partition]
+	var evicted []interface{}
+	for i, qe := range queue {
+		if qe != e {
+			continue
+		}
+		for _, old := range queue[:i] {
+			delete(r.values, old.msg)
+			evicted = append(evicted, old.value)
+		}
+		queue = queue[i+1:]
+		break
+	}
+	delete(r.values, e.msg)
+	if len(queue) == 0 {
+		delete(e.set.partitions, e.partition)
+	} else {
+		e.set.partitions[e.partition] = queue
+	}
+	return evicted
+}
+
+// evict passes the given values to r.Evict, if set.
+func (r *Registry) evict(values []interface{}) {
+	if r.Evict == nil {
+		return
+	}
+	for _, v := range values {
+		r.Evict(v)
+	}
+}
+
+// Set holds the values of the messages of a consumer, in order of reception
+// within each partition.
+type Set struct {
+	registry   *Registry
+	closed     bool // guarded by registry.mu, as are partitions
+	partitions map[Partition][]*entry
+}
+
+// Store sets the value of msg, received from the given partition. The value of
+// the oldest message of the partition is evicted if the set holds too many.
+func (s *Set) Store(msg interface{}, p Partition, v interface{}) {
+	r := s.registry
+	r.mu.Lock()
+	if s.closed {
+		r.mu.Unlock()
+		return
+	}
+	if e, ok := r.values[msg]; ok && e.set == s {
+		e.value = v
+		r.mu.Unlock()
+		return
+	}
+	if r.values == nil {
+		r.values = make(map[interface{}]*entry)
+	}
+	e := &entry{set: s, msg: msg, partition: p, value: v}
+	r.values[msg] = e
+	queue := append(s.partitions[p], e)
+	var evicted []interface{}
+	if len(queue) > maxPartitionValues {
+		old := queue[0]
+		delete(r.values, old.msg)
+		evicted = append(evicted, old.value)
+		queue = queue[1:]
+	}
+	s.partitions[p] = queue
+	r.mu.Unlock()
+	r.evict(evicted)
+}
+
+// Take removes and returns the value of msg, evicting the values of the
+// messages received before it from its partition.
+func (s *Set) Take(msg interface{}) (interface{}, bool) {
+	r := s.registry
+	r.mu.Lock()
+	e, ok := r.values[msg]
+	ok = ok && e.set == s
+	var evicted []interface{}
+	if ok {
+		evicted = r.take(e)
+	}
+	r.mu.Unlock()
+	r.evict(evicted)
+	if !ok {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Close removes the values of s from its Registry and returns them. Values
+// stored afterwards are dropped.
+func (s *Set) Close() []interface{} {
+	r := s.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var values []interface{}
+	for _, queue := range s.partitions {
+		for _, e := range queue {
+			delete(r.values, e.msg)
+			values = append(values, e.value)
+		}
+	}
+	s.partitions = nil
+	s.closed = true
+	return values
+}
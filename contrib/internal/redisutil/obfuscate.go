@@ -0,0 +1,346 @@
+// Package redisutil provides helpers shared by the Redis integrations.
+package redisutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/jsonutil"
+)
+
+const (
+	// MaxRawCommandLength is the maximum length of the raw commands recorded on spans.
+	MaxRawCommandLength = 1000
+	// maxPipelineCommandNames is the maximum number of distinct command names
+	// in the resource name of a pipeline.
+	maxPipelineCommandNames = 10
+	// mask replaces obfuscated arguments.
+	mask = "?"
+)
+
+// obfuscateFunc masks the arguments following the name of a command.
+type obfuscateFunc func(args []string)
+
+// obfuscators holds the way the arguments of commands carrying values are
+// masked, when they are not masked by default (see RawCommand).
+var obfuscators = map[string]obfuscateFunc{
+	"AUTH":    maskFrom(0),
+	"HELLO":   maskHello,
+	"MIGRATE": maskMigrate,
+	"CONFIG":  maskConfig,
+	"ACL":     maskACL,
+	"PING":    maskFrom(0),
+	"ECHO":    maskFrom(0),
+	// commands of the form: CMD script|sha numkeys key... arg...
+	"EVAL":       maskEval,
+	"EVALSHA":    maskEval,
+	"EVAL_RO":    maskEval,
+	"EVALSHA_RO": maskEval,
+	"FCALL":      maskEval,
+	"FCALL_RO":   maskEval,
+	// RESTORE key ttl serialized-value [REPLACE] [ABSTTL] [IDLETIME seconds] [FREQ frequency]
+	"RESTORE": maskAt(2),
+	// commands of the form: CMD key value...
+	"APPEND":    maskFrom(1),
+	"GETSET":    maskFrom(1),
+	"SETNX":     maskFrom(1),
+	"LPUSH":     maskFrom(1),
+	"LPUSHX":    maskFrom(1),
+	"RPUSH":     maskFrom(1),
+	"RPUSHX":    maskFrom(1),
+	"LREM":      maskFrom(2),
+	"SADD":      maskFrom(1),
+	"SREM":      maskFrom(1),
+	"SISMEMBER": maskFrom(1),
+	"PFADD":     maskFrom(1),
+	"PUBLISH":   maskFrom(1),
+	// commands of the form: CMD key arg value
+	"SET":      maskAt(1),
+	"SETEX":    maskAt(2),
+	"PSETEX":   maskAt(2),
+	"SETRANGE": maskAt(2),
+	"LSET":     maskAt(2),
+	"LINSERT":  maskFrom(2),
+	// commands of the form: CMD [key] field value [field value...]
+	"MSET":   maskPairs(0),
+	"MSETNX": maskPairs(0),
+	"HSET":   maskPairs(1),
+	"HSETNX": maskPairs(1),
+	"HMSET":  maskPairs(1),
+	"ZADD":   maskZAdd,
+	"GEOADD": maskGeoAdd,
+	"XADD":   maskXAdd,
+}
+
+// plainCommands holds the commands whose arguments are only keys, fields,
+// counts, ranges or options, and are recorded as they are. It mostly holds
+// read-only commands, excluding the ones looking up members by value, such
+// as SISMEMBER or ZSCORE.
+var plainCommands = map[string]bool{
+	// keys
+	"DEL": true, "UNLINK": true, "EXISTS": true, "TYPE": true, "TTL": true,
+	"PTTL": true, "EXPIRE": true, "PEXPIRE": true, "EXPIREAT": true,
+	"PEXPIREAT": true, "EXPIRETIME": true, "PERSIST": true, "RENAME": true,
+	"RENAMENX": true, "TOUCH": true, "KEYS": true, "SCAN": true, "RANDOMKEY": true,
+	"DUMP": true, "OBJECT": true, "MOVE": true, "COPY": true,
+	// strings
+	"GET": true, "MGET": true, "GETDEL": true, "GETEX": true, "STRLEN": true,
+	"GETRANGE": true, "INCR": true, "DECR": true, "INCRBY": true, "DECRBY": true,
+	"INCRBYFLOAT": true, "GETBIT": true, "BITCOUNT": true, "BITPOS": true,
+	// hashes
+	"HGET": true, "HMGET": true, "HGETALL": true, "HKEYS": true, "HVALS": true,
+	"HLEN": true, "HEXISTS": true, "HSTRLEN": true, "HSCAN": true, "HDEL": true,
+	"HRANDFIELD": true,
+	// lists
+	"LLEN": true, "LRANGE": true, "LINDEX": true, "LPOP": true, "RPOP": true,
+	"BLPOP": true, "BRPOP": true, "LTRIM": true, "RPOPLPUSH": true, "LMOVE": true,
+	"BLMOVE": true, "BRPOPLPUSH": true,
+	// sets
+	"SMEMBERS": true, "SCARD": true, "SRANDMEMBER": true, "SPOP": true,
+	"SSCAN": true, "SINTER": true, "SUNION": true, "SDIFF": true,
+	"SINTERSTORE": true, "SUNIONSTORE": true, "SDIFFSTORE": true,
+	// sorted sets
+	"ZCARD": true, "ZRANGE": true, "ZREVRANGE": true, "ZRANGEBYSCORE": true,
+	"ZREVRANGEBYSCORE": true, "ZCOUNT": true, "ZSCAN": true, "ZPOPMIN": true,
+	"ZPOPMAX": true, "BZPOPMIN": true, "BZPOPMAX": true, "ZREMRANGEBYRANK": true,
+	"ZREMRANGEBYSCORE": true,
+	// hyperloglogs, streams and geospatial indexes
+	"PFCOUNT": true, "PFMERGE": true, "XLEN": true, "XRANGE": true,
+	"XREVRANGE": true, "XREAD": true, "XREADGROUP": true, "XINFO": true,
+	"XDEL": true, "XTRIM": true, "XACK": true, "XPENDING": true, "XGROUP": true,
+	// connection, transactions, pub/sub and server
+	"SELECT": true, "QUIT": true, "CLIENT": true, "MULTI": true, "EXEC": true,
+	"DISCARD": true, "WATCH": true, "UNWATCH": true, "SUBSCRIBE": true,
+	"UNSUBSCRIBE": true, "PSUBSCRIBE": true, "PUNSUBSCRIBE": true, "INFO": true,
+	"DBSIZE": true, "TIME": true, "FLUSHDB": true, "FLUSHALL": true,
+	"COMMAND": true, "SCRIPT": true, "SLOWLOG": true, "WAIT": true,
+}
+
+// maskFrom masks the arguments from index i on.
+func maskFrom(i int) obfuscateFunc {
+	return func(args []string) {
+		for j := i; j < len(args); j++ {
+			args[j] = mask
+		}
+	}
+}
+
+// maskAt masks the argument at index i.
+func maskAt(i int) obfuscateFunc {
+	return func(args []string) {
+		if i < len(args) {
+			args[i] = mask
+		}
+	}
+}
+
+// maskPairs masks the second element of the pairs following the first i arguments.
+func maskPairs(i int) obfuscateFunc {
+	return func(args []string) {
+		for j := i + 1; j < len(args); j += 2 {
+			args[j] = mask
+		}
+	}
+}
+
+// maskZAdd masks the members of ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member...
+func maskZAdd(args []string) {
+	i := 1
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX", "XX", "GT", "LT", "CH", "INCR":
+			continue
+		}
+		break
+	}
+	maskPairs(i)(args)
+}
+
+// maskGeoAdd masks the members of GEOADD key longitude latitude member...
+func maskGeoAdd(args []string) {
+	for i := 3; i < len(args); i += 3 {
+		args[i] = mask
+	}
+}
+
+// maskXAdd masks the values of XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~]
+// threshold [LIMIT count]] id field value...
+func maskXAdd(args []string) {
+	i := 1
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NOMKSTREAM":
+			continue
+		case "MAXLEN", "MINID":
+			if i+1 < len(args) && (args[i+1] == "=" || args[i+1] == "~") {
+				i++
+			}
+			i++ // the threshold
+			continue
+		case "LIMIT":
+			i++ // the count
+			continue
+		}
+		break
+	}
+	// args[i] is the id of the entry, followed by its fields and values.
+	maskPairs(i + 1)(args)
+}
+
+// maskEval masks the arguments following the keys of EVAL script numkeys
+// key... arg..., and of the commands of the same form.
+func maskEval(args []string) {
+	if len(args) < 2 {
+		return
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 {
+		maskFrom(1)(args)
+		return
+	}
+	maskFrom(2 + n)(args)
+}
+
+// maskHello masks the credentials of HELLO [protover [AUTH username password]
+// [SETNAME clientname]].
+func maskHello(args []string) {
+	for i := 0; i < len(args); i++ {
+		if strings.EqualFold(args[i], "AUTH") {
+			maskFrom(i + 1)(args[:min(i+3, len(args))])
+			return
+		}
+	}
+}
+
+// maskMigrate masks the credentials of MIGRATE host port key db timeout [COPY]
+// [REPLACE] [AUTH password | AUTH2 username password] [KEYS key...].
+func maskMigrate(args []string) {
+	for i := 5; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "AUTH":
+			maskFrom(i + 1)(args[:min(i+2, len(args))])
+			return
+		case "AUTH2":
+			maskFrom(i + 1)(args[:min(i+3, len(args))])
+			return
+		case "KEYS":
+			return
+		}
+	}
+}
+
+// maskConfig masks the passwords set by CONFIG SET parameter value...
+func maskConfig(args []string) {
+	if len(args) == 0 || !strings.EqualFold(args[0], "SET") {
+		return
+	}
+	for i := 1; i+1 < len(args); i += 2 {
+		switch strings.ToLower(args[i]) {
+		case "requirepass", "masterauth":
+			args[i+1] = mask
+		}
+	}
+}
+
+// maskACL masks the passwords and password hashes of ACL SETUSER username rule...
+func maskACL(args []string) {
+	if len(args) == 0 || !strings.EqualFold(args[0], "SETUSER") {
+		return
+	}
+	for i := 2; i < len(args); i++ {
+		if args[i] == "" {
+			continue
+		}
+		switch args[i][0] {
+		case '>', '<', '#', '!':
+			args[i] = mask
+		}
+	}
+}
+
+// RawCommand returns the raw command of the given command name and arguments,
+// in which the values carried by the command are masked (e.g. the value of SET)
+// while its name and keys are kept. Credentials, such as the arguments of AUTH
+// or the password of CONFIG SET requirepass, are always masked. The arguments
+// of the commands which are not known to carry only keys, counts or options
+// are masked after their first one, so that the values of unknown commands
+// are not recorded.
+// The result is truncated to MaxRawCommandLength, and the arguments which
+// would not fit in it are not formatted.
+func RawCommand(name string, args []interface{}) string {
+	// every argument takes at least one byte, so those past the limit are
+	// never recorded.
+	if len(args) > MaxRawCommandLength {
+		args = args[:MaxRawCommandLength]
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = formatArg(arg, MaxRawCommandLength)
+	}
+	cmd := strings.ToUpper(name)
+	if obfuscate, ok := obfuscators[cmd]; ok {
+		obfuscate(strs)
+	} else if !plainCommands[cmd] {
+		maskFrom(1)(strs)
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	for _, s := range strs {
+		if b.Len() > MaxRawCommandLength {
+			break
+		}
+		b.WriteString(" ")
+		b.WriteString(s)
+	}
+	return jsonutil.Truncate(b.String(), MaxRawCommandLength)
+}
+
+// formatArg returns the string representation of a command argument, cut to
+// n bytes when it is a string or a byte slice.
+func formatArg(arg interface{}, n int) string {
+	switch arg := arg.(type) {
+	case string:
+		if len(arg) > n {
+			return arg[:n]
+		}
+		return arg
+	case []byte:
+		if len(arg) > n {
+			arg = arg[:n]
+		}
+		return string(arg)
+	case int:
+		return strconv.Itoa(arg)
+	case int32:
+		return strconv.FormatInt(int64(arg), 10)
+	case int64:
+		return strconv.FormatInt(arg, 10)
+	case fmt.Stringer:
+		return arg.String()
+	default:
+		return fmt.Sprint(arg)
+	}
+}
+
+// PipelineResource returns the resource name of a pipeline of commands with
+// the given names: its distinct command names, in order, followed by the
+// number of commands, e.g. "get set (3 commands)".
+func PipelineResource(names []string) string {
+	var (
+		distinct []string
+		seen     = make(map[string]bool)
+	)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if len(distinct) == maxPipelineCommandNames {
+			distinct = append(distinct, "...")
+			break
+		}
+		distinct = append(distinct, name)
+	}
+	return fmt.Sprintf("%s (%d commands)", strings.Join(distinct, " "), len(names))
+}
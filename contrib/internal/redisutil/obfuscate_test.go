@@ -0,0 +1,92 @@
+package redisutil
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawCommand(t *testing.T) {
+	for _, tt := range []struct {
+		name, expected string
+		args           []interface{}
+	}{
+		{"GET", "GET key", []interface{}{"key"}},
+		{"set", "set key ? EX 10", []interface{}{"key", "secret", "EX", 10}},
+		{"AUTH", "AUTH ? ?", []interface{}{"user", "password"}},
+		{"auth", "auth ?", []interface{}{"password"}},
+		{"SETEX", "SETEX key 10 ?", []interface{}{"key", int64(10), []byte("secret")}},
+		{"HSET", "HSET key f1 ? f2 ?", []interface{}{"key", "f1", "v1", "f2", "v2"}},
+		{"MSET", "MSET k1 ? k2 ?", []interface{}{"k1", "v1", "k2", "v2"}},
+		{"SADD", "SADD key ? ?", []interface{}{"key", "a", "b"}},
+		{"ZADD", "ZADD key NX CH 1 ? 2 ?", []interface{}{"key", "NX", "CH", 1, "a", 2, "b"}},
+		{"GEOADD", "GEOADD key 13.3 38.1 ?", []interface{}{"key", 13.3, 38.1, "Palermo"}},
+		{"LINSERT", "LINSERT key BEFORE ? ?", []interface{}{"key", "BEFORE", "pivot", "value"}},
+		{"EXPIRE", "EXPIRE key 3600", []interface{}{"key", 3600}},
+		{"HELLO", "HELLO 3 AUTH ? ? SETNAME client", []interface{}{"3", "AUTH", "user", "password", "SETNAME", "client"}},
+		{"MIGRATE", "MIGRATE host 6379 key 0 5000 COPY AUTH ?", []interface{}{"host", 6379, "key", 0, 5000, "COPY", "AUTH", "password"}},
+		{"MIGRATE", "MIGRATE host 6379  0 5000 AUTH2 ? ? KEYS k1 k2", []interface{}{"host", 6379, "", 0, 5000, "AUTH2", "user", "password", "KEYS", "k1", "k2"}},
+		{"CONFIG", "CONFIG SET requirepass ? maxmemory 100mb", []interface{}{"SET", "requirepass", "password", "maxmemory", "100mb"}},
+		{"config", "config set MASTERAUTH ?", []interface{}{"set", "MASTERAUTH", "password"}},
+		{"CONFIG", "CONFIG GET requirepass", []interface{}{"GET", "requirepass"}},
+		{"ACL", "ACL SETUSER user on ? ~keys:* ? +get", []interface{}{"SETUSER", "user", "on", ">password", "~keys:*", "#5e884898da", "+get"}},
+		{"XADD", "XADD stream * f1 ? f2 ?", []interface{}{"stream", "*", "f1", "v1", "f2", "v2"}},
+		{"XADD", "XADD stream NOMKSTREAM MAXLEN ~ 1000 LIMIT 10 1-0 f1 ?", []interface{}{"stream", "NOMKSTREAM", "MAXLEN", "~", 1000, "LIMIT", 10, "1-0", "f1", "v1"}},
+		{"EVAL", "EVAL return 1 2 k1 k2 ? ?", []interface{}{"return 1", 2, "k1", "k2", "a1", "a2"}},
+		{"evalsha", "evalsha 6b1bf486 0 ?", []interface{}{"6b1bf486", 0, "a1"}},
+		{"EVALSHA", "EVALSHA 6b1bf486 ? ?", []interface{}{"6b1bf486", "n", "a1"}},
+		{"RESTORE", "RESTORE key 0 ? REPLACE", []interface{}{"key", 0, "\x00\x03bar", "REPLACE"}},
+		// unknown commands have their arguments masked after the first one
+		{"ZINCRBY", "ZINCRBY key ? ?", []interface{}{"key", 2, "member"}},
+		{"ZREM", "ZREM key ? ?", []interface{}{"key", "m1", "m2"}},
+		{"SMOVE", "SMOVE src ? ?", []interface{}{"src", "dst", "member"}},
+		{"LPOS", "LPOS key ? ? ?", []interface{}{"key", "element", "RANK", 2}},
+		{"SETBIT", "SETBIT key ? ?", []interface{}{"key", 7, 1}},
+		{"HINCRBY", "HINCRBY key ? ?", []interface{}{"key", "field", 5}},
+		{"ZSCORE", "ZSCORE key ?", []interface{}{"key", "member"}},
+		{"NOT_A_COMMAND", "NOT_A_COMMAND a ?", []interface{}{"a", "b"}},
+		// commands carrying no values are recorded as they are
+		{"HMGET", "HMGET key f1 f2", []interface{}{"key", "f1", "f2"}},
+		{"ZRANGE", "ZRANGE key 0 -1 WITHSCORES", []interface{}{"key", 0, -1, "WITHSCORES"}},
+		{"client", "client list", []interface{}{"list"}},
+		{"DEL", "DEL k1 k2", []interface{}{"k1", "k2"}},
+		{"PING", "PING ?", []interface{}{"hello"}},
+	} {
+		assert.Equal(t, tt.expected, RawCommand(tt.name, tt.args))
+	}
+}
+
+func TestRawCommandLength(t *testing.T) {
+	raw := RawCommand("DEL", []interface{}{strings.Repeat("k", 2*MaxRawCommandLength), "other"})
+	assert.Len(t, raw, MaxRawCommandLength)
+	assert.True(t, strings.HasPrefix(raw, "DEL kkk"))
+	assert.True(t, strings.HasSuffix(raw, "..."))
+
+	raw = RawCommand("SET", []interface{}{"key", make([]byte, 8<<20)})
+	assert.Equal(t, "SET key ?", raw)
+
+	raw = RawCommand("GET", []interface{}{strings.Repeat("é", MaxRawCommandLength)})
+	assert.True(t, len(raw) <= MaxRawCommandLength)
+	assert.True(t, utf8.ValidString(raw), "runes are not cut in half")
+
+	args := make([]interface{}, 1<<20)
+	for i := range args {
+		args[i] = "k"
+	}
+	raw = RawCommand("DEL", args)
+	assert.Len(t, raw, MaxRawCommandLength)
+	assert.True(t, strings.HasSuffix(raw, "..."))
+}
+
+func TestPipelineResource(t *testing.T) {
+	assert.Equal(t, "expire (1 commands)", PipelineResource([]string{"expire"}))
+	assert.Equal(t, "get set (3 commands)", PipelineResource([]string{"get", "set", "get"}))
+
+	var names []string
+	for _, c := range "abcdefghijkl" {
+		names = append(names, string(c))
+	}
+	assert.Equal(t, "a b c d e f g h i j ... (12 commands)", PipelineResource(names))
+}
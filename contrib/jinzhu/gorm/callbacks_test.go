@@ -0,0 +1,121 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+
+	sqltrace "github.com/adityayuga/signalfx-go-tracing/contrib/database/sql"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"github.com/jinzhu/gorm"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type product struct {
+	gorm.Model
+	Code string
+}
+
+func TestCallbacks(t *testing.T) {
+	sqltrace.Register("sqlite3", &sqlite3.SQLiteDriver{})
+	assert := assert.New(t)
+
+	db, err := Open("sqlite3", ":memory:", WithServiceName("my-gorm"), WithoutRecordNotFoundErrors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.DB().SetMaxOpenConns(1)
+	assert.NoError(db.AutoMigrate(&product{}).Error)
+
+	// only the spans of the callbacks are of interest
+	spans := func(mt mocktracer.Tracer) []mocktracer.Span {
+		var gormSpans []mocktracer.Span
+		for _, s := range mt.FinishedSpans() {
+			if s.Tag(ext.ServiceName) == "my-gorm" {
+				gormSpans = append(gormSpans, s)
+			}
+		}
+		return gormSpans
+	}
+
+	t.Run("create", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		parent, ctx := tracer.StartSpanFromContext(context.Background(), "parent")
+		assert.NoError(WithContext(ctx, db).Create(&product{Code: "L1212"}).Error)
+		parent.Finish()
+
+		spans := spans(mt)
+		assert.Len(spans, 1)
+		s := spans[0]
+		assert.Equal("gorm.create", s.OperationName())
+		assert.Equal(parent.(mocktracer.Span).SpanID(), s.ParentID())
+		assert.Equal("products", s.Tag(tagTable))
+		assert.Equal("create", s.Tag(tagOperation))
+		assert.Equal(int64(1), s.Tag(tagRowsAffected))
+		assert.Equal(ext.SpanTypeSQL, s.Tag(ext.SpanType))
+		assert.Contains(s.Tag(ext.ResourceName), `INSERT INTO "products"`)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		var p product
+		assert.True(db.Where("code = ?", "missing").First(&p).RecordNotFound())
+
+		spans := spans(mt)
+		assert.Len(spans, 1)
+		s := spans[0]
+		assert.Equal("gorm.query", s.OperationName())
+		assert.Equal("products", s.Tag(tagTable))
+		assert.Equal(int64(0), s.Tag(tagRowsAffected))
+		assert.Nil(s.Tag(ext.Error))
+	})
+
+	t.Run("update-delete", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assert.NoError(db.Model(&product{}).Where("code = ?", "L1212").Update("code", "L1213").Error)
+		assert.NoError(db.Where("code = ?", "L1213").Delete(&product{}).Error)
+
+		spans := spans(mt)
+		assert.Len(spans, 2)
+		assert.Equal("gorm.update", spans[0].OperationName())
+		assert.Equal(int64(1), spans[0].Tag(tagRowsAffected))
+		assert.Equal("gorm.delete", spans[1].OperationName())
+		assert.Equal(int64(1), spans[1].Tag(tagRowsAffected))
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		rows, err := db.Raw("SELECT code FROM products WHERE 1 = ?", 0).Rows()
+		assert.NoError(err)
+		rows.Close()
+
+		spans := spans(mt)
+		assert.Len(spans, 1)
+		assert.Equal("gorm.row_query", spans[0].OperationName())
+		assert.Equal("SELECT code FROM products WHERE 1 = ?", spans[0].Tag(ext.ResourceName))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+
+		assert.Error(db.Table("missing").Create(&product{}).Error)
+
+		spans := spans(mt)
+		assert.Len(spans, 1)
+		assert.NotNil(spans[0].Tag(ext.Error))
+		assert.Equal("missing", spans[0].Tag(tagTable))
+	})
+}
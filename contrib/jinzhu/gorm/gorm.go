@@ -2,17 +2,132 @@
 package gorm
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	sqltraced "github.com/adityayuga/signalfx-go-tracing/contrib/database/sql"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 
 	"github.com/jinzhu/gorm"
 )
 
+const (
+	gormContextKey   = "signalfx-go-tracing:context"
+	gormConfigKey    = "signalfx-go-tracing:config"
+	gormStartTimeKey = "signalfx-go-tracing:start"
+)
+
+const (
+	// tagTable is the table of the model of the operation.
+	tagTable = "gorm.table"
+	// tagOperation is the operation performed by gorm, e.g. "create".
+	tagOperation = "gorm.operation"
+	// tagRowsAffected is the number of rows affected by the operation.
+	tagRowsAffected = "gorm.rows_affected"
+)
+
 // Open opens a new (traced) database connection. The used dialect must be formerly registered
-// using (github.com/adityayuga/signalfx-go-tracing/contrib/database/sql).Register.
-func Open(dialect, source string) (*gorm.DB, error) {
-	db, err := sqltraced.Open(dialect, source)
+// using (github.com/adityayuga/signalfx-go-tracing/contrib/database/sql).Register. The operations
+// of the returned DB are traced using WithCallbacks.
+func Open(dialect, source string, opts ...Option) (*gorm.DB, error) {
+	sqldb, err := sqltraced.Open(dialect, source)
 	if err != nil {
 		return nil, err
 	}
-	return gorm.Open(dialect, db)
+	db, err := gorm.Open(dialect, sqldb)
+	if err != nil {
+		return db, err
+	}
+	return WithCallbacks(db, opts...), nil
+}
+
+// WithCallbacks registers callbacks tracing the create, query, update, delete and
+// row (including raw) query operations of db, and returns db configured with
+// the given options. Spans are children of the span found in the context given
+// to WithContext.
+func WithCallbacks(db *gorm.DB, opts ...Option) *gorm.DB {
+	cfg := new(config)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	cb := db.Callback()
+	cb.Create().Before("gorm:begin_transaction").Register("signalfx-go-tracing:before_create", before)
+	cb.Create().After("gorm:commit_or_rollback_transaction").Register("signalfx-go-tracing:after_create", after("create"))
+	cb.Query().Before("gorm:query").Register("signalfx-go-tracing:before_query", before)
+	cb.Query().After("gorm:after_query").Register("signalfx-go-tracing:after_query", after("query"))
+	cb.Update().Before("gorm:begin_transaction").Register("signalfx-go-tracing:before_update", before)
+	cb.Update().After("gorm:commit_or_rollback_transaction").Register("signalfx-go-tracing:after_update", after("update"))
+	cb.Delete().Before("gorm:begin_transaction").Register("signalfx-go-tracing:before_delete", before)
+	cb.Delete().After("gorm:commit_or_rollback_transaction").Register("signalfx-go-tracing:after_delete", after("delete"))
+	cb.RowQuery().Before("gorm:row_query").Register("signalfx-go-tracing:before_row_query", before)
+	cb.RowQuery().After("gorm:row_query").Register("signalfx-go-tracing:after_row_query", after("row_query"))
+	return db.Set(gormConfigKey, cfg)
+}
+
+// WithContext returns a copy of db, set using db.Set, whose operations are
+// traced as children of the span found in ctx.
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil {
+		return db
+	}
+	return db.Set(gormContextKey, ctx)
+}
+
+// contextFromDB returns the context set on db using WithContext, if any.
+func contextFromDB(db *gorm.DB) context.Context {
+	if v, ok := db.Get(gormContextKey); ok {
+		if ctx, ok := v.(context.Context); ok {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+func before(scope *gorm.Scope) {
+	scope.Set(gormStartTimeKey, time.Now())
+}
+
+func after(operation string) func(*gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(gormConfigKey)
+		if !ok {
+			return
+		}
+		cfg := v.(*config)
+		v, ok = scope.Get(gormStartTimeKey)
+		if !ok {
+			return
+		}
+		start := v.(time.Time)
+		query := strings.TrimSpace(scope.SQL)
+		opts := []ddtrace.StartSpanOption{
+			tracer.StartTime(start),
+			tracer.ServiceName(cfg.serviceName),
+			tracer.SpanType(ext.SpanTypeSQL),
+			tracer.ResourceName(query),
+			tracer.Tag(tagOperation, operation),
+			tracer.Tag(tagRowsAffected, scope.DB().RowsAffected),
+		}
+		if scope.Value != nil {
+			if table := scope.TableName(); table != "" {
+				opts = append(opts, tracer.Tag(tagTable, table))
+			}
+		}
+		if query != "" {
+			opts = append(opts, tracer.Tag(ext.DBStatement, query))
+		}
+		if cfg.analyticsRate > 0 {
+			opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+		}
+		span, _ := tracer.StartSpanFromContext(contextFromDB(scope.DB()), "gorm."+operation, opts...)
+		err := scope.DB().Error
+		if cfg.ignoreRecordNotFound && gorm.IsRecordNotFoundError(err) {
+			err = nil
+		}
+		span.FinishWithOptionsExt(tracer.WithError(err))
+	}
 }
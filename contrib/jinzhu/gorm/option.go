@@ -0,0 +1,46 @@
+package gorm
+
+type config struct {
+	serviceName          string
+	analyticsRate        float64
+	ignoreRecordNotFound bool
+}
+
+// Option represents an option that can be passed to Open or WithCallbacks.
+type Option func(*config)
+
+func defaults(cfg *config) {
+	cfg.serviceName = "gorm.db"
+	// cfg.analyticsRate = globalconfig.AnalyticsRate()
+}
+
+// WithServiceName sets the given service name for the spans of the callbacks.
+func WithServiceName(name string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = name
+	}
+}
+
+// WithoutRecordNotFoundErrors prevents gorm.ErrRecordNotFound from being
+// recorded as an error on spans, as it only means that a query found no record.
+func WithoutRecordNotFoundErrors() Option {
+	return func(cfg *config) {
+		cfg.ignoreRecordNotFound = true
+	}
+}
+
+// WithAnalytics enables Trace Analytics for all started spans.
+func WithAnalytics(on bool) Option {
+	if on {
+		return WithAnalyticsRate(1.0)
+	}
+	return WithAnalyticsRate(0.0)
+}
+
+// WithAnalyticsRate sets the sampling rate for Trace Analytics events
+// correlated to started spans.
+func WithAnalyticsRate(rate float64) Option {
+	return func(cfg *config) {
+		cfg.analyticsRate = rate
+	}
+}
@@ -44,12 +44,12 @@ func TestMySQL(t *testing.T) {
 		TableName:  tableName,
 		ExpectName: "Query",
 		ExpectTags: map[string]interface{}{
-			ext.ServiceName: "mysql-test",
-			ext.SpanType:    ext.SpanTypeSQL,
-			ext.TargetHost:  "127.0.0.1",
-			ext.TargetPort:  "3306",
-			ext.DBUser:      "test",
-			ext.DBInstance:  "test",
+			ext.ServiceName:  "mysql-test",
+			ext.SpanType:     ext.SpanTypeSQL,
+			ext.PeerHostname: "127.0.0.1",
+			ext.PeerPort:     "3306",
+			ext.DBUser:       "test",
+			ext.DBInstance:   "test",
 		},
 	}
 	sqltest.RunAll(t, testConfig)
@@ -72,12 +72,12 @@ func TestPostgres(t *testing.T) {
 		TableName:  tableName,
 		ExpectName: "Query",
 		ExpectTags: map[string]interface{}{
-			ext.ServiceName: "postgres.db",
-			ext.SpanType:    ext.SpanTypeSQL,
-			ext.TargetHost:  "127.0.0.1",
-			ext.TargetPort:  "5432",
-			ext.DBUser:      "postgres",
-			ext.DBInstance:  "postgres",
+			ext.ServiceName:  "postgres.db",
+			ext.SpanType:     ext.SpanTypeSQL,
+			ext.PeerHostname: "127.0.0.1",
+			ext.PeerPort:     "5432",
+			ext.DBUser:       "postgres",
+			ext.DBInstance:   "postgres",
 		},
 	}
 	sqltest.RunAll(t, testConfig)
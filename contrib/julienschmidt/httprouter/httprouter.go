@@ -40,5 +40,11 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		route = strings.Replace(route, param.Value, ":"+param.Key, 1)
 	}
 	resource := req.Method + " " + route
-	httputil.TraceAndServe(r.Router, w, req, r.config.serviceName, resource, r.config.spanOpts...)
+	httputil.TraceAndServeWithConfig(r.Router, w, req, &httputil.TraceConfig{
+		Service:       r.config.serviceName,
+		Resource:      resource,
+		SpanOpts:      r.config.spanOpts,
+		RecoverPanics: r.config.recoverPanics,
+		Ignore:        &r.config.ignore,
+	})
 }
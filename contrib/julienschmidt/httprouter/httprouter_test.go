@@ -68,6 +68,34 @@ func TestHttpTracer500(t *testing.T) {
 	assert.Equal("500: Internal Server Error", s.Tag(ext.Error).(error).Error())
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := New(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	)
+	router.GET("/", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+	router.GET("/healthz", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...RouterOption) {
 		router := New(opts...)
@@ -2,8 +2,10 @@
 package echo
 
 import (
+	"net/http"
 	"strconv"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -20,8 +22,12 @@ func Middleware(opts ...Option) echo.MiddlewareFunc {
 		for _, fn := range opts {
 			fn(cfg)
 		}
-		return func(c echo.Context) error {
+		return func(c echo.Context) (err error) {
 			request := c.Request()
+			if cfg.ignore.Ignore(request) {
+				c.SetRequest(httputil.PropagateContext(request))
+				return next(c)
+			}
 			operationName := c.Path()
 			opts := []ddtrace.StartSpanOption{
 				tracer.ServiceName(cfg.serviceName),
@@ -37,12 +43,29 @@ func Middleware(opts ...Option) echo.MiddlewareFunc {
 			}
 			span, ctx := tracer.StartSpanFromContext(request.Context(), operationName, opts...)
 			defer span.Finish()
+			defer func() {
+				if p := recover(); p != nil {
+					if cfg.recoverPanics {
+						// let the echo error handler answer the request
+						c.Error(&httputil.PanicError{Value: p})
+					}
+					if c.Response().Committed {
+						span.SetTag(ext.HTTPCode, strconv.Itoa(c.Response().Status))
+					} else {
+						span.SetTag(ext.HTTPCode, strconv.Itoa(http.StatusInternalServerError))
+					}
+					httputil.TagPanic(span, p)
+					if !cfg.recoverPanics {
+						panic(p)
+					}
+				}
+			}()
 
 			// pass the span through the request context
 			c.SetRequest(request.WithContext(ctx))
 
 			// serve the request to the next middleware
-			err := next(c)
+			err = next(c)
 
 			span.SetTag(ext.HTTPCode, strconv.Itoa(c.Response().Status))
 
@@ -124,6 +124,36 @@ func TestError(t *testing.T) {
 	assert.Equal(wantErr.Error(), span.Tag(ext.Error).(error).Error())
 }
 
+func TestPanic(t *testing.T) {
+	for name, recoverPanics := range map[string]bool{"propagated": false, "recovered": true} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mt := mocktracer.Start()
+			defer mt.Stop()
+
+			router := echo.New()
+			router.Use(Middleware(WithServiceName("foobar"), WithPanicRecovery(recoverPanics)))
+			router.GET("/panic", func(c echo.Context) error {
+				panic("oh no")
+			})
+			r := httptest.NewRequest("GET", "/panic", nil)
+			w := httptest.NewRecorder()
+			if recoverPanics {
+				router.ServeHTTP(w, r)
+				assert.Equal(500, w.Code)
+			} else {
+				assert.PanicsWithValue("oh no", func() { router.ServeHTTP(w, r) })
+			}
+
+			spans := mt.FinishedSpans()
+			assert.Len(spans, 1)
+			span := spans[0]
+			assert.Equal("500", span.Tag(ext.HTTPCode))
+			assert.Equal("panic: oh no", span.Tag(ext.Error).(error).Error())
+		})
+	}
+}
+
 func TestGetSpanNotInstrumented(t *testing.T) {
 	assert := assert.New(t)
 	router := echo.New()
@@ -144,6 +174,35 @@ func TestGetSpanNotInstrumented(t *testing.T) {
 	assert.False(traced)
 }
 
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	router := echo.New()
+	router.Use(Middleware(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.URL.Query().Get("probe") != "" }),
+	))
+	router.GET("/", func(c echo.Context) error { return c.NoContent(200) })
+	router.GET("/healthz", func(c echo.Context) error { return c.NoContent(200) })
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/?probe=1", nil),
+		probe,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestEchoTracer200Zipkin(t *testing.T) {
 	zipkin := zipkinserver.Start()
 	defer zipkin.Stop()
@@ -1,7 +1,15 @@
 package echo
 
+import (
+	"net/http"
+
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/httputil"
+)
+
 type config struct {
-	serviceName string
+	serviceName   string
+	recoverPanics bool
+	ignore        httputil.IgnoreRules
 }
 
 // Option represents an option that can be passed to Middleware.
@@ -9,6 +17,7 @@ type Option func(*config)
 
 func defaults(cfg *config) {
 	cfg.serviceName = "echo"
+	cfg.ignore = httputil.DefaultIgnoreRules()
 }
 
 // WithServiceName sets the given service name for the system.
@@ -17,3 +26,42 @@ func WithServiceName(name string) Option {
 		cfg.serviceName = name
 	}
 }
+
+// WithIgnoreRequest serves the requests for which fn returns true without tracing
+// them. The span context propagated in the headers of an ignored request is
+// still passed on to the handler through the request context, so that the spans
+// it starts continue the trace of the caller.
+func WithIgnoreRequest(fn func(*http.Request) bool) Option {
+	return func(cfg *config) {
+		cfg.ignore.Funcs = append(cfg.ignore.Funcs, fn)
+	}
+}
+
+// WithIgnorePaths serves the requests whose path matches any of the given glob
+// patterns without tracing them, as with WithIgnoreRequest. In patterns, '*'
+// matches any sequence of characters, including '/', and '?' matches any single
+// character. The patterns add to those set by tracing.WithHTTPIgnorePaths.
+func WithIgnorePaths(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.ignore.Paths = append(cfg.ignore.Paths, globs...)
+	}
+}
+
+// WithIgnoreUserAgents serves the requests whose User-Agent header matches any of
+// the given glob patterns without tracing them, as with WithIgnoreRequest. The
+// patterns follow the syntax of WithIgnorePaths and add to those set by
+// tracing.WithHTTPIgnoreUserAgents.
+func WithIgnoreUserAgents(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.ignore.UserAgents = append(cfg.ignore.UserAgents, globs...)
+	}
+}
+
+// WithPanicRecovery sets whether panics raised by handlers are recovered and
+// answered with a 500 status. By default, the panic is recorded on the span
+// and propagated to the caller.
+func WithPanicRecovery(on bool) Option {
+	return func(cfg *config) {
+		cfg.recoverPanics = on
+	}
+}
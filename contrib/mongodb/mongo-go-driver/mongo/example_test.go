@@ -32,3 +32,16 @@ func Example() {
 		}},
 	})
 }
+
+func Example_poolMonitor() {
+	// trace failed connection checkouts and tag commands with the pool state
+	pm := mongotrace.NewPoolMonitor()
+	opts := options.Client()
+	opts.SetMonitor(mongotrace.NewMonitor(mongotrace.WithPoolMonitor(pm)))
+	opts.SetPoolMonitor(pm.PoolMonitor)
+	client, err := mongo.Connect(context.Background(), opts.ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		panic(err)
+	}
+	client.Database("example").Collection("inventory").FindOne(context.Background(), bson.D{{Key: "item", Value: "canvas"}})
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/jsonutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -17,6 +18,9 @@ import (
 	"go.mongodb.org/mongo-driver/event"
 )
 
+// tagCollection is the tag holding the collection a command applies to.
+const tagCollection = "db.mongodb.collection"
+
 type spanKey struct {
 	ConnectionID string
 	RequestID    int64
@@ -30,21 +34,26 @@ type monitor struct {
 
 func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 	hostname, port := peerInfo(evt)
-	b, _ := bson.MarshalExtJSON(evt.Command, false, false)
 	opts := []ddtrace.StartSpanOption{
 		tracer.ServiceName(m.cfg.serviceName),
 		tracer.ResourceName("mongo." + evt.CommandName),
 		tracer.SpanType(ext.SpanTypeMongoDB),
 		tracer.Tag(ext.DBInstance, evt.DatabaseName),
-		tracer.Tag(ext.DBStatement, string(b)),
+		tracer.Tag(ext.DBStatement, m.statement(evt.Command)),
 		tracer.Tag(ext.DBType, "mongo"),
 		tracer.Tag(ext.PeerHostname, hostname),
 		tracer.Tag(ext.PeerPort, port),
 	}
+	if coll := collection(evt.CommandName, evt.Command); coll != "" {
+		opts = append(opts, tracer.Tag(tagCollection, coll))
+	}
 	if m.cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, m.cfg.analyticsRate))
 	}
 	span, _ := tracer.StartSpanFromContext(ctx, "mongo."+evt.CommandName, opts...)
+	if m.cfg.pool != nil {
+		m.cfg.pool.setTags(span, address(evt.ConnectionID))
+	}
 	key := spanKey{
 		ConnectionID: evt.ConnectionID,
 		RequestID:    evt.RequestID,
@@ -54,6 +63,37 @@ func (m *monitor) Started(ctx context.Context, evt *event.CommandStartedEvent) {
 	m.Unlock()
 }
 
+// collection returns the collection which the given command applies to, if
+// any. Most commands hold it as their value, while getMore and killCursors
+// commands may hold a cursor ID instead, along with a collection field.
+func collection(name string, cmd bson.Raw) string {
+	if coll, ok := cmd.Lookup(name).StringValueOK(); ok {
+		return coll
+	}
+	switch name {
+	case "getMore", "killCursors":
+		coll, _ := cmd.Lookup("collection").StringValueOK()
+		return coll
+	}
+	return ""
+}
+
+// statement returns the statement recorded for the given command, obfuscated
+// and truncated according to the configuration.
+func (m *monitor) statement(cmd bson.Raw) string {
+	b, err := bson.MarshalExtJSON(cmd, false, false)
+	if err != nil {
+		return ""
+	}
+	stmt := string(b)
+	if m.cfg.obfuscate {
+		if stmt, err = jsonutil.Obfuscate(b); err != nil {
+			return "?"
+		}
+	}
+	return jsonutil.Truncate(stmt, m.cfg.maxStatementLength)
+}
+
 func (m *monitor) Succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
 	m.Finished(&evt.CommandFinishedEvent, nil)
 }
@@ -97,12 +137,18 @@ func NewMonitor(opts ...Option) *event.CommandMonitor {
 	}
 }
 
+// address returns the address of the server of the given connection ID, which
+// has the form "host:port[-N]".
+func address(connectionID string) string {
+	if idx := strings.IndexByte(connectionID, '['); idx >= 0 {
+		return connectionID[:idx]
+	}
+	return connectionID
+}
+
 func peerInfo(evt *event.CommandStartedEvent) (hostname, port string) {
-	hostname = evt.ConnectionID
+	hostname = address(evt.ConnectionID)
 	port = "27017"
-	if idx := strings.IndexByte(hostname, '['); idx >= 0 {
-		hostname = hostname[:idx]
-	}
 	if idx := strings.IndexByte(hostname, ':'); idx >= 0 {
 		port = hostname[idx+1:]
 		hostname = hostname[:idx]
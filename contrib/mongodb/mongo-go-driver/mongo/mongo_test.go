@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
@@ -17,10 +18,11 @@ import (
 	"github.com/adityayuga/signalfx-go-tracing/zipkinserver"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/x/network/result"
-	"go.mongodb.org/mongo-driver/x/network/wiremessage"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/wiremessage"
 )
 
 func Test(t *testing.T) {
@@ -63,7 +65,9 @@ func Test(t *testing.T) {
 	assert.Equal(t, "mongo.insert", s.Tag(ext.ResourceName))
 	assert.Equal(t, hostname, s.Tag(ext.PeerHostname))
 	assert.Equal(t, port, s.Tag(ext.PeerPort))
-	assert.Contains(t, s.Tag(ext.DBStatement), `"test-item":"test-value"`)
+	assert.Contains(t, s.Tag(ext.DBStatement), `"test-item":"?"`)
+	assert.NotContains(t, s.Tag(ext.DBStatement), "test-value")
+	assert.Equal(t, "test-collection", s.Tag(tagCollection))
 	assert.Equal(t, "test-database", s.Tag(ext.DBInstance))
 	assert.Equal(t, "mongo", s.Tag(ext.DBType))
 }
@@ -118,12 +122,136 @@ func TestWithZipkin(t *testing.T) {
 		assert.Equal("test-database", span.Tags[ext.DBInstance])
 		assert.Equal("mongo", span.Tags[ext.DBType])
 		assert.Equal(strings.ToLower(ext.SpanKindClient), span.Tags[ext.SpanKind])
-		assert.Contains(span.Tags[ext.DBStatement], `"test-item":"test-value"`)
+		assert.Contains(span.Tags[ext.DBStatement], `"test-item":"?"`)
 
 		assert.Len(span.Annotations, 0)
 	})
 }
 
+func TestStatement(t *testing.T) {
+	cmd, err := bson.Marshal(bson.D{
+		{Key: "insert", Value: "test-collection"},
+		{Key: "documents", Value: bson.A{bson.D{{Key: "name", Value: "alice"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		opts []Option
+		want string
+	}{
+		{nil, `{"insert":"?","documents":[{"name":"?"}]}`},
+		{[]Option{WithStatementObfuscation(false)}, `{"insert":"test-collection","documents":[{"name":"alice"}]}`},
+		{[]Option{WithMaxStatementLength(20)}, `{"insert":"?","do...`},
+		{[]Option{WithStatementObfuscation(false), WithMaxStatementLength(0)}, `{"insert":"test-collection","documents":[{"name":"alice"}]}`},
+	} {
+		cfg := new(config)
+		defaults(cfg)
+		for _, opt := range tt.opts {
+			opt(cfg)
+		}
+		m := &monitor{cfg: cfg}
+		assert.Equal(t, tt.want, m.statement(cmd))
+	}
+}
+
+func TestCollection(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cmd  bson.D
+		want string
+	}{
+		{"find", bson.D{{Key: "find", Value: "test-collection"}}, "test-collection"},
+		{"getMore", bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "test-collection"}}, "test-collection"},
+		{"killCursors", bson.D{{Key: "killCursors", Value: "test-collection"}, {Key: "cursors", Value: bson.A{int64(42)}}}, "test-collection"},
+		{"killCursors", bson.D{{Key: "killCursors", Value: int64(42)}, {Key: "collection", Value: "test-collection"}}, "test-collection"},
+		{"ping", bson.D{{Key: "ping", Value: 1}}, ""},
+		{"insert", bson.D{{Key: "insert", Value: int64(42)}, {Key: "collection", Value: "other"}}, ""},
+	} {
+		cmd, err := bson.Marshal(tt.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.want, collection(tt.name, cmd), "%v", tt.cmd)
+	}
+}
+
+func TestPoolMonitor(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	const addr = "127.0.0.1:27017"
+	pm := NewPoolMonitor()
+	pm.Event(&event.PoolEvent{Type: event.PoolCreated, Address: addr, PoolOptions: &event.MonitorPoolOptions{MaxPoolSize: 1}})
+	pm.Event(&event.PoolEvent{Type: event.GetStarted, Address: addr})
+	pm.Event(&event.PoolEvent{Type: event.GetSucceeded, Address: addr, ConnectionID: 1, Duration: time.Millisecond})
+	pm.Event(&event.PoolEvent{Type: event.GetStarted, Address: addr})
+	pm.Event(&event.PoolEvent{Type: event.GetFailed, Address: addr, Reason: event.ReasonTimedOut, Duration: 20 * time.Millisecond})
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "mongo.checkout", s.OperationName())
+	assert.Equal(t, "127.0.0.1", s.Tag(ext.PeerHostname))
+	assert.Equal(t, "27017", s.Tag(ext.PeerPort))
+	assert.Equal(t, true, s.Tag(tagPoolExhausted))
+	assert.Equal(t, event.ReasonTimedOut, s.Tag(tagPoolFailureReason))
+	assert.Equal(t, uint64(1), s.Tag(tagPoolMaxSize))
+	assert.Equal(t, 1, s.Tag(tagPoolInUse))
+	assert.Equal(t, 20.0, s.Tag(tagPoolCheckoutWait))
+	assert.True(t, s.FinishTime().Sub(s.StartTime()) >= 20*time.Millisecond, "the span covers the wait")
+	assert.NotNil(t, s.Tag(ext.Error))
+
+	span := tracer.StartSpan("command")
+	pm.setTags(span, addr)
+	span.Finish()
+	s = mt.FinishedSpans()[1]
+	assert.Equal(t, 1, s.Tag(tagPoolInUse))
+	assert.Equal(t, uint64(1), s.Tag(tagPoolMaxSize))
+	assert.Nil(t, s.Tag(tagPoolCheckoutWait), "checkout waits are not tied to commands")
+
+	pm.Event(&event.PoolEvent{Type: event.ConnectionReturned, Address: addr, ConnectionID: 1})
+	pm.Event(&event.PoolEvent{Type: event.PoolClosedEvent, Address: addr})
+	span = tracer.StartSpan("command")
+	pm.setTags(span, addr)
+	span.Finish()
+	assert.Nil(t, mt.FinishedSpans()[2].Tag(tagPoolInUse))
+}
+
+func TestPoolMonitorClient(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	li, err := mockMongo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	pm := NewPoolMonitor()
+	opts := options.Client().
+		SetMonitor(NewMonitor(WithPoolMonitor(pm))).
+		SetPoolMonitor(pm.PoolMonitor).
+		SetMaxPoolSize(5).
+		ApplyURI(fmt.Sprintf("mongodb://%s", li.Addr().String()))
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(ctx)
+
+	_, err = client.Database("test-database").Collection("test-collection").
+		InsertOne(ctx, bson.D{{Key: "test-item", Value: "test-value"}})
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, uint64(5), spans[0].Tag(tagPoolMaxSize))
+	assert.Equal(t, 1, spans[0].Tag(tagPoolInUse))
+}
+
 // mockMongo implements a crude mongodb server that responds with
 // expected replies so that we can confirm tracing works properly
 func mockMongo() (net.Listener, error) {
@@ -132,12 +260,20 @@ func mockMongo() (net.Listener, error) {
 		return li, err
 	}
 
+	hello, _ := bson.Marshal(bson.D{
+		{Key: "ismaster", Value: true},
+		{Key: "ok", Value: 1},
+		{Key: "maxBsonObjectSize", Value: 16777216},
+		{Key: "maxMessageSizeBytes", Value: 48000000},
+		{Key: "maxWriteBatchSize", Value: 100000},
+		{Key: "logicalSessionTimeoutMinutes", Value: 30},
+		{Key: "readOnly", Value: false},
+		{Key: "minWireVersion", Value: 0},
+		{Key: "maxWireVersion", Value: 7},
+	})
+	ack, _ := bson.Marshal(bson.D{{Key: "n", Value: 1}, {Key: "ok", Value: 1}})
+
 	go func() {
-		var requestID int32
-		nextRequestID := func() int32 {
-			requestID++
-			return requestID
-		}
 		defer li.Close()
 		for {
 			conn, err := li.Accept()
@@ -149,92 +285,58 @@ func mockMongo() (net.Listener, error) {
 
 				for {
 					var hdrbuf [16]byte
-					_, err := io.ReadFull(conn, hdrbuf[:])
-					if err != nil {
-						panic(err)
-					}
-
-					hdr, err := wiremessage.ReadHeader(hdrbuf[:], 0)
-					if err != nil {
-						panic(err)
+					if _, err := io.ReadFull(conn, hdrbuf[:]); err != nil {
+						// the client disconnected
+						return
 					}
-
-					msgbuf := make([]byte, hdr.MessageLength)
+					length := int32(binary.LittleEndian.Uint32(hdrbuf[:4]))
+					msgbuf := make([]byte, length)
 					copy(msgbuf, hdrbuf[:])
-					_, err = io.ReadFull(conn, msgbuf[16:])
-					if err != nil {
-						panic(err)
+					if _, err := io.ReadFull(conn, msgbuf[16:]); err != nil {
+						return
+					}
+					_, requestID, _, opcode, rem, ok := wiremessage.ReadHeader(msgbuf)
+					if !ok {
+						panic("invalid message header")
 					}
 
-					switch hdr.OpCode {
+					var reply []byte
+					switch opcode {
 					case wiremessage.OpQuery:
-						var msg wiremessage.Query
-						err = msg.UnmarshalWireMessage(msgbuf)
-						if err != nil {
-							panic(err)
-						}
-
-						bs, _ := bson.Marshal(result.IsMaster{
-							IsMaster:                     true,
-							OK:                           1,
-							MaxBSONObjectSize:            16777216,
-							MaxMessageSizeBytes:          48000000,
-							MaxWriteBatchSize:            100000,
-							LogicalSessionTimeoutMinutes: 30,
-							ReadOnly:                     false,
-							MinWireVersion:               0,
-							MaxWireVersion:               7,
-						})
-
-						reply := wiremessage.Reply{
-							MsgHeader: wiremessage.Header{
-								RequestID:  nextRequestID(),
-								ResponseTo: hdr.RequestID,
-							},
-							ResponseFlags:  wiremessage.AwaitCapable,
-							NumberReturned: 1,
-							Documents:      []bson.Raw{bs},
-						}
-						bs, err = reply.MarshalWireMessage()
-						if err != nil {
-							panic(err)
-						}
-
-						_, err = conn.Write(bs)
-						if err != nil {
-							panic(err)
-						}
+						// the legacy handshake
+						idx, b := wiremessage.AppendHeaderStart(nil, wiremessage.NextRequestID(), requestID, wiremessage.OpReply)
+						b = wiremessage.AppendReplyFlags(b, wiremessage.AwaitCapable)
+						b = wiremessage.AppendReplyCursorID(b, 0)
+						b = wiremessage.AppendReplyStartingFrom(b, 0)
+						b = wiremessage.AppendReplyNumberReturned(b, 1)
+						b = append(b, hello...)
+						reply = bsoncore.UpdateLength(b, idx, int32(len(b[idx:])))
 
 					case wiremessage.OpMsg:
-						var msg wiremessage.Msg
-						err = msg.UnmarshalWireMessage(msgbuf)
-						if err != nil {
-							panic(err)
-						}
-						d := bson.D{{Key: "n", Value: 1}, {Key: "ok", Value: 1}}
-						bs, _ := bson.Marshal(d)
-
-						bs, _ = wiremessage.Msg{
-							MsgHeader: wiremessage.Header{
-								RequestID:  nextRequestID(),
-								ResponseTo: hdr.RequestID,
-							},
-							Sections: []wiremessage.Section{
-								&wiremessage.SectionBody{
-									Document: bs,
-								},
-							},
-						}.MarshalWireMessage()
-
-						_, err = conn.Write(bs)
-						if err != nil {
-							panic(err)
+						doc := ack
+						if _, rem, ok := wiremessage.ReadMsgFlags(rem); ok {
+							if _, rem, ok := wiremessage.ReadMsgSectionType(rem); ok {
+								if cmd, _, ok := wiremessage.ReadMsgSectionSingleDocument(rem); ok {
+									switch strings.ToLower(cmd.Index(0).Key()) {
+									case "hello", "ismaster":
+										doc = hello
+									}
+								}
+							}
 						}
+						idx, b := wiremessage.AppendHeaderStart(nil, wiremessage.NextRequestID(), requestID, wiremessage.OpMsg)
+						b = wiremessage.AppendMsgFlags(b, 0)
+						b = wiremessage.AppendMsgSectionType(b, wiremessage.SingleDocument)
+						b = append(b, doc...)
+						reply = bsoncore.UpdateLength(b, idx, int32(len(b[idx:])))
 
 					default:
-						panic("unknown op code: " + hdr.OpCode.String())
+						panic("unknown op code: " + opcode.String())
 					}
 
+					if _, err := conn.Write(reply); err != nil {
+						return
+					}
 				}
 			}()
 		}
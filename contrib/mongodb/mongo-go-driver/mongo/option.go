@@ -1,15 +1,23 @@
 package mongo
 
 type config struct {
-	serviceName   string
-	analyticsRate float64
+	serviceName        string
+	analyticsRate      float64
+	obfuscate          bool
+	maxStatementLength int
+	pool               *PoolMonitor
 }
 
 // Option represents an option that can be passed to Dial.
 type Option func(*config)
 
+// defaultMaxStatementLength is the default maximum length of statements.
+const defaultMaxStatementLength = 5000
+
 func defaults(cfg *config) {
 	cfg.serviceName = "mongo"
+	cfg.obfuscate = true
+	cfg.maxStatementLength = defaultMaxStatementLength
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
 }
 
@@ -37,3 +45,30 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithStatementObfuscation sets whether the values of recorded statements are
+// replaced by "?", keeping the shape of the command. It is enabled by default,
+// as commands may hold whole documents.
+func WithStatementObfuscation(on bool) Option {
+	return func(cfg *config) {
+		cfg.obfuscate = on
+	}
+}
+
+// WithMaxStatementLength sets the maximum length, in bytes, of the recorded
+// statements. Longer statements are truncated. It defaults to 5000, and a
+// non-positive length disables truncation.
+func WithMaxStatementLength(n int) Option {
+	return func(cfg *config) {
+		cfg.maxStatementLength = n
+	}
+}
+
+// WithPoolMonitor tags command spans with the state of the connection pool of
+// their server, as recorded by the given PoolMonitor. The PoolMonitor must also
+// be set on the client options using SetPoolMonitor.
+func WithPoolMonitor(pm *PoolMonitor) Option {
+	return func(cfg *config) {
+		cfg.pool = pm
+	}
+}
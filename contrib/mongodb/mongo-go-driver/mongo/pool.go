@@ -0,0 +1,135 @@
+package mongo
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const (
+	// tagPoolMaxSize is the tag holding the maximum size of the pool.
+	tagPoolMaxSize = "mongo.pool.max_size"
+	// tagPoolInUse is the tag holding the number of checked out connections.
+	tagPoolInUse = "mongo.pool.in_use"
+	// tagPoolCheckoutWait is the tag holding the time, in milliseconds, a
+	// failed connection checkout waited for.
+	tagPoolCheckoutWait = "mongo.pool.checkout_wait_ms"
+	// tagPoolExhausted is set on checkout spans which timed out waiting for a
+	// connection of an exhausted pool.
+	tagPoolExhausted = "mongo.pool.exhausted"
+	// tagPoolFailureReason is the tag holding the reason a checkout failed.
+	tagPoolFailureReason = "mongo.pool.failure_reason"
+)
+
+// PoolMonitor is an event.PoolMonitor recording the state of the connection
+// pools of a client. Failed connection checkouts, such as those timing out on
+// an exhausted pool, are traced as error spans. Command spans are tagged with
+// the state of the pool of their server when passing the PoolMonitor to
+// NewMonitor using WithPoolMonitor.
+type PoolMonitor struct {
+	*event.PoolMonitor
+
+	cfg   *config
+	mu    sync.Mutex
+	pools map[string]*poolState // by server address
+}
+
+// poolState holds the state of the connection pool of a server.
+type poolState struct {
+	maxSize uint64
+	inUse   int
+}
+
+// NewPoolMonitor creates a new PoolMonitor. It is set on the client options
+// using SetPoolMonitor.
+func NewPoolMonitor(opts ...Option) *PoolMonitor {
+	cfg := new(config)
+	defaults(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	pm := &PoolMonitor{
+		cfg:   cfg,
+		pools: make(map[string]*poolState),
+	}
+	pm.PoolMonitor = &event.PoolMonitor{Event: pm.event}
+	return pm
+}
+
+func (pm *PoolMonitor) event(evt *event.PoolEvent) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if evt.Type == event.PoolClosedEvent {
+		delete(pm.pools, evt.Address)
+		return
+	}
+	p, ok := pm.pools[evt.Address]
+	if !ok {
+		p = new(poolState)
+		pm.pools[evt.Address] = p
+	}
+	switch evt.Type {
+	case event.PoolCreated:
+		if evt.PoolOptions != nil {
+			p.maxSize = evt.PoolOptions.MaxPoolSize
+		}
+	case event.GetSucceeded:
+		p.inUse++
+	case event.GetFailed:
+		pm.traceFailure(evt, p)
+	case event.ConnectionReturned:
+		if p.inUse > 0 {
+			p.inUse--
+		}
+	}
+}
+
+// traceFailure records the failed checkout of evt as an error span, covering
+// the time the checkout waited for.
+func (pm *PoolMonitor) traceFailure(evt *event.PoolEvent, p *poolState) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.StartTime(time.Now().Add(-evt.Duration)),
+		tracer.ServiceName(pm.cfg.serviceName),
+		tracer.ResourceName("mongo.checkout"),
+		tracer.SpanType(ext.SpanTypeMongoDB),
+		tracer.Tag(ext.DBType, "mongo"),
+		tracer.Tag(tagPoolFailureReason, evt.Reason),
+		tracer.Tag(tagPoolInUse, p.inUse),
+		tracer.Tag(tagPoolCheckoutWait, float64(evt.Duration)/float64(time.Millisecond)),
+	}
+	if host, port, err := net.SplitHostPort(evt.Address); err == nil {
+		opts = append(opts, tracer.Tag(ext.PeerHostname, host), tracer.Tag(ext.PeerPort, port))
+	}
+	if p.maxSize > 0 {
+		opts = append(opts, tracer.Tag(tagPoolMaxSize, p.maxSize))
+	}
+	if evt.Reason == event.ReasonTimedOut {
+		opts = append(opts, tracer.Tag(tagPoolExhausted, true))
+	}
+	if pm.cfg.analyticsRate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, pm.cfg.analyticsRate))
+	}
+	span := tracer.StartSpan("mongo.checkout", opts...)
+	span.FinishWithOptionsExt(tracer.WithError(errors.New("connection checkout failed: " + evt.Reason)))
+}
+
+// setTags tags span with the state of the pool of the server at addr.
+func (pm *PoolMonitor) setTags(span ddtrace.Span, addr string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	p, ok := pm.pools[addr]
+	if !ok {
+		return
+	}
+	if p.maxSize > 0 {
+		span.SetTag(tagPoolMaxSize, p.maxSize)
+	}
+	span.SetTag(tagPoolInUse, p.inUse)
+}
@@ -40,7 +40,13 @@ func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if mux.cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, mux.cfg.analyticsRate))
 	}
-	httputil.TraceAndServe(mux.ServeMux, w, r, mux.cfg.serviceName, route, opts...)
+	httputil.TraceAndServeWithConfig(mux.ServeMux, w, r, &httputil.TraceConfig{
+		Service:       mux.cfg.serviceName,
+		Resource:      route,
+		SpanOpts:      opts,
+		RecoverPanics: mux.cfg.recoverPanics,
+		Ignore:        &mux.cfg.ignore,
+	})
 }
 
 // WrapHandler wraps an http.Handler with tracing using the given service and resource.
@@ -51,6 +57,12 @@ func WrapHandler(h http.Handler, service, resource string, opts ...Option) http.
 		fn(cfg)
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		httputil.TraceAndServe(h, w, req, service, resource, cfg.spanOpts...)
+		httputil.TraceAndServeWithConfig(h, w, req, &httputil.TraceConfig{
+			Service:       service,
+			Resource:      resource,
+			SpanOpts:      cfg.spanOpts,
+			RecoverPanics: cfg.recoverPanics,
+			Ignore:        &cfg.ignore,
+		})
 	})
 }
@@ -182,6 +182,40 @@ func TestWrapHandler200(t *testing.T) {
 	assert.Equal("bar", s.Tag("foo"))
 }
 
+func TestIgnore(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	assert := assert.New(t)
+
+	mux := NewServeMux(
+		WithIgnorePaths("/health*"),
+		WithIgnoreUserAgents("kube-probe/*"),
+		WithIgnoreRequest(func(r *http.Request) bool { return r.Method == "HEAD" }),
+	)
+	mux.HandleFunc("/", handler200)
+
+	probe := httptest.NewRequest("GET", "/", nil)
+	probe.Header.Set("User-Agent", "kube-probe/1.18")
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("HEAD", "/", nil),
+		probe,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(200, w.Code)
+	}
+	assert.Len(mt.FinishedSpans(), 0)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+
+	handler := WrapHandler(http.HandlerFunc(handler200), "service", "resource", WithIgnorePaths("/health*"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	assert.Len(mt.FinishedSpans(), 1)
+}
+
 func TestAnalyticsSettings(t *testing.T) {
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...Option) {
 		mux := NewServeMux(opts...)
@@ -0,0 +1,90 @@
+package elastic
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+)
+
+const (
+	// tagBulkActions is the tag holding the number of actions of a bulk request.
+	tagBulkActions = "elasticsearch.bulk.actions"
+	// tagBulkIndices is the tag holding the normalized indices, comma-separated,
+	// of the actions of a bulk request.
+	tagBulkIndices = "elasticsearch.bulk.indices"
+	// tagBulkTruncated is set when only part of a bulk request was summarised,
+	// as its body is longer than obfuscationCutoff.
+	tagBulkTruncated = "elasticsearch.bulk.truncated"
+)
+
+// isBulk reports whether the request to the given path is a bulk request.
+func isBulk(path string) bool {
+	return path == "/_bulk" || strings.HasSuffix(path, "/_bulk")
+}
+
+// bulkIndex returns the default index of the bulk request to the given path,
+// which is used by the actions not specifying theirs.
+func bulkIndex(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// tagBulk summarises the given bulk request body on span, with the number of
+// actions of each type, such as "elasticsearch.bulk.index", and their indices.
+// Documents are not recorded.
+func tagBulk(span ddtrace.Span, body, index string, truncated bool) {
+	var (
+		total   int
+		counts  = make(map[string]int)
+		indices = make(map[string]struct{})
+	)
+	lines := strings.Split(body, "\n")
+	if truncated {
+		// the last line was cut.
+		lines = lines[:len(lines)-1]
+	}
+	for i := 0; i < len(lines); i++ {
+		var action map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &action); err != nil || len(action) != 1 {
+			continue
+		}
+		for name, meta := range action {
+			switch name {
+			case "index", "create", "update":
+				// the next line holds the document.
+				i++
+			case "delete":
+			default:
+				continue
+			}
+			total++
+			counts[name]++
+			if meta.Index == "" {
+				meta.Index = index
+			}
+			if meta.Index != "" {
+				indices[string(normalize([]byte(meta.Index)))] = struct{}{}
+			}
+		}
+	}
+	span.SetTag(tagBulkActions, total)
+	for name, n := range counts {
+		span.SetTag("elasticsearch.bulk."+name, n)
+	}
+	names := make([]string, 0, len(indices))
+	for name := range indices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	span.SetTag(tagBulkIndices, strings.Join(names, ","))
+	if truncated {
+		span.SetTag(tagBulkTruncated, true)
+	}
+}
@@ -1,4 +1,5 @@
-// Package elastic provides functions to trace the gopkg.in/olivere/elastic.v{3,5} packages.
+// Package elastic provides functions to trace the gopkg.in/olivere/elastic.v{3,5,6}
+// and github.com/olivere/elastic/v7 packages.
 package elastic // import "github.com/adityayuga/signalfx-go-tracing/contrib/olivere/elastic"
 
 import (
@@ -10,6 +11,7 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/jsonutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -25,6 +27,22 @@ func NewHTTPClient(opts ...ClientOption) *http.Client {
 	return &http.Client{Transport: &httpTransport{config: cfg}}
 }
 
+// WrapRoundTripper returns an http.RoundTripper which traces the Elasticsearch
+// requests sent through rt. It allows tracing a client which has its own base
+// transport, by setting the result as the Transport of the http.Client passed
+// to SetHttpClient. The WithTransport and WithRoundTripper options are ignored.
+func WrapRoundTripper(rt http.RoundTripper, opts ...ClientOption) http.RoundTripper {
+	cfg := new(clientConfig)
+	defaults(cfg)
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if rt != nil {
+		cfg.transport = rt
+	}
+	return &httpTransport{config: cfg}
+}
+
 // httpTransport is a traced HTTP transport that captures Elasticsearch spans.
 type httpTransport struct{ config *clientConfig }
 
@@ -32,6 +50,11 @@ type httpTransport struct{ config *clientConfig }
 // value obtained from an HTTP request or response body.
 var bodyCutoff = 5 * 1024
 
+// obfuscationCutoff specifies the maximum number of bytes of a request body that
+// will be read to obfuscate it or to summarise a bulk request. As obfuscated
+// bodies are shorter, it is larger than bodyCutoff.
+var obfuscationCutoff = 64 * 1024
+
 // RoundTrip satisfies the RoundTripper interface, wraps the sub Transport and
 // captures a span of the Elasticsearch request.
 func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -52,11 +75,28 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	span, _ := tracer.StartSpanFromContext(req.Context(), "elasticsearch.query", opts...)
 	defer span.Finish()
 
-	snip, rc, err := peek(req.Body, int(req.ContentLength), bodyCutoff)
-	if err == nil {
-		span.SetTag("elasticsearch.body", snip)
+	if t.config.obfuscate {
+		// one more byte than the cutoff is read to tell whether the body is longer.
+		snip, rc, err := peek(req.Body, int(req.ContentLength), obfuscationCutoff+1)
+		if err == nil && snip != "" {
+			if isBulk(url) {
+				truncated := len(snip) > obfuscationCutoff
+				if truncated {
+					snip = snip[:obfuscationCutoff]
+				}
+				tagBulk(span, snip, bulkIndex(url), truncated)
+			} else {
+				span.SetTag("elasticsearch.body", obfuscate(snip))
+			}
+		}
+		req.Body = rc
+	} else {
+		snip, rc, err := peek(req.Body, int(req.ContentLength), bodyCutoff)
+		if err == nil {
+			span.SetTag("elasticsearch.body", snip)
+		}
+		req.Body = rc
 	}
-	req.Body = rc
 	// process using the standard transport
 	res, err := t.config.transport.RoundTrip(req)
 	if err != nil {
@@ -64,8 +104,15 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		span.SetTag(ext.Error, err)
 	} else if res.StatusCode < 200 || res.StatusCode > 299 {
 		// HTTP error
-		snip, rc, err := peek(res.Body, int(res.ContentLength), bodyCutoff)
-		if err != nil {
+		cutoff := bodyCutoff
+		if t.config.obfuscate {
+			cutoff = obfuscationCutoff + 1
+		}
+		snip, rc, err := peek(res.Body, int(res.ContentLength), cutoff)
+		if err == nil && t.config.obfuscate {
+			snip = obfuscate(snip)
+		}
+		if err != nil || snip == "" || snip == "?" {
 			snip = http.StatusText(res.StatusCode)
 		}
 		span.SetTag(ext.Error, errors.New(snip))
@@ -77,9 +124,22 @@ func (t *httpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return res, err
 }
 
+// obfuscate returns the given request or response body with its values replaced
+// by "?", cut to bodyCutoff bytes. Bodies which can not be parsed, such as the
+// ones longer than obfuscationCutoff, are replaced by "?" entirely.
+func obfuscate(body string) string {
+	obfuscated, err := jsonutil.Obfuscate([]byte(body))
+	if err != nil {
+		return "?"
+	}
+	return jsonutil.Truncate(obfuscated, bodyCutoff)
+}
+
 var (
 	idRegexp         = regexp.MustCompile("/([0-9]+)([/\\?]|$)")
 	idPlaceholder    = []byte("/?$2")
+	uuidRegexp       = regexp.MustCompile("[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}")
+	dateRegexp       = regexp.MustCompile("[0-9]{4}[-._][0-9]{2}[-._][0-9]{2}")
 	indexRegexp      = regexp.MustCompile("[0-9]{2,}")
 	indexPlaceholder = []byte("?")
 )
@@ -89,10 +149,18 @@ var (
 // URLs with an ID will be generalized as will (potential) timestamped indices.
 func quantize(url, method string) string {
 	quantizedURL := idRegexp.ReplaceAll([]byte(url), idPlaceholder)
-	quantizedURL = indexRegexp.ReplaceAll(quantizedURL, indexPlaceholder)
+	quantizedURL = normalize(quantizedURL)
 	return fmt.Sprintf("%s %s", method, quantizedURL)
 }
 
+// normalize replaces the UUIDs, dates and numbers of the given index name or
+// URL by placeholders, so that indices rolled over time share a name.
+func normalize(b []byte) []byte {
+	b = uuidRegexp.ReplaceAll(b, indexPlaceholder)
+	b = dateRegexp.ReplaceAll(b, indexPlaceholder)
+	return indexRegexp.ReplaceAll(b, indexPlaceholder)
+}
+
 // peek attempts to return the first n bytes, as a string, from the provided io.ReadCloser.
 // It returns a new io.ReadCloser which points to the same underlying stream and can be read
 // from to access the entire data including the snippet. max is used to specify the length
@@ -7,30 +7,36 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
 	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
+	elasticv7 "github.com/olivere/elastic/v7"
 	elasticv3 "gopkg.in/olivere/elastic.v3"
 	elasticv5 "gopkg.in/olivere/elastic.v5"
+	elasticv6 "gopkg.in/olivere/elastic.v6"
 
 	"testing"
 )
 
 const debug = false
 
+var integration bool
+
 func TestMain(m *testing.M) {
-	_, ok := os.LookupEnv("INTEGRATION")
-	if !ok {
-		fmt.Println("--- SKIP: to enable integration test, set the INTEGRATION environment variable")
-		os.Exit(0)
-	}
+	_, integration = os.LookupEnv("INTEGRATION")
 	os.Exit(m.Run())
 }
 
 func TestClientV5(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -66,6 +72,9 @@ func TestClientV5(t *testing.T) {
 }
 
 func TestClientErrorCutoffV3(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -75,7 +84,7 @@ func TestClientErrorCutoffV3(t *testing.T) {
 	}()
 	bodyCutoff = 10
 
-	tc := NewHTTPClient(WithServiceName("my-es-service"))
+	tc := NewHTTPClient(WithServiceName("my-es-service"), WithBodyObfuscation(false))
 	client, err := elasticv5.NewClient(
 		elasticv5.SetURL("http://127.0.0.1:9200"),
 		elasticv5.SetHttpClient(tc),
@@ -96,6 +105,9 @@ func TestClientErrorCutoffV3(t *testing.T) {
 }
 
 func TestClientErrorCutoffV5(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -119,10 +131,13 @@ func TestClientErrorCutoffV5(t *testing.T) {
 	assert.Error(err)
 
 	span := mt.FinishedSpans()[0]
-	assert.Equal(`{"error":{`, span.Tag(ext.Error).(error).Error())
+	assert.Equal(`{"error...`, span.Tag(ext.Error).(error).Error())
 }
 
 func TestClientV3(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assert := assert.New(t)
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -215,13 +230,106 @@ func TestClientV5Failure(t *testing.T) {
 	assert.Equal("*net.OpError", fmt.Sprintf("%T", spans[0].Tag(ext.Error).(error)))
 }
 
+// newESServer returns a server answering the requests of Elasticsearch clients
+// with successful responses.
+func newESServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if isBulk(r.URL.Path) {
+			w.Write([]byte(`{"took":1,"errors":false,"items":[]}`))
+			return
+		}
+		w.Write([]byte(`{"_index":"twitter","_type":"tweet","_id":"1","_version":1,"result":"created"}`))
+	}))
+}
+
+func TestClientV6(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	srv := newESServer()
+	defer srv.Close()
+
+	tc := NewHTTPClient(WithServiceName("my-es-service"))
+	client, err := elasticv6.NewClient(
+		elasticv6.SetURL(srv.URL),
+		elasticv6.SetHttpClient(tc),
+		elasticv6.SetSniff(false),
+		elasticv6.SetHealthcheck(false),
+	)
+	assert.NoError(err)
+
+	_, err = client.Index().
+		Index("twitter").Id("1").
+		Type("tweet").
+		BodyString(`{"user": "test", "message": "hello"}`).
+		Do(context.TODO())
+	assert.NoError(err)
+	checkPUTTrace(assert, mt)
+
+	mt.Reset()
+	_, err = client.Bulk().
+		Add(elasticv6.NewBulkIndexRequest().Index("logs-2019.05.01").Type("_doc").Id("1").Doc(map[string]string{"message": "secret"})).
+		Add(elasticv6.NewBulkDeleteRequest().Index("twitter").Type("tweet").Id("2")).
+		Do(context.TODO())
+	assert.NoError(err)
+	checkBulkTrace(assert, mt)
+}
+
+func TestClientV7(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	srv := newESServer()
+	defer srv.Close()
+
+	base := &countingTransport{rt: http.DefaultTransport}
+	tc := &http.Client{Transport: WrapRoundTripper(base, WithServiceName("my-es-service"))}
+	client, err := elasticv7.NewClient(
+		elasticv7.SetURL(srv.URL),
+		elasticv7.SetHttpClient(tc),
+		elasticv7.SetSniff(false),
+		elasticv7.SetHealthcheck(false),
+	)
+	assert.NoError(err)
+
+	_, err = client.Index().
+		Index("twitter").Id("1").
+		BodyString(`{"user": "test", "message": "hello"}`).
+		Do(context.TODO())
+	assert.NoError(err)
+	span := mt.FinishedSpans()[0]
+	assert.Equal("my-es-service", span.Tag(ext.ServiceName))
+	assert.Equal("PUT /twitter/_doc/?", span.Tag(ext.ResourceName))
+	assert.Equal(`{"user":"?","message":"?"}`, span.Tag("elasticsearch.body"))
+
+	mt.Reset()
+	_, err = client.Bulk().
+		Add(elasticv7.NewBulkIndexRequest().Index("logs-2019.05.01").Id("1").Doc(map[string]string{"message": "secret"})).
+		Add(elasticv7.NewBulkDeleteRequest().Index("twitter").Id("2")).
+		Do(context.TODO())
+	assert.NoError(err)
+	checkBulkTrace(assert, mt)
+	assert.Equal(2, base.n)
+}
+
+func checkBulkTrace(assert *assert.Assertions, mt mocktracer.Tracer) {
+	span := mt.FinishedSpans()[0]
+	assert.Equal("my-es-service", span.Tag(ext.ServiceName))
+	assert.Equal(2, span.Tag(tagBulkActions))
+	assert.Equal(1, span.Tag("elasticsearch.bulk.index"))
+	assert.Equal(1, span.Tag("elasticsearch.bulk.delete"))
+	assert.Equal("logs-?,twitter", span.Tag(tagBulkIndices))
+	assert.Nil(span.Tag("elasticsearch.body"))
+}
+
 func checkPUTTrace(assert *assert.Assertions, mt mocktracer.Tracer) {
 	span := mt.FinishedSpans()[0]
 	assert.Equal("my-es-service", span.Tag(ext.ServiceName))
 	assert.Equal("PUT /twitter/tweet/?", span.Tag(ext.ResourceName))
 	assert.Equal("/twitter/tweet/1", span.Tag("elasticsearch.url"))
 	assert.Equal("PUT", span.Tag("elasticsearch.method"))
-	assert.Equal(`{"user": "test", "message": "hello"}`, span.Tag("elasticsearch.body"))
+	assert.Equal(`{"user":"?","message":"?"}`, span.Tag("elasticsearch.body"))
 }
 
 func checkGETTrace(assert *assert.Assertions, mt mocktracer.Tracer) {
@@ -266,11 +374,174 @@ func TestQuantize(t *testing.T) {
 			method:   "PUT",
 			expected: "PUT /logs_?_?/event/?",
 		},
+		{
+			url:      "/logstash-2019.05.01/_doc/_search",
+			method:   "GET",
+			expected: "GET /logstash-?/_doc/_search",
+		},
+		{
+			url:      "/sessions-3f2b6c1e-9a4d-4b8e-8c2f-0d1e2f3a4b5c/_doc/5e0ad0f5-4b5f-4d16-9f0b-3c2a4a4b6d7e",
+			method:   "GET",
+			expected: "GET /sessions-?/_doc/?",
+		},
 	} {
 		assert.Equal(t, tc.expected, quantize(tc.url, tc.method))
 	}
 }
 
+func TestObfuscation(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	post := func(client *http.Client, path, body string) mocktracer.Span {
+		mt.Reset()
+		res, err := client.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		assert.NoError(err)
+		res.Body.Close()
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		return spans[0]
+	}
+
+	t.Run("query", func(t *testing.T) {
+		span := post(NewHTTPClient(), "/twitter/_search", `{"query": {"terms": {"user": ["alice", "bob"]}}}`)
+		assert.Equal(`{"query":{"terms":{"user":["?"]}}}`, span.Tag("elasticsearch.body"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		span := post(NewHTTPClient(WithBodyObfuscation(false)), "/twitter/_search", `{"query": {"match_all": {}}}`)
+		assert.Equal(`{"query": {"match_all": {}}}`, span.Tag("elasticsearch.body"))
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		body := strings.Join([]string{
+			`{"index": {"_index": "logs-2019.05.01", "_id": "1"}}`,
+			`{"message": "secret"}`,
+			`{"index": {"_id": "2"}}`,
+			`{"message": "secret"}`,
+			`{"update": {"_index": "users", "_id": "3"}}`,
+			`{"doc": {"name": "secret"}}`,
+			`{"delete": {"_index": "logs-2019.05.02", "_id": "4"}}`,
+		}, "\n") + "\n"
+		span := post(NewHTTPClient(), "/tweets/_bulk", body)
+		assert.Equal("POST /tweets/_bulk", span.Tag(ext.ResourceName))
+		assert.Equal(4, span.Tag(tagBulkActions))
+		assert.Equal(2, span.Tag("elasticsearch.bulk.index"))
+		assert.Equal(1, span.Tag("elasticsearch.bulk.update"))
+		assert.Equal(1, span.Tag("elasticsearch.bulk.delete"))
+		assert.Equal("logs-?,tweets,users", span.Tag(tagBulkIndices))
+		assert.Nil(span.Tag("elasticsearch.body"))
+		assert.Nil(span.Tag(tagBulkTruncated))
+	})
+
+	t.Run("bulk-truncated", func(t *testing.T) {
+		old := obfuscationCutoff
+		defer func() { obfuscationCutoff = old }()
+		obfuscationCutoff = 60
+
+		body := `{"delete": {"_index": "a", "_id": "1"}}` + "\n" + `{"delete": {"_index": "b", "_id": "2"}}` + "\n"
+		span := post(NewHTTPClient(), "/_bulk", body)
+		assert.Equal(1, span.Tag(tagBulkActions))
+		assert.Equal("a", span.Tag(tagBulkIndices))
+		assert.Equal(true, span.Tag(tagBulkTruncated))
+	})
+
+	t.Run("bulk-unknown-length", func(t *testing.T) {
+		body := `{"delete": {"_index": "a", "_id": "1"}}` + "\n" + `{"delete": {"_index": "b", "_id": "2"}}` + "\n"
+		old := obfuscationCutoff
+		defer func() { obfuscationCutoff = old }()
+		obfuscationCutoff = len(body)
+
+		mt.Reset()
+		req, err := http.NewRequest("POST", srv.URL+"/_bulk", ioutil.NopCloser(strings.NewReader(body)))
+		assert.NoError(err)
+		req.ContentLength = -1
+		res, err := NewHTTPClient().Do(req)
+		assert.NoError(err)
+		res.Body.Close()
+
+		span := mt.FinishedSpans()[0]
+		assert.Equal(2, span.Tag(tagBulkActions))
+		assert.Equal("a,b", span.Tag(tagBulkIndices))
+		assert.Nil(span.Tag(tagBulkTruncated), "a body as long as the cutoff is complete")
+	})
+}
+
+type countingTransport struct {
+	n  int
+	rt http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return t.rt.RoundTrip(req)
+}
+
+func TestWrapRoundTripper(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer srv.Close()
+
+	base := &countingTransport{rt: http.DefaultTransport}
+	client := &http.Client{Transport: WrapRoundTripper(base, WithServiceName("my-es-service"))}
+	res, err := client.Get(srv.URL + "/twitter/_doc/1")
+	assert.NoError(err)
+	res.Body.Close()
+
+	assert.Equal(1, base.n)
+	spans := mt.FinishedSpans()
+	assert.Len(spans, 1)
+	assert.Equal("my-es-service", spans[0].Tag(ext.ServiceName))
+	assert.Equal("GET /twitter/_doc/?", spans[0].Tag(ext.ResourceName))
+	assert.Equal("404", spans[0].Tag(ext.HTTPCode))
+	assert.Equal(`{"error":"?"}`, spans[0].Tag(ext.Error).(error).Error())
+}
+
+func TestErrorResponse(t *testing.T) {
+	assert := assert.New(t)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	body := `{"error":{"type":"index_not_found_exception","reason":"no such index [secret]"},"status":404}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream secret"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	get := func(client *http.Client, path string) string {
+		mt.Reset()
+		res, err := client.Get(srv.URL + path)
+		assert.NoError(err)
+		res.Body.Close()
+		spans := mt.FinishedSpans()
+		assert.Len(spans, 1)
+		return spans[0].Tag(ext.Error).(error).Error()
+	}
+
+	assert.Equal(`{"error":{"type":"?","reason":"?"},"status":"?"}`, get(NewHTTPClient(), "/secret/_doc/1"))
+	// bodies which can not be obfuscated are not recorded
+	assert.Equal(http.StatusText(http.StatusBadGateway), get(NewHTTPClient(), "/text"))
+	assert.Equal(body, get(NewHTTPClient(WithBodyObfuscation(false)), "/secret/_doc/1"))
+}
+
 func TestPeek(t *testing.T) {
 	assert := assert.New(t)
 
@@ -346,6 +617,9 @@ func TestPeek(t *testing.T) {
 }
 
 func TestAnalyticsSettings(t *testing.T) {
+	if !integration {
+		t.Skip("to enable integration test, set the INTEGRATION environment variable")
+	}
 	assertRate := func(t *testing.T, mt mocktracer.Tracer, rate interface{}, opts ...ClientOption) {
 		tc := NewHTTPClient(opts...)
 		client, err := elasticv5.NewClient(
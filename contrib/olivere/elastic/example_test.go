@@ -2,11 +2,14 @@ package elastic_test
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	elastictrace "github.com/adityayuga/signalfx-go-tracing/contrib/olivere/elastic"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
 	elasticv3 "gopkg.in/olivere/elastic.v3"
 	elasticv5 "gopkg.in/olivere/elastic.v5"
+	elasticv6 "gopkg.in/olivere/elastic.v6"
 )
 
 // To start tracing elastic.v5 requests, create a new TracedHTTPClient that you will
@@ -60,3 +63,22 @@ func Example_v3() {
 		DoC(ctx)
 	root.Finish()
 }
+
+// To trace elastic.v6 or elastic/v7 clients which use their own base transport,
+// wrap it with WrapRoundTripper and set it on the HTTP client.
+func Example_v6() {
+	base := &http.Transport{IdleConnTimeout: 30 * time.Second}
+	tc := &http.Client{
+		Transport: elastictrace.WrapRoundTripper(base, elastictrace.WithServiceName("my-es-service")),
+	}
+	client, _ := elasticv6.NewClient(
+		elasticv6.SetURL("http://127.0.0.1:9200"),
+		elasticv6.SetHttpClient(tc),
+	)
+
+	// Bulk requests are summarised by their actions and indices
+	client.Bulk().
+		Add(elasticv6.NewBulkIndexRequest().Index("twitter").Type("_doc").Id("1").Doc(map[string]string{"user": "test"})).
+		Add(elasticv6.NewBulkDeleteRequest().Index("twitter").Type("_doc").Id("2")).
+		Do(context.Background())
+}
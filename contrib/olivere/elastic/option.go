@@ -4,8 +4,9 @@ import "net/http"
 
 type clientConfig struct {
 	serviceName   string
-	transport     *http.Transport
+	transport     http.RoundTripper
 	analyticsRate float64
+	obfuscate     bool
 }
 
 // ClientOption represents an option that can be used when creating a client.
@@ -13,7 +14,8 @@ type ClientOption func(*clientConfig)
 
 func defaults(cfg *clientConfig) {
 	cfg.serviceName = "elastic.client"
-	cfg.transport = http.DefaultTransport.(*http.Transport)
+	cfg.transport = http.DefaultTransport
+	cfg.obfuscate = true
 	// cfg.analyticsRate = globalconfig.AnalyticsRate()
 }
 
@@ -31,6 +33,25 @@ func WithTransport(t *http.Transport) ClientOption {
 	}
 }
 
+// WithRoundTripper sets the given http.RoundTripper as the base transport of
+// the client, such as a transport already wrapped by other middleware.
+func WithRoundTripper(rt http.RoundTripper) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.transport = rt
+	}
+}
+
+// WithBodyObfuscation sets whether the values of request bodies, and of the
+// bodies of error responses, are replaced by "?" before being stored on spans,
+// keeping field names so that the shape of queries is preserved. It is enabled
+// by default. Bulk requests are summarised by their actions and indices instead
+// when it is enabled.
+func WithBodyObfuscation(on bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.obfuscate = on
+	}
+}
+
 // WithAnalytics enables Trace Analytics for all started spans.
 func WithAnalytics(on bool) ClientOption {
 	if on {
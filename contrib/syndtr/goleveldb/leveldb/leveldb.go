@@ -3,7 +3,9 @@ package leveldb // import "github.com/adityayuga/signalfx-go-tracing/contrib/syn
 
 import (
 	"context"
+	"sync"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/keyutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -59,7 +61,7 @@ func (db *DB) WithContext(ctx context.Context) *DB {
 
 // CompactRange calls DB.CompactRange and traces the result.
 func (db *DB) CompactRange(r util.Range) error {
-	span := startSpan(db.cfg, "CompactRange")
+	span := startSpan(db.cfg, "CompactRange", r.Start)
 	err := db.DB.CompactRange(r)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -67,7 +69,7 @@ func (db *DB) CompactRange(r util.Range) error {
 
 // Delete calls DB.Delete and traces the result.
 func (db *DB) Delete(key []byte, wo *opt.WriteOptions) error {
-	span := startSpan(db.cfg, "Delete")
+	span := startSpan(db.cfg, "Delete", key)
 	err := db.DB.Delete(key, wo)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -75,7 +77,7 @@ func (db *DB) Delete(key []byte, wo *opt.WriteOptions) error {
 
 // Get calls DB.Get and traces the result.
 func (db *DB) Get(key []byte, ro *opt.ReadOptions) (value []byte, err error) {
-	span := startSpan(db.cfg, "Get")
+	span := startSpan(db.cfg, "Get", key)
 	value, err = db.DB.Get(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return value, err
@@ -94,7 +96,7 @@ func (db *DB) GetSnapshot() (*Snapshot, error) {
 
 // Has calls DB.Has and traces the result.
 func (db *DB) Has(key []byte, ro *opt.ReadOptions) (ret bool, err error) {
-	span := startSpan(db.cfg, "Has")
+	span := startSpan(db.cfg, "Has", key)
 	ret, err = db.DB.Has(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return ret, err
@@ -120,7 +122,7 @@ func (db *DB) OpenTransaction() (*Transaction, error) {
 
 // Put calls DB.Put and traces the result.
 func (db *DB) Put(key, value []byte, wo *opt.WriteOptions) error {
-	span := startSpan(db.cfg, "Put")
+	span := startSpan(db.cfg, "Put", key)
 	err := db.DB.Put(key, value, wo)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -128,23 +130,34 @@ func (db *DB) Put(key, value []byte, wo *opt.WriteOptions) error {
 
 // Write calls DB.Write and traces the result.
 func (db *DB) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
-	span := startSpan(db.cfg, "Write")
+	span := startSpan(db.cfg, "Write", nil)
+	tagBatch(span, batch)
 	err := db.DB.Write(batch, wo)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
-// A Snapshot wraps a leveldb.Snapshot and traces all queries.
+// A Snapshot wraps a leveldb.Snapshot and traces all queries. The lifetime of
+// the snapshot is traced until Release is called.
 type Snapshot struct {
 	*leveldb.Snapshot
-	cfg *config
+	cfg      *config
+	lifetime *lifetime
+}
+
+// lifetime is the span covering the lifetime of a snapshot, shared by its copies.
+type lifetime struct {
+	once sync.Once
+	span ddtrace.Span
 }
 
 // WrapSnapshot wraps a leveldb.Snapshot so that queries are traced.
 func WrapSnapshot(snap *leveldb.Snapshot, opts ...Option) *Snapshot {
+	cfg := newConfig(opts...)
 	return &Snapshot{
 		Snapshot: snap,
-		cfg:      newConfig(opts...),
+		cfg:      cfg,
+		lifetime: &lifetime{span: startSpan(cfg, "Snapshot", nil)},
 	}
 }
 
@@ -155,12 +168,19 @@ func (snap *Snapshot) WithContext(ctx context.Context) *Snapshot {
 	return &Snapshot{
 		Snapshot: snap.Snapshot,
 		cfg:      &newcfg,
+		lifetime: snap.lifetime,
 	}
 }
 
+// Release calls Snapshot.Release and finishes the span of its lifetime.
+func (snap *Snapshot) Release() {
+	snap.Snapshot.Release()
+	snap.lifetime.once.Do(snap.lifetime.span.Finish)
+}
+
 // Get calls Snapshot.Get and traces the result.
 func (snap *Snapshot) Get(key []byte, ro *opt.ReadOptions) (value []byte, err error) {
-	span := startSpan(snap.cfg, "Get")
+	span := startSpan(snap.cfg, "Get", key)
 	value, err = snap.Snapshot.Get(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return value, err
@@ -168,7 +188,7 @@ func (snap *Snapshot) Get(key []byte, ro *opt.ReadOptions) (value []byte, err er
 
 // Has calls Snapshot.Has and traces the result.
 func (snap *Snapshot) Has(key []byte, ro *opt.ReadOptions) (ret bool, err error) {
-	span := startSpan(snap.cfg, "Has")
+	span := startSpan(snap.cfg, "Has", key)
 	ret, err = snap.Snapshot.Has(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return ret, err
@@ -207,7 +227,7 @@ func (tr *Transaction) WithContext(ctx context.Context) *Transaction {
 
 // Commit calls Transaction.Commit and traces the result.
 func (tr *Transaction) Commit() error {
-	span := startSpan(tr.cfg, "Commit")
+	span := startSpan(tr.cfg, "Commit", nil)
 	err := tr.Transaction.Commit()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -215,7 +235,7 @@ func (tr *Transaction) Commit() error {
 
 // Get calls Transaction.Get and traces the result.
 func (tr *Transaction) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
-	span := startSpan(tr.cfg, "Get")
+	span := startSpan(tr.cfg, "Get", key)
 	value, err := tr.Transaction.Get(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return value, err
@@ -223,12 +243,21 @@ func (tr *Transaction) Get(key []byte, ro *opt.ReadOptions) ([]byte, error) {
 
 // Has calls Transaction.Has and traces the result.
 func (tr *Transaction) Has(key []byte, ro *opt.ReadOptions) (bool, error) {
-	span := startSpan(tr.cfg, "Has")
+	span := startSpan(tr.cfg, "Has", key)
 	ret, err := tr.Transaction.Has(key, ro)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return ret, err
 }
 
+// Write calls Transaction.Write and traces the result.
+func (tr *Transaction) Write(batch *leveldb.Batch, wo *opt.WriteOptions) error {
+	span := startSpan(tr.cfg, "Write", nil)
+	tagBatch(span, batch)
+	err := tr.Transaction.Write(batch, wo)
+	span.FinishWithOptionsExt(tracer.WithError(err))
+	return err
+}
+
 // NewIterator calls Transaction.NewIterator and returns a wrapped Iterator.
 func (tr *Transaction) NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator {
 	return WrapIterator(tr.Transaction.NewIterator(slice, ro), func(cfg *config) {
@@ -236,28 +265,94 @@ func (tr *Transaction) NewIterator(slice *util.Range, ro *opt.ReadOptions) itera
 	})
 }
 
-// An Iterator wraps a leveldb.Iterator and traces until Release is called.
+// An Iterator wraps a leveldb.Iterator and traces from the first seek until
+// Release is called, counting the keys it went through.
 type Iterator struct {
 	iterator.Iterator
+	cfg  *config
 	span ddtrace.Span
+	keys int
 }
 
 // WrapIterator wraps a leveldb.Iterator so that queries are traced.
 func WrapIterator(it iterator.Iterator, opts ...Option) *Iterator {
 	return &Iterator{
 		Iterator: it,
-		span:     startSpan(newConfig(opts...), "Iterator"),
+		cfg:      newConfig(opts...),
+	}
+}
+
+// step starts the span on the first seek and counts the key found, if any.
+func (it *Iterator) step(ok bool, key []byte) bool {
+	if it.span == nil {
+		it.span = startSpan(it.cfg, "Iterator", key)
+	}
+	if ok {
+		it.keys++
 	}
+	return ok
+}
+
+// First calls Iterator.First and traces the result.
+func (it *Iterator) First() bool {
+	ok := it.Iterator.First()
+	return it.step(ok, it.Iterator.Key())
+}
+
+// Last calls Iterator.Last and traces the result.
+func (it *Iterator) Last() bool {
+	ok := it.Iterator.Last()
+	return it.step(ok, it.Iterator.Key())
+}
+
+// Seek calls Iterator.Seek and traces the result.
+func (it *Iterator) Seek(key []byte) bool {
+	return it.step(it.Iterator.Seek(key), key)
+}
+
+// Next calls Iterator.Next and traces the result.
+func (it *Iterator) Next() bool {
+	ok := it.Iterator.Next()
+	return it.step(ok, it.Iterator.Key())
+}
+
+// Prev calls Iterator.Prev and traces the result.
+func (it *Iterator) Prev() bool {
+	ok := it.Iterator.Prev()
+	return it.step(ok, it.Iterator.Key())
 }
 
 // Release calls Iterator.Release and traces the result.
 func (it *Iterator) Release() {
 	err := it.Error()
 	it.Iterator.Release()
+	it.step(false, nil)
+	it.span.SetTag(tagIteratorKeys, it.keys)
 	it.span.FinishWithOptionsExt(tracer.WithError(err))
 }
 
-func startSpan(cfg *config, name string) ddtrace.Span {
+const (
+	// tagIteratorKeys is the tag holding the number of keys an iterator went
+	// through.
+	tagIteratorKeys = "leveldb.iterator.keys"
+	// tagBatchOps is the tag holding the number of operations of a batch.
+	tagBatchOps = "leveldb.batch.ops"
+	// tagBatchSize is the tag holding the size, in bytes, of a batch.
+	tagBatchSize = "leveldb.batch.size"
+	// tagKeyPrefix is the tag holding the prefix of the key of an operation.
+	tagKeyPrefix = "leveldb.key_prefix"
+)
+
+// tagBatch tags span with the number of operations and the size of batch.
+func tagBatch(span ddtrace.Span, batch *leveldb.Batch) {
+	if batch == nil {
+		return
+	}
+	span.SetTag(tagBatchOps, batch.Len())
+	span.SetTag(tagBatchSize, len(batch.Dump()))
+}
+
+func startSpan(cfg *config, name string, key []byte) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.SpanTypeLevelDB),
 		tracer.ServiceName(cfg.serviceName),
@@ -266,6 +361,9 @@ func startSpan(cfg *config, name string) ddtrace.Span {
 	if cfg.analyticsRate > 0 {
 		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
 	}
+	if n := cfg.keyPrefixLen; n > 0 && len(key) > 0 {
+		opts = append(opts, tracer.Tag(tagKeyPrefix, keyutil.PrefixBytes(key, n)))
+	}
 	span, _ := tracer.StartSpanFromContext(cfg.ctx, "leveldb.query", opts...)
 	return span
 }
@@ -123,6 +123,87 @@ func TestIterator(t *testing.T) {
 		iterator := db.NewIterator(nil, nil)
 		iterator.Release()
 	})
+
+	testAction(t, "Iterator", func(mt mocktracer.Tracer, db *DB) {
+		for _, k := range []string{"a1", "a2", "b1"} {
+			assert.NoError(t, db.DB.Put([]byte(k), []byte("v"), nil))
+		}
+		iterator := db.NewIterator(util.BytesPrefix([]byte("a")), nil)
+		var n int
+		for iterator.Next() {
+			n++
+		}
+		iterator.Release()
+		assert.Equal(t, 2, n)
+
+		spans := mt.FinishedSpans()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, 2, spans[0].Tag(tagIteratorKeys))
+	})
+}
+
+func TestSnapshotLifetime(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db, err := Open(storage.NewMemStorage(), &opt.Options{})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	snapshot, err := db.GetSnapshot()
+	assert.NoError(t, err)
+	snapshot.WithContext(context.Background()).Get([]byte("hello"), nil)
+	assert.Len(t, mt.FinishedSpans(), 1)
+	snapshot.Release()
+	snapshot.Release()
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "Get", spans[0].Tag(ext.ResourceName))
+	assert.Equal(t, "Snapshot", spans[1].Tag(ext.ResourceName))
+	assert.True(t, spans[1].StartTime().Before(spans[0].StartTime()))
+}
+
+func TestBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db, err := Open(storage.NewMemStorage(), &opt.Options{})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var batch leveldb.Batch
+	batch.Put([]byte("hello"), []byte("world"))
+	batch.Delete([]byte("bye"))
+	assert.NoError(t, db.Write(&batch, nil))
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, 2, spans[0].Tag(tagBatchOps))
+	assert.Equal(t, len(batch.Dump()), spans[0].Tag(tagBatchSize))
+}
+
+func TestKeyPrefix(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db, err := Open(storage.NewMemStorage(), &opt.Options{}, WithKeyPrefixLength(5))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Put([]byte("user:1234"), []byte("alice"), nil))
+	db.Get([]byte("abc"), nil)
+	db.Get([]byte("abcdé"), nil)
+	db.Get([]byte{0xff, 0xfe, 0x00, 0x01, 0x02, 0x03}, nil)
+	db.Write(new(leveldb.Batch), nil)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 5)
+	assert.Equal(t, "user:", spans[0].Tag(tagKeyPrefix))
+	assert.Equal(t, "abc", spans[1].Tag(tagKeyPrefix))
+	assert.Equal(t, "abcd", spans[2].Tag(tagKeyPrefix), "runes are not split")
+	assert.Equal(t, "fffe000102", spans[3].Tag(tagKeyPrefix), "invalid UTF-8 is hex-encoded")
+	assert.Nil(t, spans[4].Tag(tagKeyPrefix))
 }
 
 func testAction(t *testing.T, name string, f func(mt mocktracer.Tracer, db *DB)) {
@@ -8,6 +8,7 @@ type config struct {
 	ctx           context.Context
 	serviceName   string
 	analyticsRate float64
+	keyPrefixLen  int
 }
 
 func newConfig(opts ...Option) *config {
@@ -54,3 +55,13 @@ func WithAnalyticsRate(rate float64) Option {
 		cfg.analyticsRate = rate
 	}
 }
+
+// WithKeyPrefixLength tags spans with the first n bytes of the key they
+// operate on, allowing to find hot spots without recording whole keys.
+// Prefixes never end with part of a character, and the prefixes of keys
+// which are not valid UTF-8 are hex-encoded. Keys are not tagged by default.
+func WithKeyPrefixLength(n int) Option {
+	return func(cfg *config) {
+		cfg.keyPrefixLen = n
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/adityayuga/signalfx-go-tracing/contrib/internal/keyutil"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
@@ -45,11 +46,22 @@ func (db *DB) Begin(writable bool) (*Tx, error) {
 	return WrapTx(tx, db.opts...), nil
 }
 
-// Update calls the underlying DB.Update and traces the transaction.
+// Update calls the underlying DB.Update and traces the transaction as a batch,
+// with the number of writes it made and their size. Its queries are children
+// of its span.
 func (db *DB) Update(fn func(tx *Tx) error) error {
-	return db.DB.Update(func(tx *buntdb.Tx) error {
-		return fn(WrapTx(tx, db.opts...))
+	cfg := newConfig(db.opts...)
+	span := startSpan(cfg, "Update", "")
+	opts := append(db.opts[:len(db.opts):len(db.opts)], WithContext(tracer.ContextWithSpan(cfg.ctx, span)))
+	var batch batch
+	err := db.DB.Update(func(tx *buntdb.Tx) error {
+		wtx := WrapTx(tx, opts...)
+		wtx.batch = &batch
+		return fn(wtx)
 	})
+	batch.tag(span)
+	span.FinishWithOptionsExt(tracer.WithError(err))
+	return err
 }
 
 // View calls the underlying DB.View and traces the transaction.
@@ -69,96 +81,171 @@ func (db *DB) WithContext(ctx context.Context) *DB {
 // A Tx wraps a buntdb.Tx, automatically tracing any queries.
 type Tx struct {
 	*buntdb.Tx
-	cfg *config
+	cfg   *config
+	batch *batch
 }
 
 // WrapTx wraps a buntdb.Tx so it can be traced.
 func WrapTx(tx *buntdb.Tx, opts ...Option) *Tx {
+	return &Tx{
+		Tx:    tx,
+		cfg:   newConfig(opts...),
+		batch: new(batch),
+	}
+}
+
+func newConfig(opts ...Option) *config {
 	cfg := new(config)
 	defaults(cfg)
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	return &Tx{
-		Tx:  tx,
-		cfg: cfg,
-	}
+	return cfg
+}
+
+func (tx *Tx) startSpan(name, key string) ddtrace.Span {
+	return startSpan(tx.cfg, name, key)
 }
 
-func (tx *Tx) startSpan(name string) ddtrace.Span {
+func startSpan(cfg *config, name, key string) ddtrace.Span {
 	opts := []ddtrace.StartSpanOption{
 		tracer.SpanType(ext.AppTypeDB),
-		tracer.ServiceName(tx.cfg.serviceName),
+		tracer.ServiceName(cfg.serviceName),
 		tracer.ResourceName(name),
 	}
-	if tx.cfg.analyticsRate > 0 {
-		opts = append(opts, tracer.Tag(ext.EventSampleRate, tx.cfg.analyticsRate))
+	if cfg.analyticsRate > 0 {
+		opts = append(opts, tracer.Tag(ext.EventSampleRate, cfg.analyticsRate))
+	}
+	if n := cfg.keyPrefixLen; n > 0 && key != "" {
+		opts = append(opts, tracer.Tag(tagKeyPrefix, keyutil.Prefix(key, n)))
 	}
-	span, _ := tracer.StartSpanFromContext(tx.cfg.ctx, "buntdb.query", opts...)
+	span, _ := tracer.StartSpanFromContext(cfg.ctx, "buntdb.query", opts...)
 	return span
 }
 
+const (
+	// tagIteratorKeys is the tag holding the number of keys an iteration went
+	// through.
+	tagIteratorKeys = "buntdb.iterator.keys"
+	// tagBatchOps is the tag holding the number of writes of a transaction.
+	tagBatchOps = "buntdb.batch.ops"
+	// tagBatchSize is the tag holding the size, in bytes, of the keys and
+	// values written by a transaction.
+	tagBatchSize = "buntdb.batch.size"
+	// tagKeyPrefix is the tag holding the prefix of the key of an operation.
+	tagKeyPrefix = "buntdb.key_prefix"
+)
+
+// batch records the writes of a transaction. Transactions are not used
+// concurrently, so that it needs no locking.
+type batch struct {
+	ops, size int
+}
+
+// add records a write of the given size.
+func (b *batch) add(size int) {
+	b.ops++
+	b.size += size
+}
+
+// tag tags span with the writes recorded.
+func (b *batch) tag(span ddtrace.Span) {
+	span.SetTag(tagBatchOps, b.ops)
+	span.SetTag(tagBatchSize, b.size)
+}
+
+// countKeys returns an iterator calling iterator and counting its calls in n.
+func countKeys(n *int, iterator func(key, value string) bool) func(key, value string) bool {
+	return func(key, value string) bool {
+		*n++
+		return iterator(key, value)
+	}
+}
+
 // WithContext sets the context for the Tx.
 func (tx *Tx) WithContext(ctx context.Context) *Tx {
 	newcfg := *tx.cfg
 	newcfg.ctx = ctx
 	return &Tx{
-		Tx:  tx.Tx,
-		cfg: &newcfg,
+		Tx:    tx.Tx,
+		cfg:   &newcfg,
+		batch: tx.batch,
 	}
 }
 
+// Commit calls the underlying Tx.Commit and traces the transaction as a batch,
+// with the number of writes it made and their size.
+func (tx *Tx) Commit() error {
+	span := tx.startSpan("Commit", "")
+	tx.batch.tag(span)
+	err := tx.Tx.Commit()
+	span.FinishWithOptionsExt(tracer.WithError(err))
+	return err
+}
+
 // Ascend calls the underlying Tx.Ascend and traces the query.
 func (tx *Tx) Ascend(index string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("Ascend")
-	err := tx.Tx.Ascend(index, iterator)
+	span := tx.startSpan("Ascend", "")
+	var n int
+	err := tx.Tx.Ascend(index, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // AscendEqual calls the underlying Tx.AscendEqual and traces the query.
 func (tx *Tx) AscendEqual(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("AscendEqual")
-	err := tx.Tx.AscendEqual(index, pivot, iterator)
+	span := tx.startSpan("AscendEqual", "")
+	var n int
+	err := tx.Tx.AscendEqual(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // AscendGreaterOrEqual calls the underlying Tx.AscendGreaterOrEqual and traces the query.
 func (tx *Tx) AscendGreaterOrEqual(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("AscendGreaterOrEqual")
-	err := tx.Tx.AscendGreaterOrEqual(index, pivot, iterator)
+	span := tx.startSpan("AscendGreaterOrEqual", "")
+	var n int
+	err := tx.Tx.AscendGreaterOrEqual(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // AscendKeys calls the underlying Tx.AscendKeys and traces the query.
 func (tx *Tx) AscendKeys(pattern string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("AscendKeys")
-	err := tx.Tx.AscendKeys(pattern, iterator)
+	span := tx.startSpan("AscendKeys", "")
+	var n int
+	err := tx.Tx.AscendKeys(pattern, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // AscendLessThan calls the underlying Tx.AscendLessThan and traces the query.
 func (tx *Tx) AscendLessThan(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("AscendLessThan")
-	err := tx.Tx.AscendLessThan(index, pivot, iterator)
+	span := tx.startSpan("AscendLessThan", "")
+	var n int
+	err := tx.Tx.AscendLessThan(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // AscendRange calls the underlying Tx.AscendRange and traces the query.
 func (tx *Tx) AscendRange(index, greaterOrEqual, lessThan string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("AscendRange")
-	err := tx.Tx.AscendRange(index, greaterOrEqual, lessThan, iterator)
+	span := tx.startSpan("AscendRange", "")
+	var n int
+	err := tx.Tx.AscendRange(index, greaterOrEqual, lessThan, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // CreateIndex calls the underlying Tx.CreateIndex and traces the query.
 func (tx *Tx) CreateIndex(name, pattern string, less ...func(a, b string) bool) error {
-	span := tx.startSpan("CreateIndex")
+	span := tx.startSpan("CreateIndex", "")
 	err := tx.Tx.CreateIndex(name, pattern, less...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -166,7 +253,7 @@ func (tx *Tx) CreateIndex(name, pattern string, less ...func(a, b string) bool)
 
 // CreateIndexOptions calls the underlying Tx.CreateIndexOptions and traces the query.
 func (tx *Tx) CreateIndexOptions(name, pattern string, opts *buntdb.IndexOptions, less ...func(a, b string) bool) error {
-	span := tx.startSpan("CreateIndexOptions")
+	span := tx.startSpan("CreateIndexOptions", "")
 	err := tx.Tx.CreateIndexOptions(name, pattern, opts, less...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -174,7 +261,7 @@ func (tx *Tx) CreateIndexOptions(name, pattern string, opts *buntdb.IndexOptions
 
 // CreateSpatialIndex calls the underlying Tx.CreateSpatialIndex and traces the query.
 func (tx *Tx) CreateSpatialIndex(name, pattern string, rect func(item string) (min, max []float64)) error {
-	span := tx.startSpan("CreateSpatialIndex")
+	span := tx.startSpan("CreateSpatialIndex", "")
 	err := tx.Tx.CreateSpatialIndex(name, pattern, rect)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -182,7 +269,7 @@ func (tx *Tx) CreateSpatialIndex(name, pattern string, rect func(item string) (m
 
 // CreateSpatialIndexOptions calls the underlying Tx.CreateSpatialIndexOptions and traces the query.
 func (tx *Tx) CreateSpatialIndexOptions(name, pattern string, opts *buntdb.IndexOptions, rect func(item string) (min, max []float64)) error {
-	span := tx.startSpan("CreateSpatialIndexOptions")
+	span := tx.startSpan("CreateSpatialIndexOptions", "")
 	err := tx.Tx.CreateSpatialIndexOptions(name, pattern, opts, rect)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -190,71 +277,89 @@ func (tx *Tx) CreateSpatialIndexOptions(name, pattern string, opts *buntdb.Index
 
 // Delete calls the underlying Tx.Delete and traces the query.
 func (tx *Tx) Delete(key string) (val string, err error) {
-	span := tx.startSpan("Delete")
+	span := tx.startSpan("Delete", key)
 	val, err = tx.Tx.Delete(key)
+	if err == nil {
+		tx.batch.add(len(key))
+	}
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return val, err
 }
 
 // DeleteAll calls the underlying Tx.DeleteAll and traces the query.
 func (tx *Tx) DeleteAll() error {
-	span := tx.startSpan("DeleteAll")
+	span := tx.startSpan("DeleteAll", "")
 	err := tx.Tx.DeleteAll()
+	if err == nil {
+		tx.batch.add(0)
+	}
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // Descend calls the underlying Tx.Descend and traces the query.
 func (tx *Tx) Descend(index string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("Descend")
-	err := tx.Tx.Descend(index, iterator)
+	span := tx.startSpan("Descend", "")
+	var n int
+	err := tx.Tx.Descend(index, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DescendEqual calls the underlying Tx.DescendEqual and traces the query.
 func (tx *Tx) DescendEqual(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("DescendEqual")
-	err := tx.Tx.DescendEqual(index, pivot, iterator)
+	span := tx.startSpan("DescendEqual", "")
+	var n int
+	err := tx.Tx.DescendEqual(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DescendGreaterThan calls the underlying Tx.DescendGreaterThan and traces the query.
 func (tx *Tx) DescendGreaterThan(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("DescendGreaterThan")
-	err := tx.Tx.DescendGreaterThan(index, pivot, iterator)
+	span := tx.startSpan("DescendGreaterThan", "")
+	var n int
+	err := tx.Tx.DescendGreaterThan(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DescendKeys calls the underlying Tx.DescendKeys and traces the query.
 func (tx *Tx) DescendKeys(pattern string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("DescendKeys")
-	err := tx.Tx.DescendKeys(pattern, iterator)
+	span := tx.startSpan("DescendKeys", "")
+	var n int
+	err := tx.Tx.DescendKeys(pattern, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DescendLessOrEqual calls the underlying Tx.DescendLessOrEqual and traces the query.
 func (tx *Tx) DescendLessOrEqual(index, pivot string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("DescendLessOrEqual")
-	err := tx.Tx.DescendLessOrEqual(index, pivot, iterator)
+	span := tx.startSpan("DescendLessOrEqual", "")
+	var n int
+	err := tx.Tx.DescendLessOrEqual(index, pivot, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DescendRange calls the underlying Tx.DescendRange and traces the query.
 func (tx *Tx) DescendRange(index, lessOrEqual, greaterThan string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("DescendRange")
-	err := tx.Tx.DescendRange(index, lessOrEqual, greaterThan, iterator)
+	span := tx.startSpan("DescendRange", "")
+	var n int
+	err := tx.Tx.DescendRange(index, lessOrEqual, greaterThan, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // DropIndex calls the underlying Tx.DropIndex and traces the query.
 func (tx *Tx) DropIndex(name string) error {
-	span := tx.startSpan("DropIndex")
+	span := tx.startSpan("DropIndex", "")
 	err := tx.Tx.DropIndex(name)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
@@ -262,7 +367,7 @@ func (tx *Tx) DropIndex(name string) error {
 
 // Get calls the underlying Tx.Get and traces the query.
 func (tx *Tx) Get(key string, ignoreExpired ...bool) (val string, err error) {
-	span := tx.startSpan("Get")
+	span := tx.startSpan("Get", key)
 	val, err = tx.Tx.Get(key, ignoreExpired...)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return val, err
@@ -270,7 +375,7 @@ func (tx *Tx) Get(key string, ignoreExpired ...bool) (val string, err error) {
 
 // Indexes calls the underlying Tx.Indexes and traces the query.
 func (tx *Tx) Indexes() ([]string, error) {
-	span := tx.startSpan("Indexes")
+	span := tx.startSpan("Indexes", "")
 	indexes, err := tx.Tx.Indexes()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return indexes, err
@@ -278,15 +383,17 @@ func (tx *Tx) Indexes() ([]string, error) {
 
 // Intersects calls the underlying Tx.Intersects and traces the query.
 func (tx *Tx) Intersects(index, bounds string, iterator func(key, value string) bool) error {
-	span := tx.startSpan("Intersects")
-	err := tx.Tx.Intersects(index, bounds, iterator)
+	span := tx.startSpan("Intersects", "")
+	var n int
+	err := tx.Tx.Intersects(index, bounds, countKeys(&n, iterator))
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // Len calls the underlying Tx.Len and traces the query.
 func (tx *Tx) Len() (int, error) {
-	span := tx.startSpan("Len")
+	span := tx.startSpan("Len", "")
 	n, err := tx.Tx.Len()
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return n, err
@@ -294,23 +401,31 @@ func (tx *Tx) Len() (int, error) {
 
 // Nearby calls the underlying Tx.Nearby and traces the query.
 func (tx *Tx) Nearby(index, bounds string, iterator func(key, value string, dist float64) bool) error {
-	span := tx.startSpan("Nearby")
-	err := tx.Tx.Nearby(index, bounds, iterator)
+	span := tx.startSpan("Nearby", "")
+	var n int
+	err := tx.Tx.Nearby(index, bounds, func(key, value string, dist float64) bool {
+		n++
+		return iterator(key, value, dist)
+	})
+	span.SetTag(tagIteratorKeys, n)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return err
 }
 
 // Set calls the underlying Tx.Set and traces the query.
 func (tx *Tx) Set(key, value string, opts *buntdb.SetOptions) (previousValue string, replaced bool, err error) {
-	span := tx.startSpan("Set")
+	span := tx.startSpan("Set", key)
 	previousValue, replaced, err = tx.Tx.Set(key, value, opts)
+	if err == nil {
+		tx.batch.add(len(key) + len(value))
+	}
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return previousValue, replaced, err
 }
 
 // TTL calls the underlying Tx.TTL and traces the query.
 func (tx *Tx) TTL(key string) (time.Duration, error) {
-	span := tx.startSpan("TTL")
+	span := tx.startSpan("TTL", key)
 	duration, err := tx.Tx.TTL(key)
 	span.FinishWithOptionsExt(tracer.WithError(err))
 	return duration, err
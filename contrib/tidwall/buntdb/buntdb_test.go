@@ -409,6 +409,95 @@ func TestAnalyticsSettings(t *testing.T) {
 	})
 }
 
+func TestIteratorKeys(t *testing.T) {
+	testView(t, "AscendKeys", func(tx *Tx) error {
+		err := tx.AscendKeys("regular:*", func(key, value string) bool {
+			return key < "regular:c"
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db := getDatabase(t)
+	defer db.Close()
+
+	err := db.View(func(tx *Tx) error {
+		return tx.Ascend("test-index", func(key, value string) bool {
+			return true
+		})
+	})
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, 5, spans[0].Tag(tagIteratorKeys))
+}
+
+func TestBatch(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db := getDatabase(t)
+	defer db.Close()
+
+	err := db.Update(func(tx *Tx) error {
+		tx.Set("key", "value", nil)
+		tx.WithContext(context.Background()).Delete("regular:a")
+		tx.Get("key")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 4)
+	span := spans[3]
+	assert.Equal(t, "Update", span.Tag(ext.ResourceName))
+	assert.Equal(t, 2, span.Tag(tagBatchOps))
+	assert.Equal(t, len("key")+len("value")+len("regular:a"), span.Tag(tagBatchSize))
+
+	mt.Reset()
+	tx, err := db.Begin(true)
+	assert.NoError(t, err)
+	tx.Set("key", "value", nil)
+	assert.NoError(t, tx.Commit())
+
+	spans = mt.FinishedSpans()
+	assert.Len(t, spans, 2)
+	span = spans[1]
+	assert.Equal(t, "Commit", span.Tag(ext.ResourceName))
+	assert.Equal(t, 1, span.Tag(tagBatchOps))
+	assert.Equal(t, len("key")+len("value"), span.Tag(tagBatchSize))
+}
+
+func TestKeyPrefix(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	db := getDatabase(t, WithKeyPrefixLength(8))
+	defer db.Close()
+
+	err := db.View(func(tx *Tx) error {
+		tx.Get("regular:a")
+		tx.Get("spatial")
+		tx.Get("clé:éé")
+		tx.Get("\xff\xfe\x00\x01")
+		tx.Len()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	spans := mt.FinishedSpans()
+	assert.Len(t, spans, 5)
+	assert.Equal(t, "regular:", spans[0].Tag(tagKeyPrefix))
+	assert.Equal(t, "spatial", spans[1].Tag(tagKeyPrefix))
+	assert.Equal(t, "clé:é", spans[2].Tag(tagKeyPrefix), "runes are not split")
+	assert.Equal(t, "fffe0001", spans[3].Tag(tagKeyPrefix), "invalid UTF-8 is hex-encoded")
+	assert.Nil(t, spans[4].Tag(tagKeyPrefix))
+}
+
 func testUpdate(t *testing.T, name string, f func(tx *Tx) error) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
@@ -422,8 +511,12 @@ func testUpdate(t *testing.T, name string, f func(tx *Tx) error) {
 	span.Finish()
 
 	spans := mt.FinishedSpans()
-	assert.Len(t, spans, 2)
-	assert.Equal(t, spans[0].TraceID(), spans[1].TraceID())
+	assert.Len(t, spans, 3)
+	assert.Equal(t, spans[0].TraceID(), spans[2].TraceID())
+	// queries are children of the span of the transaction
+	assert.Equal(t, spans[1].SpanID(), spans[0].ParentID())
+	assert.Equal(t, "Update", spans[1].Tag(ext.ResourceName))
+	assert.Equal(t, spans[2].SpanID(), spans[1].ParentID())
 
 	assert.Equal(t, ext.AppTypeDB, spans[0].Tag(ext.SpanType))
 	assert.Equal(t, name, spans[0].Tag(ext.ResourceName))
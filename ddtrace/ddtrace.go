@@ -94,4 +94,28 @@ type StartSpanConfig struct {
 	// Force-set the SpanID, rather than use a random number. If no Parent SpanContext is present,
 	// then this will also set the TraceID to the same value.
 	SpanID uint64
+
+	// Links holds the contexts of the spans, other than the parent, which are
+	// causally related to the new span, such as the messages of a batch.
+	Links []SpanLink
+}
+
+// Span link types.
+const (
+	// LinkChildOf marks a linked span as a parent of the span.
+	LinkChildOf = "child_of"
+	// LinkFollowsFrom marks a span as following from a linked span, without
+	// the linked span depending on its outcome.
+	LinkFollowsFrom = "follows_from"
+)
+
+// SpanLink references a span which is causally related to a span without being
+// its parent. It allows a span to be caused by spans of many traces, e.g. when
+// fanning in.
+type SpanLink struct {
+	// Context is the context of the linked span.
+	Context SpanContext
+
+	// Type is the type of the relation, either LinkChildOf or LinkFollowsFrom.
+	Type string
 }
@@ -82,4 +82,8 @@ const (
 	SpanKindServer = "SERVER"
 	// SpanKindClient marks a span as a server span
 	SpanKindClient = "CLIENT"
+	// SpanKindProducer marks a span as a message producer span
+	SpanKindProducer = "PRODUCER"
+	// SpanKindConsumer marks a span as a message consumer span
+	SpanKindConsumer = "CONSUMER"
 )
@@ -4,6 +4,12 @@ const (
 	// CassandraQuery is the tag name used for cassandra queries.
 	CassandraQuery = "cassandra.query"
 
+	// CassandraBatch is the tag name used for cassandra batches.
+	CassandraBatch = "cassandra.batch"
+
+	// CassandraBatchSize specifies the tag name for the number of statements of a batch.
+	CassandraBatchSize = "cassandra.batch.size"
+
 	// CassandraConsistencyLevel is the tag name to set for consitency level.
 	CassandraConsistencyLevel = "cassandra.consistency_level"
 
@@ -24,6 +24,10 @@ func TestSpec(t *testing.T) {
 		SQLQuery, "sql.query",
 		HTTPURL, "http.url",
 		Environment, "env",
+		SpanKindProducer, "PRODUCER",
+		SpanKindConsumer, "CONSUMER",
+		MessagingSystem, "messaging.system",
+		MessagingDestination, "messaging.destination",
 	}
 	if len(tests)%2 != 0 {
 		t.Fatal("uneven test count")
@@ -0,0 +1,18 @@
+package ext
+
+const (
+	// MessagingSystem indicates the messaging system, e.g. "kafka".
+	MessagingSystem = "messaging.system"
+	// MessagingDestination indicates the destination of a message, e.g. the Kafka topic.
+	MessagingDestination = "messaging.destination"
+	// MessagingPayloadSize indicates the size of the payload of a message, in bytes.
+	MessagingPayloadSize = "messaging.message_payload_size_bytes"
+	// MessagingBatchSize indicates the number of messages of a batch.
+	MessagingBatchSize = "messaging.batch.message_count"
+	// MessagingKafkaMessageKey records the hash of the key of a Kafka message.
+	MessagingKafkaMessageKey = "messaging.kafka.message_key"
+	// MessagingKafkaClientID indicates the client ID of a Kafka producer or consumer.
+	MessagingKafkaClientID = "messaging.kafka.client_id"
+	// MessagingKafkaConsumerGroup indicates the group of a Kafka consumer.
+	MessagingKafkaConsumerGroup = "messaging.kafka.consumer_group"
+)
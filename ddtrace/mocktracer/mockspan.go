@@ -44,6 +44,9 @@ type Span interface {
 	// Context returns the span's SpanContext.
 	Context() ddtrace.SpanContext
 
+	// Links returns the links of the span to spans other than its parent.
+	Links() []ddtrace.SpanLink
+
 	// Stringer allows pretty-printing the span's fields for debugging.
 	fmt.Stringer
 }
@@ -87,6 +90,7 @@ func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig)
 	for k, v := range cfg.Tags {
 		s.SetTag(k, v)
 	}
+	s.links = cfg.Links
 	return s
 }
 
@@ -98,6 +102,7 @@ type mockspan struct {
 
 	startTime time.Time
 	parentID  uint64
+	links     []ddtrace.SpanLink
 	context   *spanContext
 	tracer    *mocktracer
 }
@@ -176,6 +181,8 @@ func (s *mockspan) Tags() map[string]interface{} {
 
 func (s *mockspan) ParentID() uint64 { return s.parentID }
 
+func (s *mockspan) Links() []ddtrace.SpanLink { return s.links }
+
 func (s *mockspan) TraceID() uint64 { return s.context.traceID }
 
 func (s *mockspan) SpanID() uint64 { return s.context.spanID }
@@ -38,11 +38,34 @@ func (t *opentracer) StartSpan(operationName string, options ...opentracing.Star
 		o.Apply(&sso)
 	}
 	opts := []ddtrace.StartSpanOption{tracer.StartTime(sso.StartTime)}
-	for _, ref := range sso.References {
-		if v, ok := ref.ReferencedContext.(ddtrace.SpanContext); ok && ref.Type == opentracing.ChildOfRef {
-			opts = append(opts, tracer.ChildOf(v))
-			break // can only have one parent
+	// the first ChildOf reference, or else the first FollowsFrom reference, is
+	// the parent and the other references are kept as links
+	parent := -1
+	for i, ref := range sso.References {
+		if ref.Type == opentracing.ChildOfRef {
+			parent = i
+			break
 		}
+		if parent < 0 && ref.Type == opentracing.FollowsFromRef {
+			parent = i
+		}
+	}
+	var links []ddtrace.SpanLink
+	for i, ref := range sso.References {
+		if i == parent {
+			opts = append(opts, tracer.ChildOf(ref.ReferencedContext))
+			continue
+		}
+		link := ddtrace.SpanLink{Context: ref.ReferencedContext, Type: ddtrace.LinkChildOf}
+		if ref.Type == opentracing.FollowsFromRef {
+			link.Type = ddtrace.LinkFollowsFrom
+		}
+		links = append(links, link)
+	}
+	if len(links) > 0 {
+		opts = append(opts, func(cfg *ddtrace.StartSpanConfig) {
+			cfg.Links = append(cfg.Links, links...)
+		})
 	}
 	for k, v := range sso.Tags {
 		opts = append(opts, tracer.Tag(k, v))
@@ -0,0 +1,45 @@
+package opentracer
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace"
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/mocktracer"
+)
+
+func TestStartSpanReferences(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	ot := New()
+
+	a := ot.StartSpan("a")
+	b := ot.StartSpan("b")
+	c := ot.StartSpan("c")
+
+	t.Run("child-of", func(t *testing.T) {
+		s := ot.StartSpan("s",
+			opentracing.FollowsFrom(a.Context()),
+			opentracing.ChildOf(b.Context()),
+			opentracing.ChildOf(c.Context()),
+		).(mocktracer.Span)
+		assert.Equal(t, b.(mocktracer.Span).SpanID(), s.ParentID())
+		assert.Equal(t, []ddtrace.SpanLink{
+			{Context: a.Context(), Type: ddtrace.LinkFollowsFrom},
+			{Context: c.Context(), Type: ddtrace.LinkChildOf},
+		}, s.Links())
+	})
+
+	t.Run("follows-from", func(t *testing.T) {
+		s := ot.StartSpan("s",
+			opentracing.FollowsFrom(a.Context()),
+			opentracing.FollowsFrom(b.Context()),
+		).(mocktracer.Span)
+		assert.Equal(t, a.(mocktracer.Span).SpanID(), s.ParentID())
+		assert.Equal(t, []ddtrace.SpanLink{
+			{Context: b.Context(), Type: ddtrace.LinkFollowsFrom},
+		}, s.Links())
+	})
+}
@@ -208,6 +208,18 @@ func ChildOf(ctx ddtrace.SpanContext) StartSpanOption {
 	}
 }
 
+// WithLinks links the created span to the given span contexts, which the span
+// follows from without being their child. This allows a span to be caused by
+// many upstream spans, possibly from different traces, such as the span of a
+// batch consumer processing messages produced by many traces.
+func WithLinks(ctxs ...ddtrace.SpanContext) StartSpanOption {
+	return func(cfg *ddtrace.StartSpanConfig) {
+		for _, ctx := range ctxs {
+			cfg.Links = append(cfg.Links, ddtrace.SpanLink{Context: ctx, Type: ddtrace.LinkFollowsFrom})
+		}
+	}
+}
+
 // StartTime sets a custom time as the start time for the created span. By
 // default a span is started using the creation time.
 func StartTime(t time.Time) StartSpanOption {
@@ -41,6 +41,14 @@ type errorConfig struct {
 	stackSkip    uint
 }
 
+// spanLink references a span which is causally related to a span without being
+// its parent.
+type spanLink struct {
+	TraceID uint64
+	SpanID  uint64
+	Type    string
+}
+
 // logFields holds the results of one invocation of LogFields
 type logFields struct {
 	fields map[string]interface{}
@@ -65,6 +73,7 @@ type span struct {
 	ParentID uint64             `msg:"parent_id"`         // identifier of the span's direct parent
 	Error    int32              `msg:"error"`             // error status of the span; 0 means no errors
 	Logs     []*logFields
+	Links    []spanLink `msg:"-"` // spans causally related to this span, other than its parent
 
 	finished bool         `msg:"-"` // true if the span has been submitted to a tracer.
 	context  *spanContext `msg:"-"` // span propagation context
@@ -273,6 +273,15 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 			}
 		}
 	}
+	for _, link := range opts.Links {
+		if ctx, ok := link.Context.(*spanContext); ok {
+			span.Links = append(span.Links, spanLink{
+				TraceID: ctx.traceID,
+				SpanID:  ctx.spanID,
+				Type:    link.Type,
+			})
+		}
+	}
 	span.context = newSpanContext(span, context)
 	if context == nil || context.span == nil {
 		// this is either a root span or it has a remote parent, we should add the PID.
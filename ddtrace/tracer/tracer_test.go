@@ -229,6 +229,20 @@ func TestTracerStartChildSpan(t *testing.T) {
 	})
 }
 
+func TestTracerStartSpanLinks(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer()
+	a := tracer.StartSpan("a").(*span)
+	b := tracer.StartSpan("b").(*span)
+	batch := tracer.StartSpan("batch", WithLinks(a.Context(), b.Context())).(*span)
+
+	assert.Equal(uint64(0), batch.ParentID)
+	assert.Equal([]spanLink{
+		{TraceID: a.TraceID, SpanID: a.SpanID, Type: ddtrace.LinkFollowsFrom},
+		{TraceID: b.TraceID, SpanID: b.SpanID, Type: ddtrace.LinkFollowsFrom},
+	}, batch.Links)
+}
+
 func TestTracerBaggagePropagation(t *testing.T) {
 	assert := assert.New(t)
 	tracer := newTracer()
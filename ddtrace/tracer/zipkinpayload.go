@@ -115,7 +115,7 @@ func (p *zipkinPayload) convertSpans(spans spanList) []*traceformat.Span {
 		sfxSpan.LocalEndpoint = localEndpoint
 		sfxSpan.Timestamp = pointer.Int64(span.Start / 1000)
 		sfxSpan.Duration = pointer.Int64(span.Duration / 1000)
-		sfxSpan.Annotations = convertLogs(span.Logs)
+		sfxSpan.Annotations = append(convertLogs(span.Logs), convertLinks(span)...)
 
 		if span.Resource != "" && sfxSpan.Kind != nil && *sfxSpan.Kind == spanKindServer {
 			sfxSpan.Name = pointer.String(span.Resource)
@@ -157,9 +157,34 @@ func convertLogs(logs []*logFields) []*sfxtrace.Annotation {
 	return annotations
 }
 
+// convertLinks to annotations, as Zipkin has no native span links. Each link is
+// recorded as a "link" event at the start of the span.
+func convertLinks(s *span) []*sfxtrace.Annotation {
+	var annotations []*sfxtrace.Annotation
+
+	for _, link := range s.Links {
+		jsonLink, err := json.Marshal(map[string]string{
+			"event":         "link",
+			"link.trace_id": idToHex(link.TraceID),
+			"link.span_id":  idToHex(link.SpanID),
+			"link.type":     link.Type,
+		})
+		if err != nil {
+			continue
+		}
+		annotations = append(annotations, &sfxtrace.Annotation{
+			Value: pointer.String(string(jsonLink)),
+			// In microseconds.
+			Timestamp: pointer.Int64(s.Start / int64(time.Microsecond))})
+	}
+
+	return annotations
+}
+
 func deriveKind(s *span) *string {
 	if kind, ok := s.Meta[spanKind]; ok {
-		return pointer.String(kind)
+		// Zipkin kinds are upper case: CLIENT, SERVER, PRODUCER or CONSUMER
+		return pointer.String(strings.ToUpper(kind))
 	}
 
 	switch s.Type {
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/adityayuga/signalfx-go-tracing/ddtrace/ext"
 	"github.com/stretchr/testify/require"
 )
 
@@ -92,6 +93,48 @@ func TestZipkinPayloadDecode(t *testing.T) {
 	}
 }
 
+func TestZipkinPayloadKind(t *testing.T) {
+	for _, tt := range []struct {
+		kind, spanType string
+		want           string
+	}{
+		{"", ext.SpanTypeWeb, "SERVER"},
+		{"", ext.SpanTypeSQL, "CLIENT"},
+		{"", ext.SpanTypeMessageProducer, ""},
+		{ext.SpanKindProducer, ext.SpanTypeMessageProducer, "PRODUCER"},
+		{ext.SpanKindConsumer, ext.SpanTypeMessageConsumer, "CONSUMER"},
+		{"consumer", ext.SpanTypeMessageConsumer, "CONSUMER"},
+	} {
+		s := newBasicSpan("op")
+		s.Type = tt.spanType
+		if tt.kind != "" {
+			s.Meta[ext.SpanKind] = tt.kind
+		}
+		p := newZipkinPayload("test-service")
+		spans := p.convertSpans(spanList{s})
+		require.Len(t, spans, 1)
+		if tt.want == "" {
+			require.Nil(t, spans[0].Kind)
+			continue
+		}
+		require.Equal(t, tt.want, *spans[0].Kind)
+		require.Equal(t, strings.ToLower(tt.want), spans[0].Tags[ext.SpanKind])
+	}
+}
+
+func TestZipkinPayloadLinks(t *testing.T) {
+	s := newBasicSpan("op")
+	s.Links = []spanLink{{TraceID: 1, SpanID: 2, Type: "follows_from"}}
+	p := newZipkinPayload("test-service")
+	spans := p.convertSpans(spanList{s})
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Annotations, 1)
+	require.JSONEq(t,
+		`{"event":"link","link.trace_id":"0000000000000001","link.span_id":"0000000000000002","link.type":"follows_from"}`,
+		*spans[0].Annotations[0].Value)
+	require.Equal(t, s.Start/1000, *spans[0].Annotations[0].Timestamp)
+}
+
 func BenchmarkZipkinPayloadThroughput(b *testing.B) {
 	b.Run("10K", benchmarkZipkinPayloadThroughput(1))
 	b.Run("100K", benchmarkZipkinPayloadThroughput(10))
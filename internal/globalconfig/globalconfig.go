@@ -7,8 +7,10 @@ import "sync"
 var cfg = &config{}
 
 type config struct {
-	mu            sync.RWMutex
-	analyticsRate float64
+	mu                   sync.RWMutex
+	analyticsRate        float64
+	httpIgnorePaths      []string
+	httpIgnoreUserAgents []string
 }
 
 // AnalyticsRate returns the sampling rate at which events should be marked. It uses
@@ -26,3 +28,35 @@ func SetAnalyticsRate(rate float64) {
 	cfg.analyticsRate = rate
 	cfg.mu.Unlock()
 }
+
+// HTTPIgnorePaths returns the glob patterns of the request paths which HTTP server
+// integrations should not trace by default.
+func HTTPIgnorePaths() []string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return append([]string(nil), cfg.httpIgnorePaths...)
+}
+
+// SetHTTPIgnorePaths sets the glob patterns of the request paths which HTTP server
+// integrations should not trace by default.
+func SetHTTPIgnorePaths(globs []string) {
+	cfg.mu.Lock()
+	cfg.httpIgnorePaths = append([]string(nil), globs...)
+	cfg.mu.Unlock()
+}
+
+// HTTPIgnoreUserAgents returns the glob patterns of the User-Agent headers for which
+// HTTP server integrations should not trace requests by default.
+func HTTPIgnoreUserAgents() []string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return append([]string(nil), cfg.httpIgnoreUserAgents...)
+}
+
+// SetHTTPIgnoreUserAgents sets the glob patterns of the User-Agent headers for which
+// HTTP server integrations should not trace requests by default.
+func SetHTTPIgnoreUserAgents(globs []string) {
+	cfg.mu.Lock()
+	cfg.httpIgnoreUserAgents = append([]string(nil), globs...)
+	cfg.mu.Unlock()
+}
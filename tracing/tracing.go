@@ -4,25 +4,35 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/opentracer"
 	"github.com/adityayuga/signalfx-go-tracing/ddtrace/tracer"
+	"github.com/adityayuga/signalfx-go-tracing/internal/globalconfig"
 	"os"
+	"strings"
 )
 
 const (
 	signalfxServiceName = "SIGNALFX_SERVICE_NAME"
 	signalfxEndpointURL = "SIGNALFX_ENDPOINT_URL"
 	signalfxAccessToken = "SIGNALFX_ACCESS_TOKEN"
+
+	// comma-separated glob patterns of requests not traced by HTTP server integrations
+	signalfxHTTPIgnorePaths      = "SIGNALFX_HTTP_IGNORE_PATHS"
+	signalfxHTTPIgnoreUserAgents = "SIGNALFX_HTTP_IGNORE_USER_AGENTS"
 )
 
 var defaults = map[string]string{
-	signalfxServiceName: "SignalFx-Tracing",
-	signalfxEndpointURL: "http://localhost:9080/v1/trace",
-	signalfxAccessToken: "",
+	signalfxServiceName:          "SignalFx-Tracing",
+	signalfxEndpointURL:          "http://localhost:9080/v1/trace",
+	signalfxAccessToken:          "",
+	signalfxHTTPIgnorePaths:      "",
+	signalfxHTTPIgnoreUserAgents: "",
 }
 
 type config struct {
-	serviceName string
-	accessToken string
-	url         string
+	serviceName          string
+	accessToken          string
+	url                  string
+	httpIgnorePaths      []string
+	httpIgnoreUserAgents []string
 }
 
 // StartOption is a function that configures an option for Start
@@ -30,10 +40,23 @@ type StartOption = func(*config)
 
 func defaultConfig() *config {
 	return &config{
-		serviceName: envOrDefault(signalfxServiceName),
-		accessToken: envOrDefault(signalfxAccessToken),
-		url:         envOrDefault(signalfxEndpointURL),
+		serviceName:          envOrDefault(signalfxServiceName),
+		accessToken:          envOrDefault(signalfxAccessToken),
+		url:                  envOrDefault(signalfxEndpointURL),
+		httpIgnorePaths:      splitList(envOrDefault(signalfxHTTPIgnorePaths)),
+		httpIgnoreUserAgents: splitList(envOrDefault(signalfxHTTPIgnoreUserAgents)),
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 // envOrDefault gets the given environment variable if set otherwise a default value.
@@ -65,6 +88,25 @@ func WithEndpointURL(url string) StartOption {
 	}
 }
 
+// WithHTTPIgnorePaths sets glob patterns for the paths of requests which HTTP server
+// integrations should serve without tracing, such as health checks or metrics
+// endpoints. In patterns, '*' matches any sequence of characters. It overrides
+// the patterns set in the SIGNALFX_HTTP_IGNORE_PATHS environment variable.
+func WithHTTPIgnorePaths(globs ...string) StartOption {
+	return func(c *config) {
+		c.httpIgnorePaths = globs
+	}
+}
+
+// WithHTTPIgnoreUserAgents sets glob patterns for the User-Agent headers of requests
+// which HTTP server integrations should serve without tracing, such as probes. It
+// overrides the patterns set in the SIGNALFX_HTTP_IGNORE_USER_AGENTS environment variable.
+func WithHTTPIgnoreUserAgents(globs ...string) StartOption {
+	return func(c *config) {
+		c.httpIgnoreUserAgents = globs
+	}
+}
+
 // Start tracing globally
 func Start(opts ...StartOption) {
 	c := defaultConfig()
@@ -72,6 +114,8 @@ func Start(opts ...StartOption) {
 		fn(c)
 	}
 
+	globalconfig.SetHTTPIgnorePaths(c.httpIgnorePaths)
+	globalconfig.SetHTTPIgnoreUserAgents(c.httpIgnoreUserAgents)
 	tracer.Start(
 		tracer.WithServiceName(c.serviceName),
 		tracer.WithZipkin(c.serviceName, c.url, c.accessToken))